@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/lox/buildkite-accounter/internal/audit"
+)
+
+// openAuditLog opens --audit-log, or audit.log in the cache dir, for the
+// token of the client created last
+func (c *cli) openAuditLog() (*audit.Log, error) {
+	path := c.AuditLog
+	if path == "" {
+		path = filepath.Join(c.CacheDir, "audit.log")
+	}
+	return audit.Open(path, c.token)
+}
+
+// auditMutation makes a mutation and records it in the audit log with its
+// response, or with entry.DryRun only records it
+func auditMutation(auditLog *audit.Log, entry audit.Entry, mutate func() ([]byte, error)) error {
+	if entry.DryRun {
+		return auditLog.Record(entry, nil, nil)
+	}
+
+	response, err := mutate()
+	if aerr := auditLog.Record(entry, response, err); aerr != nil {
+		return fmt.Errorf("failed to write audit log: %w", aerr)
+	}
+	return err
+}
@@ -0,0 +1,228 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	errors "golang.org/x/xerrors"
+)
+
+// batcher holds the orgs whose first pages of members are fetched in batches,
+// and the first pages fetched for orgs other than the one that needed them
+type batcher struct {
+	sync.Mutex
+	size    int
+	pending []string
+	pages   map[string]batchedPage
+}
+
+type batchedPage struct {
+	members []OrgMember
+	next    string
+	err     error
+}
+
+// BatchOrgMembers fetches the first pages of members of up to size of the
+// orgs in one request, with an alias for each org, once GetOrgMembersPages
+// needs the first of them. Orgs with a page or less of members are fetched
+// entirely by the batch, larger orgs continue a page at a time. A size of one
+// or less fetches each org separately, and sizes over MaxBatchOrgs are capped
+func (c *Client) BatchOrgMembers(orgSlugs []string, size int) {
+	if size > MaxBatchOrgs {
+		size = MaxBatchOrgs
+	}
+
+	c.batch.Lock()
+	defer c.batch.Unlock()
+
+	c.batch.size = size
+	c.batch.pending = append([]string(nil), orgSlugs...)
+	c.batch.pages = map[string]batchedPage{}
+}
+
+// batchedFirstPage returns the first page of an org's members from a batch,
+// fetching a batch of it and the pending orgs after it if it's pending, or
+// false if the org isn't batched or the batch failed, in which case the page
+// should be fetched on its own
+func (c *Client) batchedFirstPage(ctx context.Context, orgSlug string) ([]OrgMember, string, bool, error) {
+	c.batch.Lock()
+
+	if page, ok := c.batch.pages[orgSlug]; ok {
+		delete(c.batch.pages, orgSlug)
+		c.batch.Unlock()
+		return page.members, page.next, true, page.err
+	}
+
+	if c.batch.size <= 1 || !c.batch.take(orgSlug) {
+		c.batch.Unlock()
+		return nil, "", false, nil
+	}
+
+	orgSlugs := []string{orgSlug}
+	for len(orgSlugs) < c.batch.size && len(c.batch.pending) > 0 {
+		orgSlugs = append(orgSlugs, c.batch.pending[0])
+		c.batch.pending = c.batch.pending[1:]
+	}
+
+	c.batch.Unlock()
+
+	if len(orgSlugs) == 1 {
+		return nil, "", false, nil
+	}
+
+	pages, err := c.getOrgMembersFirstPages(ctx, orgSlugs)
+	if err != nil {
+		// the orgs in the failed batch are fetched on their own, so that
+		// errors are reported for the orgs they belong to
+		if c.logf != nil {
+			c.logf("Batch of %s failed, fetching them on their own: %v", strings.Join(orgSlugs, ", "), err)
+		}
+		return nil, "", false, nil
+	}
+
+	c.batch.Lock()
+	for _, slug := range orgSlugs[1:] {
+		c.batch.pages[slug] = pages[slug]
+	}
+	c.batch.Unlock()
+
+	page := pages[orgSlug]
+	return page.members, page.next, true, page.err
+}
+
+// take removes an org from the pending orgs, returning whether it was pending
+func (b *batcher) take(orgSlug string) bool {
+	for i, slug := range b.pending {
+		if slug == orgSlug {
+			b.pending = append(b.pending[:i:i], b.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// getOrgMembersFirstPages gets the first page of members of each org in one
+// request, failing only if the request does
+func (c *Client) getOrgMembersFirstPages(ctx context.Context, orgSlugs []string) (map[string]batchedPage, error) {
+	query, vars, err := OrgMembersBatchQuery(orgSlugs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.DoContext(ctx, query, vars)
+	if err != nil {
+		return nil, errors.Errorf("failed to get the first pages of members: %w", err)
+	}
+
+	t := time.Now()
+	defer func() {
+		addDecodeTime(ctx, time.Since(t))
+	}()
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := resp.DecodeInto(&body); err != nil {
+		return nil, err
+	}
+
+	pages := map[string]batchedPage{}
+	for i, slug := range orgSlugs {
+		var org *OrgMembersPageOrganization
+		if err := decodeStrict(body.Data[fmt.Sprintf("org%d", i)], &org); err != nil {
+			return nil, errors.Errorf("error decoding response: %w", err)
+		}
+
+		var page batchedPage
+		page.members, page.next, page.err = orgMembersPage(org)
+		pages[slug] = page
+	}
+
+	return pages, nil
+}
+
+// OrgMembersBatchQuery returns the GraphQL query and variables used to fetch
+// the first pages of members of orgs in one request
+func OrgMembersBatchQuery(orgSlugs []string) (string, map[string]interface{}, error) {
+	query, err := orgMembersBatchQuery(len(orgSlugs))
+	if err != nil {
+		return "", nil, err
+	}
+
+	vars := map[string]interface{}{}
+	for i, slug := range orgSlugs {
+		vars[fmt.Sprintf("org%d", i)] = slug
+	}
+	return query, vars, nil
+}
+
+// MaxBatchOrgs is the most orgs fetched in one batch, so a request can't fan
+// out into an unbounded number of orgs
+const MaxBatchOrgs = 25
+
+// orgMembersBatchOperation is the name of the batch query, whose fields vary
+// with the number of orgs in it
+const orgMembersBatchOperation = "OrgMembersBatch"
+
+// IsOrgMembersBatchQuery returns whether a query is a batch of the first pages
+// of members of orgs as sent by BatchOrgMembers, matched by its operation name
+// and then its fields for the number of orgs, which is at most MaxBatchOrgs
+func IsOrgMembersBatchQuery(query string) bool {
+	typ, name := operation(query)
+	if typ != "query" || name != orgMembersBatchOperation {
+		return false
+	}
+
+	n := strings.Count(query, ": organization(slug: $org")
+	if n == 0 || n > MaxBatchOrgs {
+		return false
+	}
+
+	expected, err := orgMembersBatchQuery(n)
+	return err == nil && strings.TrimSpace(expected) == strings.TrimSpace(query)
+}
+
+// orgMembersBatchQuery returns a query for the first page of members of n
+// orgs, with the same selection of members as OrgMembersPage so that each org
+// decodes into its generated type
+func orgMembersBatchQuery(n int) (string, error) {
+	op := OrgMembersPage_Operation
+
+	start := strings.Index(op, "members(")
+	if start < 0 {
+		return "", errors.New("no members in the OrgMembersPage query")
+	}
+
+	end, depth := -1, 0
+	for i := start; i < len(op) && end < 0; i++ {
+		switch op[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				end = i + 1
+			}
+		}
+	}
+	if end < 0 {
+		return "", errors.New("unbalanced members in the OrgMembersPage query")
+	}
+
+	members := strings.Replace(op[start:end], ", after: $after", "", 1)
+
+	var params, fields strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			params.WriteString(", ")
+		}
+		fmt.Fprintf(&params, "$org%d: ID!", i)
+		fmt.Fprintf(&fields, "\torg%d: organization(slug: $org%d) {\n\t\t%s\n\t}\n", i, i, members)
+	}
+
+	return fmt.Sprintf("query %s (%s) {\n%s}", orgMembersBatchOperation, params.String(), fields.String()), nil
+}
@@ -0,0 +1,15 @@
+package buildkite
+
+import "testing"
+
+func TestIsOrgMembersBatchQuery(t *testing.T) {
+	for _, n := range []int{1, 2, MaxBatchOrgs, MaxBatchOrgs + 1} {
+		query, err := orgMembersBatchQuery(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := IsOrgMembersBatchQuery(query), n <= MaxBatchOrgs; got != want {
+			t.Errorf("IsOrgMembersBatchQuery() of %d orgs = %v, want %v", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package buildkite_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/buildkite/buildkitetest"
+)
+
+func TestBatchOrgMembersFallsBackOnFailure(t *testing.T) {
+	s := buildkitetest.NewServer()
+	defer s.Close()
+	s.AddOrg("acme", buildkite.OrgMember{ID: "u1", Email: "alice@acme.com", Name: "Alice", Role: "ADMIN"})
+	s.AddOrg("llama", buildkite.OrgMember{ID: "u2", Email: "bob@acme.com", Name: "Bob", Role: "MEMBER"})
+
+	var logs []string
+	client, err := buildkite.NewClient("test-token",
+		buildkite.WithEndpoint(s.URL),
+		buildkite.WithLogger(func(format string, v ...interface{}) {
+			logs = append(logs, fmt.Sprintf(format, v...))
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.BatchOrgMembers([]string{"acme", "llama"}, 2)
+	s.FailNext(http.StatusBadRequest, "Query has too many fields")
+
+	for _, org := range []string{"acme", "llama"} {
+		members, err := client.GetOrgMembers(org)
+		if err != nil {
+			t.Fatalf("GetOrgMembers(%q) error = %v", org, err)
+		}
+		if len(members) != 1 {
+			t.Errorf("got %d %s members, want 1", len(members), org)
+		}
+	}
+
+	if len(logs) != 1 || !strings.Contains(logs[0], "acme, llama") || !strings.Contains(logs[0], "failed to get the first pages of members") {
+		t.Errorf("logged %q, want the failed batch", logs)
+	}
+}
@@ -0,0 +1,91 @@
+package buildkite
+
+import (
+	"sync"
+	"time"
+
+	errors "golang.org/x/xerrors"
+)
+
+// ErrCircuitOpen is returned without making a request while the circuit
+// breaker is open after consecutive failures
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// ErrUnavailable matches errors that suggest the API is degraded rather than
+// the request being wrong, like transport errors, 5xx responses and rate
+// limiting, as well as ErrCircuitOpen
+var ErrUnavailable = errors.New("api unavailable")
+
+// unavailableError marks an error as counting towards the circuit breaker
+type unavailableError struct {
+	err error
+}
+
+func (e *unavailableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *unavailableError) Unwrap() error {
+	return e.err
+}
+
+// Is matches ErrUnavailable
+func (e *unavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+// breaker opens after threshold consecutive failures, failing requests fast
+// until cooldown has passed, then lets a single request through to test
+// whether the API has recovered
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return nil
+	}
+
+	if wait := b.cooldown - time.Since(b.openedAt); wait > 0 || b.probing {
+		if wait < 0 {
+			wait = 0
+		}
+		return &unavailableError{errors.Errorf("%d consecutive requests failed, next attempt in %s: %w",
+			b.failures, wait.Round(time.Second), ErrCircuitOpen)}
+	}
+
+	b.probing = true
+	return nil
+}
+
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if !errors.Is(err, ErrUnavailable) {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// open returns whether the breaker is failing requests fast
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.threshold > 0 && b.failures >= b.threshold && time.Since(b.openedAt) < b.cooldown
+}
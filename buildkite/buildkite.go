@@ -0,0 +1,771 @@
+package buildkite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"iter"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	errors "golang.org/x/xerrors"
+)
+
+// ErrRequestBudgetExceeded is returned by requests made after the client's
+// request budget is exhausted
+var ErrRequestBudgetExceeded = errors.New("request budget exceeded")
+
+// Errors that requests can fail with, wrapped with more detail
+var (
+	// ErrUnauthorized is a token that's invalid or lacks a required scope
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrOrgNotFound is an org that doesn't exist or the token can't access
+	ErrOrgNotFound = errors.New("organization not found")
+	// ErrRateLimited is a request rejected by the API's rate limit
+	ErrRateLimited = errors.New("rate limited")
+	// ErrGraphQL is a request that returned GraphQL errors
+	ErrGraphQL = errors.New("graphql error")
+)
+
+const (
+	// DefaultEndpoint is the Buildkite GraphQL API
+	DefaultEndpoint = "https://graphql.buildkite.com/v1"
+)
+
+// NewClient returns a new Buildkite GraphQL client, which makes requests to
+// DefaultEndpoint with http.DefaultClient unless options say otherwise
+func NewClient(token string, opts ...Option) (*Client, error) {
+	header := make(http.Header)
+	header.Add("Content-Type", "application/json")
+	header.Add("Authorization", "Bearer "+token)
+
+	c := &Client{
+		token:      token,
+		header:     header,
+		httpClient: http.DefaultClient,
+		traceCtx:   context.Background(),
+	}
+
+	for _, opt := range append([]Option{WithEndpoint(DefaultEndpoint)}, opts...) {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// NewClientWithHTTPClient returns a new Buildkite GraphQL client that makes
+// requests with the provided http.Client
+func NewClientWithHTTPClient(token string, httpClient *http.Client) (*Client, error) {
+	return NewClient(token, WithHTTPClient(httpClient))
+}
+
+// NewClientWithEndpoint returns a new Buildkite GraphQL client that makes
+// requests to an alternate endpoint, like a caching proxy
+func NewClientWithEndpoint(token string, endpoint string, httpClient *http.Client) (*Client, error) {
+	return NewClient(token, WithEndpoint(endpoint), WithHTTPClient(httpClient))
+}
+
+// Client is a Buildkite GraphQL client
+type Client struct {
+	token      string
+	endpoint   *url.URL
+	httpClient *http.Client
+	header     http.Header
+	stats      statsRecorder
+	breaker    breaker
+	batch      batcher
+	retry      retryPolicy
+	logf       Logf
+	// traceCtx is the parent of spans of requests made without a context
+	traceCtx context.Context
+
+	maxRequests int
+	// inflight is a semaphore limiting concurrent requests, if set
+	inflight chan struct{}
+}
+
+// Stats returns an accounting of the requests made by the client so far
+func (c *Client) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+// SetMaxRequests limits the number of requests the client will make, after
+// which requests fail with ErrRequestBudgetExceeded, zero means no limit
+func (c *Client) SetMaxRequests(n int) {
+	c.maxRequests = n
+}
+
+// SetCircuitBreaker fails requests fast with ErrCircuitOpen for cooldown after
+// threshold consecutive requests fail with ErrUnavailable, zero disables it
+func (c *Client) SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	c.breaker.threshold = threshold
+	c.breaker.cooldown = cooldown
+}
+
+// SetConcurrency limits the number of requests in flight at once, zero means
+// no limit
+func (c *Client) SetConcurrency(n int) {
+	c.inflight = nil
+	if n > 0 {
+		c.inflight = make(chan struct{}, n)
+	}
+}
+
+// CircuitOpen returns whether the circuit breaker is failing requests fast
+func (c *Client) CircuitOpen() bool {
+	return c.breaker.open()
+}
+
+// SetUserAgent sets the User-Agent header sent with requests
+func (c *Client) SetUserAgent(ua string) {
+	c.header.Set("User-Agent", ua)
+}
+
+// Do sends a GraphQL query with bound variables and returns a Response
+func (c *Client) Do(query string, vars map[string]interface{}) (*Response, error) {
+	return c.DoContext(c.traceCtx, query, vars)
+}
+
+// DoContext is Do with a context, which requests are made with and whose
+// span is the parent of the request's span
+func (c *Client) DoContext(ctx context.Context, query string, vars map[string]interface{}) (resp *Response, err error) {
+	opType, opName := operation(query)
+	spanName := "graphql " + opName
+	if opName == "" {
+		spanName = "graphql " + opType
+	}
+	ctx, span := tracing.Start(ctx, spanName,
+		attribute.String("graphql.operation.type", opType),
+		attribute.String("graphql.operation.name", opName),
+	)
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		}
+		tracing.End(span, err)
+	}()
+
+	b, err := json.MarshalIndent(struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}{
+		Query:     strings.TrimSpace(query),
+		Variables: vars,
+	}, "", "  ")
+	if err != nil {
+		return nil, errors.Errorf("failed to marshal vars: %w", err)
+	}
+
+	// a mutation that failed might still have been made, so only queries are
+	// retried
+	retries := c.retry.retries
+	if strings.HasPrefix(strings.TrimSpace(query), "mutation") {
+		retries = 0
+	}
+
+	wait := c.retry.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.do(withRetryAttempt(ctx, attempt), b)
+		if err == nil || attempt >= retries || !errors.Is(err, ErrUnavailable) || errors.Is(err, ErrCircuitOpen) {
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt+1),
+			attribute.String("error", err.Error()),
+		))
+		if c.logf != nil {
+			c.logf("Retrying request in %v, attempt %d of %d failed: %v", wait, attempt+1, retries+1, err)
+		}
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// operation returns the type and name of a GraphQL operation, which are
+// empty for an anonymous query
+func operation(query string) (string, string) {
+	fields := strings.FieldsFunc(strings.TrimSpace(query), func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '(' || r == '{'
+	})
+	if len(fields) == 0 || strings.HasPrefix(strings.TrimSpace(query), "{") {
+		return "query", ""
+	}
+	if len(fields) == 1 || strings.HasPrefix(fields[1], "$") {
+		return fields[0], ""
+	}
+	return fields[0], fields[1]
+}
+
+// do sends a marshaled GraphQL request once
+func (c *Client) do(ctx context.Context, b []byte) (*Response, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	if !c.stats.reserve(c.maxRequests) {
+		return nil, errors.Errorf("%d requests made: %w", c.maxRequests, ErrRequestBudgetExceeded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint.String(), bytes.NewReader(b))
+	if err != nil {
+		return nil, errors.Errorf("failed to create http request: %w", err)
+	}
+	req.Header = c.header
+
+	if os.Getenv(`DEBUG`) != "" {
+		if dump, err := httputil.DumpRequest(req, true); err == nil {
+			c.debugf("DEBUG request uri=%s\n%s", req.URL, dump)
+		}
+	}
+
+	if c.inflight != nil {
+		c.inflight <- struct{}{}
+	}
+
+	t := time.Now()
+
+	resp, err := c.httpClient.Do(req)
+	if c.inflight != nil {
+		<-c.inflight
+	}
+	if err != nil {
+		c.stats.recordRequest(len(b), nil, time.Since(t))
+		err = &unavailableError{errors.Errorf("request failed: %w", err)}
+		c.breaker.record(err)
+		return nil, err
+	}
+
+	if os.Getenv(`DEBUG`) != "" {
+		if dump, err := httputil.DumpResponse(resp, true); err == nil {
+			c.debugf("DEBUG response uri=%s\n%s", req.URL, dump)
+		}
+	}
+
+	err = checkResponseForErrors(resp)
+	c.stats.recordRequest(len(b), resp, time.Since(t))
+	c.breaker.record(err)
+
+	return &Response{resp}, err
+}
+
+// debugf logs a dump with the client's logger, or prints it to stdout
+// without one
+func (c *Client) debugf(format string, v ...interface{}) {
+	if c.logf != nil {
+		c.logf(format, v...)
+		return
+	}
+	fmt.Printf(format+"\n", v...)
+}
+
+// Response is a GraphQL response
+type Response struct {
+	*http.Response
+}
+
+// DecodeInto decodes a JSON body into the provided type, failing with
+// ErrUnexpectedShape if it's missing fields the type expects
+func (r *Response) DecodeInto(v interface{}) error {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return errors.Errorf("failed to read body: %w", err)
+	}
+	if err := decodeStrict(data, v); err != nil {
+		return errors.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+type responseError struct {
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (r *responseError) Error() string {
+	return fmt.Sprintf("graphql error: %s", r.messages())
+}
+
+func (r *responseError) messages() string {
+	var errors []string
+	for _, err := range r.Errors {
+		errors = append(errors, err.Message)
+	}
+	return strings.Join(errors, ", ")
+}
+
+// Is matches ErrGraphQL
+func (r *responseError) Is(target error) bool {
+	return target == ErrGraphQL
+}
+
+func checkResponseForErrors(r *http.Response) error {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return errors.Errorf("failed to read body: %w", err)
+	}
+
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewBuffer(data))
+	r.ContentLength = int64(len(data))
+
+	var errResp responseError
+	_ = json.Unmarshal(data, &errResp)
+
+	status := r.Status
+	if len(errResp.Errors) > 0 {
+		status += ": " + errResp.messages()
+	}
+
+	switch {
+	case r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden:
+		return errors.Errorf("response returned status %s: %w", status, ErrUnauthorized)
+	case r.StatusCode == http.StatusTooManyRequests:
+		return &unavailableError{errors.Errorf("response returned status %s: %w", status, ErrRateLimited)}
+	case r.StatusCode >= http.StatusInternalServerError:
+		return &unavailableError{errors.Errorf("response returned status %s", status)}
+	case len(errResp.Errors) > 0:
+		return &errResp
+	case r.StatusCode != http.StatusOK:
+		return errors.Errorf("response returned status %s", r.Status)
+	}
+
+	return nil
+}
+
+type pageInfo struct {
+	HasNextPage bool `json:"hasNextPage"`
+	// EndCursor is null for an empty page
+	EndCursor string `json:"endCursor,omitempty"`
+}
+
+type Authorization struct {
+	ID                     string
+	Email                  string
+	Name                   string
+	CreatedAt              time.Time
+	ExpireAt               *time.Time
+	RevokedAt              *time.Time
+	UserSessionDestroyedAt *time.Time
+	// State is like CREATED, VERIFIED, EXPIRED or REVOKED
+	State string
+}
+
+type OrgMember struct {
+	ID            string
+	Name          string
+	Email         string
+	Role          string
+	Bot           bool
+	Complimentary bool
+	CreatedAt     time.Time
+	Authorization *Authorization
+	// MembershipID is the ID of the user's membership of the org, used to remove them
+	MembershipID string
+}
+
+// OrgMembersQuery returns the GraphQL query and variables used to fetch a page
+// of org members, starting after the provided cursor
+func OrgMembersQuery(orgSlug string, after string) (string, map[string]interface{}) {
+	vars, _ := variables(orgMembersPageInput(orgSlug, after))
+	return OrgMembersPage_Operation, vars
+}
+
+func orgMembersPageInput(orgSlug string, after string) *__OrgMembersPageInput {
+	input := &__OrgMembersPageInput{OrgSlug: orgSlug}
+	if after != "" {
+		input.After = &after
+	}
+	return input
+}
+
+// nextOrgMembersPage gets a page of an org's members, from a batch if it's the
+// first page of an org passed to BatchOrgMembers
+func (c *Client) nextOrgMembersPage(ctx context.Context, orgSlug string, after string) ([]OrgMember, string, error) {
+	if after == "" {
+		if members, next, ok, err := c.batchedFirstPage(ctx, orgSlug); ok {
+			return members, next, err
+		}
+	}
+	return c.getOrgMembersPage(ctx, orgSlug, after)
+}
+
+func (c *Client) getOrgMembersPage(ctx context.Context, orgSlug string, after string) ([]OrgMember, string, error) {
+	input := orgMembersPageInput(orgSlug, after)
+
+	r, err := OrgMembersPage(ctx, graphqlClient{c}, input.OrgSlug, input.After)
+	if err != nil {
+		return nil, "", errors.Errorf("failed to get authorizations: %w", err)
+	}
+
+	t := time.Now()
+	defer func() {
+		addDecodeTime(ctx, time.Since(t))
+	}()
+
+	return orgMembersPage(r.Organization)
+}
+
+// orgMembersPage converts a page of an org's members, returning them and the
+// cursor of the next page, which is empty for the last page
+func orgMembersPage(org *OrgMembersPageOrganization) ([]OrgMember, string, error) {
+	if org == nil {
+		return nil, "", errors.Errorf("failed to get authorizations: %w", ErrOrgNotFound)
+	} else if org.Members == nil {
+		return nil, "", nil
+	}
+
+	var members []OrgMember
+
+	for _, edge := range org.Members.Edges {
+		if edge == nil || edge.Node == nil {
+			continue
+		}
+		node := edge.Node
+
+		member := OrgMember{
+			ID:            node.User.Id,
+			MembershipID:  node.Id,
+			Name:          node.User.Name,
+			Email:         node.User.Email,
+			Role:          string(node.Role),
+			Bot:           node.User.Bot,
+			Complimentary: node.Complimentary,
+			CreatedAt:     node.CreatedAt,
+		}
+
+		if auths := node.Sso.Authorizations; auths != nil && len(auths.Edges) > 0 && auths.Edges[0] != nil && auths.Edges[0].Node != nil {
+			auth := auths.Edges[0].Node
+
+			member.Authorization = &Authorization{
+				ID:                     auth.Id,
+				CreatedAt:              auth.CreatedAt,
+				ExpireAt:               auth.ExpiredAt,
+				RevokedAt:              auth.RevokedAt,
+				UserSessionDestroyedAt: auth.UserSessionDestroyedAt,
+				State:                  string(auth.State),
+			}
+
+			if auth.Identity != nil {
+				member.Authorization.Email = stringValue(auth.Identity.Email)
+				member.Authorization.Name = stringValue(auth.Identity.Name)
+			}
+		}
+
+		members = append(members, member)
+	}
+
+	pageInfo := org.Members.PageInfo
+	if pageInfo.HasNextPage && stringValue(pageInfo.EndCursor) != "" {
+		return members, *pageInfo.EndCursor, nil
+	}
+
+	return members, "", nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// GetOrgMembers gets org members and their last authorization
+func (c *Client) GetOrgMembers(orgSlug string) ([]OrgMember, error) {
+	var result []OrgMember
+
+	err := c.GetOrgMembersPages(orgSlug, "", func(members []OrgMember, next string) error {
+		result = append(result, members...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// errStopped stops paging when the consumer of an iterator stops early
+var errStopped = errors.New("stopped")
+
+// OrgMembers returns an iterator over the members of an org that fetches them
+// a page at a time as they're consumed, so only a page is held in memory at
+// once. If a page fails to load, it yields the error and stops
+func (c *Client) OrgMembers(orgSlug string) iter.Seq2[OrgMember, error] {
+	return func(yield func(OrgMember, error) bool) {
+		err := c.GetOrgMembersPages(orgSlug, "", func(members []OrgMember, next string) error {
+			for _, m := range members {
+				if !yield(m, nil) {
+					return errStopped
+				}
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopped) {
+			yield(OrgMember{}, err)
+		}
+	}
+}
+
+// GetOrgMembersPages gets org members a page at a time starting after the
+// provided cursor, calling f with each page and the cursor of the next page,
+// which is empty for the last page
+func (c *Client) GetOrgMembersPages(orgSlug string, after string, f func(members []OrgMember, next string) error) (err error) {
+	t := time.Now()
+	var stats OrgStats
+
+	defer func() {
+		stats.Duration = time.Since(t)
+		c.stats.recordOrg(orgSlug, stats)
+	}()
+
+	ctx, span := tracing.Start(c.traceCtx, "org members", attribute.String("org", orgSlug))
+	defer func() {
+		span.SetAttributes(attribute.Int("pages", stats.Pages), attribute.Int("members", stats.Members))
+		tracing.End(span, err)
+	}()
+
+	ctx = withDecodeTimer(ctx, &stats.Decode)
+
+	for {
+		pt := time.Now()
+		members, nextAfter, err := c.nextOrgMembersPage(ctx, orgSlug, after)
+		if err != nil {
+			return err
+		}
+
+		stats.Pages++
+		stats.Members += len(members)
+		stats.PageDurations = append(stats.PageDurations, time.Since(pt))
+
+		if err := f(members, nextAfter); err != nil {
+			return err
+		}
+
+		if nextAfter == "" {
+			break
+		}
+
+		after = nextAfter
+	}
+
+	return nil
+}
+
+const removeOrgMemberMutation = `mutation ($id: ID!) {
+	organizationMemberDelete(input: {id: $id}) {
+		deletedOrganizationMemberID
+	}
+}`
+
+// RemoveOrgMember removes a member from an org by their membership ID,
+// returning the response body for auditing, even if it failed
+func (c *Client) RemoveOrgMember(membershipID string) ([]byte, error) {
+	resp, err := c.Do(removeOrgMemberMutation, map[string]interface{}{
+		`id`: membershipID,
+	})
+
+	var body []byte
+	if resp != nil {
+		body, _ = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	if err != nil {
+		return body, errors.Errorf("failed to remove org member: %w", err)
+	}
+	return body, nil
+}
+
+const revokeSSOAuthorizationMutation = `mutation ($id: ID!) {
+	ssoAuthorizationRevoke(input: {id: $id}) {
+		ssoAuthorization {
+			id
+			state
+			revokedAt
+		}
+	}
+}`
+
+// RevokeSSOAuthorization revokes a member's SSO authorization by its ID,
+// returning the response body for auditing, even if it failed
+func (c *Client) RevokeSSOAuthorization(authorizationID string) ([]byte, error) {
+	body, err := c.mutate(revokeSSOAuthorizationMutation, map[string]interface{}{
+		`id`: authorizationID,
+	})
+	if err != nil {
+		return body, errors.Errorf("failed to revoke sso authorization: %w", err)
+	}
+	return body, nil
+}
+
+const destroyUserSessionsMutation = `mutation ($id: ID!) {
+	userSessionDestroy(input: {organizationMemberID: $id}) {
+		organizationMember {
+			id
+		}
+	}
+}`
+
+// DestroyUserSessions logs a member out of an org by their membership ID,
+// destroying their sessions, returning the response body for auditing, even
+// if it failed
+func (c *Client) DestroyUserSessions(membershipID string) ([]byte, error) {
+	body, err := c.mutate(destroyUserSessionsMutation, map[string]interface{}{
+		`id`: membershipID,
+	})
+	if err != nil {
+		return body, errors.Errorf("failed to destroy user sessions: %w", err)
+	}
+	return body, nil
+}
+
+// Organization is an organization visible to the token
+type Organization struct {
+	ID   string
+	Slug string
+	Name string
+}
+
+const organizationsQuery = `query ($after: String) {
+	viewer {
+		organizations(first: 100, after: $after) {
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+			edges {
+				node {
+					id
+					slug
+					name
+				}
+			}
+		}
+	}
+}`
+
+// GetOrganizations gets the organizations the token has access to
+func (c *Client) GetOrganizations() ([]Organization, error) {
+	after := ""
+	var result []Organization
+
+	for {
+		resp, err := c.Do(organizationsQuery, map[string]interface{}{
+			`after`: after,
+		})
+		if err != nil {
+			return nil, errors.Errorf("failed to get organizations: %w", err)
+		}
+
+		var r struct {
+			Data struct {
+				Viewer struct {
+					Organizations struct {
+						PageInfo pageInfo `json:"pageInfo"`
+						Edges    []struct {
+							Node struct {
+								ID   string `json:"id"`
+								Slug string `json:"slug"`
+								Name string `json:"name"`
+							} `json:"node"`
+						} `json:"edges"`
+					} `json:"organizations"`
+				} `json:"viewer"`
+			} `json:"data"`
+		}
+
+		if err := resp.DecodeInto(&r); err != nil {
+			return nil, err
+		}
+
+		for _, edge := range r.Data.Viewer.Organizations.Edges {
+			result = append(result, Organization{
+				ID:   edge.Node.ID,
+				Slug: edge.Node.Slug,
+				Name: edge.Node.Name,
+			})
+		}
+
+		pageInfo := r.Data.Viewer.Organizations.PageInfo
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == "" {
+			break
+		}
+
+		after = pageInfo.EndCursor
+	}
+
+	return result, nil
+}
+
+const organizationQuery = `query ($orgSlug: ID!) {
+	organization(slug: $orgSlug) {
+		id
+		slug
+		name
+	}
+}`
+
+// OrganizationQuery returns the GraphQL query and variables used to check an
+// org exists
+func OrganizationQuery(orgSlug string) (string, map[string]interface{}) {
+	return organizationQuery, map[string]interface{}{
+		`orgSlug`: orgSlug,
+	}
+}
+
+// MembersQueries returns the queries used to list the orgs of a token, check
+// orgs exist and page through their members, other than the batches of first
+// pages matched by IsOrgMembersBatchQuery
+func MembersQueries() []string {
+	return []string{organizationsQuery, organizationQuery, OrgMembersPage_Operation}
+}
+
+// GetOrganization gets an organization by slug, returning ErrOrgNotFound if
+// it doesn't exist or the token can't access it
+func (c *Client) GetOrganization(orgSlug string) (*Organization, error) {
+	resp, err := c.Do(OrganizationQuery(orgSlug))
+	if err != nil {
+		return nil, errors.Errorf("failed to get organization: %w", err)
+	}
+
+	var r struct {
+		Data struct {
+			Organization *struct {
+				ID   string `json:"id"`
+				Slug string `json:"slug"`
+				Name string `json:"name"`
+			} `json:"organization"`
+		} `json:"data"`
+	}
+
+	if err := resp.DecodeInto(&r); err != nil {
+		return nil, err
+	}
+
+	if r.Data.Organization == nil {
+		return nil, errors.Errorf("organization %q not found or token lacks access: %w", orgSlug, ErrOrgNotFound)
+	}
+
+	return &Organization{
+		ID:   r.Data.Organization.ID,
+		Slug: r.Data.Organization.Slug,
+		Name: r.Data.Organization.Name,
+	}, nil
+}
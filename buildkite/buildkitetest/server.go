@@ -0,0 +1,1106 @@
+// Package buildkitetest provides a fake Buildkite GraphQL server for testing
+// code that uses the buildkite client without a real token
+package buildkitetest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+)
+
+// Server is an httptest-backed fake of the Buildkite GraphQL API
+type Server struct {
+	*httptest.Server
+
+	// Token is the bearer token the server requires, any token is accepted if empty
+	Token string
+
+	// PageSize is the number of members returned per page, defaults to 100
+	PageSize int
+
+	// Viewer is the user the token belongs to
+	Viewer buildkite.Viewer
+
+	// Scopes are the token's scopes, returned by the REST access token endpoint
+	Scopes []string
+
+	mu        sync.Mutex
+	orgs      map[string][]buildkite.OrgMember
+	pipelines map[string][]pipeline
+	teams     map[string][]buildkite.Team
+	providers map[string][]buildkite.SSOProvider
+	invites   map[string][]buildkite.Invitation
+	failures  []failure
+	requests  int
+	limit     int
+	remaining int
+}
+
+type pipeline struct {
+	buildkite.Pipeline
+	builds []buildkite.Build
+}
+
+type failure struct {
+	status  int
+	message string
+}
+
+// NewServer starts and returns a new Server, callers should call Close when finished
+func NewServer() *Server {
+	s := &Server{
+		PageSize:  100,
+		orgs:      make(map[string][]buildkite.OrgMember),
+		pipelines: make(map[string][]pipeline),
+		teams:     make(map[string][]buildkite.Team),
+		providers: make(map[string][]buildkite.SSOProvider),
+		invites:   make(map[string][]buildkite.Invitation),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns a buildkite client that sends its requests to the server
+func (s *Server) Client(token string) (*buildkite.Client, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, err
+	}
+	return buildkite.NewClientWithHTTPClient(token, &http.Client{
+		Transport: &rewriteTransport{target: u},
+	})
+}
+
+// AddOrg adds an org with the provided members to the server
+func (s *Server) AddOrg(slug string, members ...buildkite.OrgMember) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orgs[slug] = append(s.orgs[slug], members...)
+}
+
+// AddPipeline adds a pipeline with the provided builds to an org
+func (s *Server) AddPipeline(orgSlug string, p buildkite.Pipeline, builds ...buildkite.Build) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pipelines[orgSlug] = append(s.pipelines[orgSlug], pipeline{Pipeline: p, builds: builds})
+}
+
+// AddTeam adds a team with its members to an org, deriving any IDs that
+// aren't set, with user IDs from the org's members with the same email
+func (s *Server) AddTeam(orgSlug string, t buildkite.Team) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.ID == "" {
+		t.ID = "team-" + orgSlug + "-" + t.Slug
+	}
+	for i, m := range t.Members {
+		t.Members[i] = s.teamMember(orgSlug, t, m)
+	}
+	s.teams[orgSlug] = append(s.teams[orgSlug], t)
+}
+
+// teamMember derives the IDs of a team member that aren't set
+func (s *Server) teamMember(orgSlug string, t buildkite.Team, m buildkite.TeamMember) buildkite.TeamMember {
+	if m.UserID == "" {
+		m.UserID = "user-" + m.Email
+		for _, om := range s.orgs[orgSlug] {
+			if strings.EqualFold(om.Email, m.Email) {
+				m.UserID = om.ID
+			}
+		}
+	}
+	if m.ID == "" {
+		m.ID = "teammember-" + t.ID + "-" + m.UserID
+	}
+	return m
+}
+
+// AddSSOProvider adds an SSO provider to an org
+func (s *Server) AddSSOProvider(orgSlug string, p buildkite.SSOProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.providers[orgSlug] = append(s.providers[orgSlug], p)
+}
+
+// AddInvitation adds an invitation to an org, pending unless it has a state
+func (s *Server) AddInvitation(orgSlug string, inv buildkite.Invitation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if inv.State == "" {
+		inv.State = "PENDING"
+	}
+	s.invites[orgSlug] = append(s.invites[orgSlug], inv)
+}
+
+// FailNext queues a failure for the next request, a status of http.StatusOK
+// returns a GraphQL error in the response body
+func (s *Server) FailNext(status int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure{status: status, message: message})
+}
+
+// SetRateLimit sets the rate limit headers returned, each request consumes one
+// point and requests are rejected once no points remain
+func (s *Server) SetRateLimit(limit, remaining int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	s.remaining = remaining
+}
+
+// Requests returns the number of requests the server has received
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	w.Header().Set("Content-Type", "application/json")
+
+	if s.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	if s.limit > 0 {
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(s.limit))
+		w.Header().Set("RateLimit-Reset", "60")
+		if s.remaining <= 0 {
+			w.Header().Set("RateLimit-Remaining", "0")
+			writeError(w, http.StatusTooManyRequests, "You have exceeded your rate limit")
+			return
+		}
+		s.remaining--
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(s.remaining))
+	}
+
+	if len(s.failures) > 0 {
+		f := s.failures[0]
+		s.failures = s.failures[1:]
+		writeError(w, f.status, f.message)
+		return
+	}
+
+	if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/access-token") {
+		s.serveAccessToken(w)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// pipelines queries also select teams and team members, and team queries
+	// select team members, so match them first
+	switch {
+	case strings.Contains(req.Query, "pipelines("):
+		s.servePipelines(w, req.Variables)
+	case strings.Contains(req.Query, "teamMemberCreate("):
+		s.serveAddTeamMember(w, req.Variables)
+	case strings.Contains(req.Query, "teamMemberUpdate("):
+		s.serveUpdateTeamMember(w, req.Variables)
+	case strings.Contains(req.Query, "teamMemberDelete("):
+		s.serveRemoveTeamMember(w, req.Variables)
+	case strings.Contains(req.Query, "teams("):
+		s.serveTeams(w, req.Variables)
+	case strings.Contains(req.Query, "team("):
+		s.serveTeam(w, req.Variables)
+	case strings.Contains(req.Query, "invitations("):
+		s.serveInvitations(w, req.Variables)
+	case strings.Contains(req.Query, "organizationInvitationCreate("):
+		s.serveCreateInvitation(w, req.Variables)
+	case strings.Contains(req.Query, "organizationInvitationRevoke("):
+		s.serveRevokeInvitation(w, req.Variables)
+	case strings.Contains(req.Query, "ssoProviders("):
+		s.serveSSOProviders(w, req.Variables)
+	case strings.Contains(req.Query, "OrgMembersBatch"):
+		s.serveOrgMembersBatch(w, req.Variables)
+	case strings.Contains(req.Query, "members("):
+		s.serveOrgMembers(w, req.Variables)
+	case strings.Contains(req.Query, "organizations("):
+		s.serveOrganizations(w, req.Variables)
+	case strings.Contains(req.Query, "viewer"):
+		s.serveViewer(w)
+	case strings.Contains(req.Query, "ssoAuthorizationRevoke("):
+		s.serveRevokeSSOAuthorization(w, req.Variables)
+	case strings.Contains(req.Query, "userSessionDestroy("):
+		s.serveDestroyUserSessions(w, req.Variables)
+	case strings.Contains(req.Query, "organizationMemberDelete("):
+		s.serveRemoveOrgMember(w, req.Variables)
+	case strings.Contains(req.Query, "builds("):
+		s.serveBuilds(w, req.Variables)
+	case strings.Contains(req.Query, "organization("):
+		s.serveOrganization(w, req.Variables)
+	default:
+		writeError(w, http.StatusOK, "Unsupported query")
+	}
+}
+
+func (s *Server) serveOrgMembers(w http.ResponseWriter, vars map[string]interface{}) {
+	orgSlug, _ := vars["orgSlug"].(string)
+	after, _ := vars["after"].(string)
+
+	org, ok := s.orgMembersPage(orgSlug, after)
+	if !ok {
+		writeError(w, http.StatusOK, "Invalid cursor")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{"organization": org},
+	})
+}
+
+// serveOrgMembersBatch serves the first page of members of each org in a
+// batch, aliased by the variables that hold their slugs
+func (s *Server) serveOrgMembersBatch(w http.ResponseWriter, vars map[string]interface{}) {
+	data := map[string]interface{}{}
+
+	for alias, v := range vars {
+		orgSlug, _ := v.(string)
+		data[alias], _ = s.orgMembersPage(orgSlug, "")
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+// orgMembersPage returns a page of an org's members after a cursor, or nil if
+// the org doesn't exist, and false if the cursor is invalid
+func (s *Server) orgMembersPage(orgSlug, after string) (interface{}, bool) {
+	members, ok := s.orgs[orgSlug]
+	if !ok {
+		return nil, true
+	}
+
+	start, err := decodeCursor(after)
+	if err != nil {
+		return nil, false
+	}
+
+	end := start + s.PageSize
+	if end > len(members) {
+		end = len(members)
+	}
+	if start > end {
+		start = end
+	}
+
+	edges := []interface{}{}
+	for _, m := range members[start:end] {
+		edges = append(edges, map[string]interface{}{"node": memberNode(m)})
+	}
+
+	return map[string]interface{}{
+		"members": map[string]interface{}{
+			"pageInfo": map[string]interface{}{
+				"hasNextPage": end < len(members),
+				"endCursor":   encodeCursor(end),
+			},
+			"edges": edges,
+		},
+	}, true
+}
+
+func (s *Server) serveViewer(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"viewer": map[string]interface{}{
+				"user": map[string]interface{}{
+					"id":    s.Viewer.ID,
+					"name":  s.Viewer.Name,
+					"email": s.Viewer.Email,
+				},
+			},
+		},
+	})
+}
+
+func (s *Server) serveAccessToken(w http.ResponseWriter) {
+	scopes := s.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"uuid":   "b8a5a9c3-3e87-4b4f-8e0a-5c2a6f0d1e4b",
+		"scopes": scopes,
+	})
+}
+
+func (s *Server) serveOrganizations(w http.ResponseWriter, vars map[string]interface{}) {
+	after, _ := vars["after"].(string)
+
+	slugs := make([]string, 0, len(s.orgs))
+	for slug := range s.orgs {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	start, err := decodeCursor(after)
+	if err != nil {
+		writeError(w, http.StatusOK, "Invalid cursor")
+		return
+	}
+
+	end := start + s.PageSize
+	if end > len(slugs) {
+		end = len(slugs)
+	}
+	if start > end {
+		start = end
+	}
+
+	edges := []interface{}{}
+	for _, slug := range slugs[start:end] {
+		edges = append(edges, map[string]interface{}{
+			"node": map[string]interface{}{
+				"id":   base64.StdEncoding.EncodeToString([]byte("Organization---" + slug)),
+				"slug": slug,
+				"name": slug,
+			},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"viewer": map[string]interface{}{
+				"organizations": map[string]interface{}{
+					"pageInfo": map[string]interface{}{
+						"hasNextPage": end < len(slugs),
+						"endCursor":   encodeCursor(end),
+					},
+					"edges": edges,
+				},
+			},
+		},
+	})
+}
+
+func (s *Server) serveOrganization(w http.ResponseWriter, vars map[string]interface{}) {
+	orgSlug, _ := vars["orgSlug"].(string)
+
+	var org interface{}
+	if _, ok := s.orgs[orgSlug]; ok {
+		org = map[string]interface{}{
+			"id":   base64.StdEncoding.EncodeToString([]byte("Organization---" + orgSlug)),
+			"slug": orgSlug,
+			"name": orgSlug,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{"organization": org},
+	})
+}
+
+func (s *Server) serveRemoveOrgMember(w http.ResponseWriter, vars map[string]interface{}) {
+	id, _ := vars["id"].(string)
+
+	for orgSlug, members := range s.orgs {
+		for i, m := range members {
+			if membershipID(m) != id {
+				continue
+			}
+			s.orgs[orgSlug] = append(members[:i:i], members[i+1:]...)
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"data": map[string]interface{}{
+					"organizationMemberDelete": map[string]interface{}{
+						"deletedOrganizationMemberID": id,
+					},
+				},
+			})
+			return
+		}
+	}
+
+	writeError(w, http.StatusOK, "No organization member found with that ID")
+}
+
+func (s *Server) serveInvitations(w http.ResponseWriter, vars map[string]interface{}) {
+	orgSlug, _ := vars["orgSlug"].(string)
+	after, _ := vars["after"].(string)
+
+	if _, ok := s.orgs[orgSlug]; !ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"organization": nil},
+		})
+		return
+	}
+
+	var pending []buildkite.Invitation
+	for _, inv := range s.invites[orgSlug] {
+		if inv.State == "PENDING" {
+			pending = append(pending, inv)
+		}
+	}
+
+	start, end, err := s.page(after, len(pending))
+	if err != nil {
+		writeError(w, http.StatusOK, "Invalid cursor")
+		return
+	}
+
+	edges := []interface{}{}
+	for _, inv := range pending[start:end] {
+		var createdBy interface{}
+		if inv.InvitedBy != "" {
+			createdBy = map[string]interface{}{"email": inv.InvitedBy}
+		}
+		edges = append(edges, map[string]interface{}{
+			"node": map[string]interface{}{
+				"id":        inv.ID,
+				"email":     inv.Email,
+				"role":      inv.Role,
+				"state":     inv.State,
+				"createdAt": inv.CreatedAt,
+				"createdBy": createdBy,
+			},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"organization": map[string]interface{}{
+				"invitations": connection(edges, end < len(pending), end),
+			},
+		},
+	})
+}
+
+func (s *Server) serveCreateInvitation(w http.ResponseWriter, vars map[string]interface{}) {
+	orgID, _ := vars["orgID"].(string)
+	email, _ := vars["email"].(string)
+	role, _ := vars["role"].(string)
+
+	for orgSlug := range s.orgs {
+		if base64.StdEncoding.EncodeToString([]byte("Organization---"+orgSlug)) != orgID {
+			continue
+		}
+		inv := buildkite.Invitation{
+			ID:        fmt.Sprintf("invitation-%s-%d", orgSlug, len(s.invites[orgSlug])+1),
+			Email:     email,
+			Role:      role,
+			State:     "PENDING",
+			CreatedAt: time.Now(),
+		}
+		s.invites[orgSlug] = append(s.invites[orgSlug], inv)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"organizationInvitationCreate": map[string]interface{}{
+					"invitationEdges": []interface{}{
+						map[string]interface{}{
+							"node": map[string]interface{}{"id": inv.ID, "email": inv.Email},
+						},
+					},
+				},
+			},
+		})
+		return
+	}
+
+	writeError(w, http.StatusOK, "No organization found with that ID")
+}
+
+func (s *Server) serveRevokeInvitation(w http.ResponseWriter, vars map[string]interface{}) {
+	id, _ := vars["id"].(string)
+
+	for _, invites := range s.invites {
+		for i := range invites {
+			if invites[i].ID != id || invites[i].State != "PENDING" {
+				continue
+			}
+			invites[i].State = "REVOKED"
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"data": map[string]interface{}{
+					"organizationInvitationRevoke": map[string]interface{}{
+						"organizationInvitation": map[string]interface{}{"id": id, "state": "REVOKED"},
+					},
+				},
+			})
+			return
+		}
+	}
+
+	writeError(w, http.StatusOK, "No pending organization invitation found with that ID")
+}
+
+func (s *Server) serveRevokeSSOAuthorization(w http.ResponseWriter, vars map[string]interface{}) {
+	id, _ := vars["id"].(string)
+
+	for _, members := range s.orgs {
+		for i, m := range members {
+			if m.Authorization == nil || m.Authorization.ID != id {
+				continue
+			}
+			a := *m.Authorization
+			now := time.Now()
+			a.RevokedAt = &now
+			a.State = "REVOKED"
+			members[i].Authorization = &a
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"data": map[string]interface{}{
+					"ssoAuthorizationRevoke": map[string]interface{}{
+						"ssoAuthorization": map[string]interface{}{
+							"id":        id,
+							"state":     a.State,
+							"revokedAt": formatTime(a.RevokedAt),
+						},
+					},
+				},
+			})
+			return
+		}
+	}
+
+	writeError(w, http.StatusOK, "No SSO authorization found with that ID")
+}
+
+func (s *Server) serveDestroyUserSessions(w http.ResponseWriter, vars map[string]interface{}) {
+	id, _ := vars["id"].(string)
+
+	for _, members := range s.orgs {
+		for i, m := range members {
+			if membershipID(m) != id {
+				continue
+			}
+			if m.Authorization != nil {
+				a := *m.Authorization
+				now := time.Now()
+				a.UserSessionDestroyedAt = &now
+				members[i].Authorization = &a
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"data": map[string]interface{}{
+					"userSessionDestroy": map[string]interface{}{
+						"organizationMember": map[string]interface{}{"id": id},
+					},
+				},
+			})
+			return
+		}
+	}
+
+	writeError(w, http.StatusOK, "No organization member found with that ID")
+}
+
+// membershipID returns the member's MembershipID, or one derived from their ID
+func membershipID(m buildkite.OrgMember) string {
+	if m.MembershipID != "" {
+		return m.MembershipID
+	}
+	return base64.StdEncoding.EncodeToString([]byte("OrganizationMember---" + m.ID))
+}
+
+func (s *Server) servePipelines(w http.ResponseWriter, vars map[string]interface{}) {
+	orgSlug, _ := vars["orgSlug"].(string)
+	after, _ := vars["after"].(string)
+
+	pipelines := s.pipelines[orgSlug]
+
+	start, end, err := s.page(after, len(pipelines))
+	if err != nil {
+		writeError(w, http.StatusOK, "Invalid cursor")
+		return
+	}
+
+	edges := []interface{}{}
+	for _, p := range pipelines[start:end] {
+		teamEdges := []interface{}{}
+		for _, t := range p.Teams {
+			memberEdges := []interface{}{}
+			for _, email := range t.Maintainers {
+				memberEdges = append(memberEdges, map[string]interface{}{
+					"node": map[string]interface{}{
+						"user": map[string]interface{}{"email": email},
+					},
+				})
+			}
+			teamEdges = append(teamEdges, map[string]interface{}{
+				"node": map[string]interface{}{
+					"team": map[string]interface{}{
+						"slug":    t.Slug,
+						"members": map[string]interface{}{"edges": memberEdges},
+					},
+				},
+			})
+		}
+
+		edges = append(edges, map[string]interface{}{
+			"node": map[string]interface{}{
+				"id":    p.ID,
+				"slug":  p.Slug,
+				"name":  p.Name,
+				"teams": map[string]interface{}{"edges": teamEdges},
+			},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"organization": map[string]interface{}{
+				"pipelines": connection(edges, end < len(pipelines), end),
+			},
+		},
+	})
+}
+
+func (s *Server) serveTeams(w http.ResponseWriter, vars map[string]interface{}) {
+	orgSlug, _ := vars["orgSlug"].(string)
+	after, _ := vars["after"].(string)
+
+	if _, ok := s.orgs[orgSlug]; !ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"organization": nil},
+		})
+		return
+	}
+
+	teams := s.teams[orgSlug]
+
+	start, end, err := s.page(after, len(teams))
+	if err != nil {
+		writeError(w, http.StatusOK, "Invalid cursor")
+		return
+	}
+
+	edges := []interface{}{}
+	for _, t := range teams[start:end] {
+		members, _ := s.teamMembersPage(t, "")
+		edges = append(edges, map[string]interface{}{
+			"node": map[string]interface{}{
+				"id":      t.ID,
+				"slug":    t.Slug,
+				"name":    t.Name,
+				"members": members,
+			},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"organization": map[string]interface{}{
+				"teams": connection(edges, end < len(teams), end),
+			},
+		},
+	})
+}
+
+func (s *Server) serveTeam(w http.ResponseWriter, vars map[string]interface{}) {
+	slug, _ := vars["slug"].(string)
+	after, _ := vars["after"].(string)
+
+	var team interface{}
+	if t := s.findTeam(func(orgSlug string, t buildkite.Team) bool { return orgSlug+"/"+t.Slug == slug }); t != nil {
+		members, err := s.teamMembersPage(*t, after)
+		if err != nil {
+			writeError(w, http.StatusOK, "Invalid cursor")
+			return
+		}
+		team = map[string]interface{}{"members": members}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{"team": team},
+	})
+}
+
+// teamMembersPage returns a page of a team's members as a connection
+func (s *Server) teamMembersPage(t buildkite.Team, after string) (map[string]interface{}, error) {
+	start, end, err := s.page(after, len(t.Members))
+	if err != nil {
+		return nil, err
+	}
+
+	edges := []interface{}{}
+	for _, m := range t.Members[start:end] {
+		edges = append(edges, map[string]interface{}{
+			"node": map[string]interface{}{
+				"id":   m.ID,
+				"role": m.Role,
+				"user": map[string]interface{}{"id": m.UserID, "email": m.Email},
+			},
+		})
+	}
+
+	return connection(edges, end < len(t.Members), end), nil
+}
+
+// findTeam returns the first team matching f, or nil
+func (s *Server) findTeam(f func(orgSlug string, t buildkite.Team) bool) *buildkite.Team {
+	for orgSlug, teams := range s.teams {
+		for i := range teams {
+			if f(orgSlug, teams[i]) {
+				return &teams[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) serveAddTeamMember(w http.ResponseWriter, vars map[string]interface{}) {
+	teamID, _ := vars["teamID"].(string)
+	userID, _ := vars["userID"].(string)
+	role, _ := vars["role"].(string)
+
+	var teamOrg string
+	t := s.findTeam(func(orgSlug string, t buildkite.Team) bool {
+		teamOrg = orgSlug
+		return t.ID == teamID
+	})
+	if t == nil {
+		writeError(w, http.StatusOK, "No team found with that ID")
+		return
+	}
+
+	var email string
+	for _, om := range s.orgs[teamOrg] {
+		if om.ID == userID {
+			email = om.Email
+		}
+	}
+	if email == "" {
+		writeError(w, http.StatusOK, "User isn't a member of the organization")
+		return
+	}
+
+	for _, m := range t.Members {
+		if m.UserID == userID {
+			writeError(w, http.StatusOK, "User is already a member of the team")
+			return
+		}
+	}
+
+	m := s.teamMember(teamOrg, *t, buildkite.TeamMember{UserID: userID, Email: email, Role: role})
+	t.Members = append(t.Members, m)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"teamMemberCreate": map[string]interface{}{
+				"teamMemberEdge": map[string]interface{}{
+					"node": map[string]interface{}{"id": m.ID},
+				},
+			},
+		},
+	})
+}
+
+func (s *Server) serveUpdateTeamMember(w http.ResponseWriter, vars map[string]interface{}) {
+	id, _ := vars["id"].(string)
+	role, _ := vars["role"].(string)
+
+	for _, teams := range s.teams {
+		for _, t := range teams {
+			for i := range t.Members {
+				if t.Members[i].ID != id {
+					continue
+				}
+				t.Members[i].Role = role
+				writeJSON(w, http.StatusOK, map[string]interface{}{
+					"data": map[string]interface{}{
+						"teamMemberUpdate": map[string]interface{}{
+							"teamMember": map[string]interface{}{"id": id, "role": role},
+						},
+					},
+				})
+				return
+			}
+		}
+	}
+
+	writeError(w, http.StatusOK, "No team member found with that ID")
+}
+
+func (s *Server) serveRemoveTeamMember(w http.ResponseWriter, vars map[string]interface{}) {
+	id, _ := vars["id"].(string)
+
+	for _, teams := range s.teams {
+		for j := range teams {
+			for i, m := range teams[j].Members {
+				if m.ID != id {
+					continue
+				}
+				teams[j].Members = append(teams[j].Members[:i:i], teams[j].Members[i+1:]...)
+				writeJSON(w, http.StatusOK, map[string]interface{}{
+					"data": map[string]interface{}{
+						"teamMemberDelete": map[string]interface{}{"deletedTeamMemberID": id},
+					},
+				})
+				return
+			}
+		}
+	}
+
+	writeError(w, http.StatusOK, "No team member found with that ID")
+}
+
+func (s *Server) serveSSOProviders(w http.ResponseWriter, vars map[string]interface{}) {
+	orgSlug, _ := vars["orgSlug"].(string)
+
+	if _, ok := s.orgs[orgSlug]; !ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"organization": nil},
+		})
+		return
+	}
+
+	edges := []interface{}{}
+	for _, p := range s.providers[orgSlug] {
+		var hours, domain interface{}
+		if p.SessionDuration > 0 {
+			hours = int(p.SessionDuration / time.Hour)
+		}
+		if p.EmailDomain != "" {
+			domain = p.EmailDomain
+		}
+		edges = append(edges, map[string]interface{}{
+			"node": map[string]interface{}{
+				"type":                   p.Type,
+				"state":                  p.State,
+				"sessionDurationInHours": hours,
+				"emailDomain":            domain,
+				"enabledAt":              p.EnabledAt,
+			},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"organization": map[string]interface{}{
+				"ssoProviders": map[string]interface{}{"edges": edges},
+			},
+		},
+	})
+}
+
+func (s *Server) serveBuilds(w http.ResponseWriter, vars map[string]interface{}) {
+	slug, _ := vars["slug"].(string)
+	after, _ := vars["after"].(string)
+	from, _ := time.Parse(time.RFC3339, fmt.Sprint(vars["from"]))
+	to, _ := time.Parse(time.RFC3339, fmt.Sprint(vars["to"]))
+
+	var builds []buildkite.Build
+	found := false
+	for orgSlug, pipelines := range s.pipelines {
+		for _, p := range pipelines {
+			if orgSlug+"/"+p.Slug != slug {
+				continue
+			}
+			found = true
+			for _, b := range p.builds {
+				if (!from.IsZero() && b.CreatedAt.Before(from)) || (!to.IsZero() && b.CreatedAt.After(to)) {
+					continue
+				}
+				builds = append(builds, b)
+			}
+		}
+	}
+
+	if !found {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"pipeline": nil},
+		})
+		return
+	}
+
+	start, end, err := s.page(after, len(builds))
+	if err != nil {
+		writeError(w, http.StatusOK, "Invalid cursor")
+		return
+	}
+
+	edges := []interface{}{}
+	for _, b := range builds[start:end] {
+		edges = append(edges, map[string]interface{}{"node": buildNode(b)})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"pipeline": map[string]interface{}{
+				"builds": connection(edges, end < len(builds), end),
+			},
+		},
+	})
+}
+
+// page returns the bounds of the page of n items after a cursor
+func (s *Server) page(after string, n int) (int, int, error) {
+	start, err := decodeCursor(after)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end := start + s.PageSize
+	if end > n {
+		end = n
+	}
+	if start > end {
+		start = end
+	}
+
+	return start, end, nil
+}
+
+func connection(edges []interface{}, hasNextPage bool, end int) map[string]interface{} {
+	return map[string]interface{}{
+		"pageInfo": map[string]interface{}{
+			"hasNextPage": hasNextPage,
+			"endCursor":   encodeCursor(end),
+		},
+		"edges": edges,
+	}
+}
+
+func buildNode(b buildkite.Build) map[string]interface{} {
+	var createdBy interface{}
+	if c := b.Creator; c != nil {
+		if c.ID != "" {
+			createdBy = map[string]interface{}{"__typename": "User", "id": c.ID, "name": c.Name, "email": c.Email}
+		} else {
+			createdBy = map[string]interface{}{"__typename": "UnregisteredUser", "name": c.Name, "email": c.Email}
+		}
+	}
+
+	jobEdges := []interface{}{}
+	for _, j := range b.Jobs {
+		jobEdges = append(jobEdges, map[string]interface{}{
+			"node": map[string]interface{}{
+				"startedAt":  formatTime(j.StartedAt),
+				"finishedAt": formatTime(j.FinishedAt),
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"id":         b.ID,
+		"number":     b.Number,
+		"state":      b.State,
+		"createdAt":  b.CreatedAt.Format(time.RFC3339),
+		"startedAt":  formatTime(b.StartedAt),
+		"finishedAt": formatTime(b.FinishedAt),
+		"createdBy":  createdBy,
+		"jobs": map[string]interface{}{
+			"edges": jobEdges,
+		},
+	}
+}
+
+// authorizationState returns the authorization's state, defaulting to CREATED
+func authorizationState(a *buildkite.Authorization) string {
+	if a.State == "" {
+		return "CREATED"
+	}
+	return a.State
+}
+
+func memberNode(m buildkite.OrgMember) map[string]interface{} {
+	authEdges := []interface{}{}
+
+	if a := m.Authorization; a != nil {
+		authEdges = append(authEdges, map[string]interface{}{
+			"node": map[string]interface{}{
+				"id": a.ID,
+				"identity": map[string]interface{}{
+					"name":  a.Name,
+					"email": a.Email,
+				},
+				"createdAt":              a.CreatedAt.Format(time.RFC3339),
+				"expiredAt":              formatTime(a.ExpireAt),
+				"revokedAt":              formatTime(a.RevokedAt),
+				"userSessionDestroyedAt": formatTime(a.UserSessionDestroyedAt),
+				"state":                  authorizationState(a),
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"id":            membershipID(m),
+		"createdAt":     m.CreatedAt.Format(time.RFC3339),
+		"role":          m.Role,
+		"complimentary": m.Complimentary,
+		"user": map[string]interface{}{
+			"id":    m.ID,
+			"name":  m.Name,
+			"email": m.Email,
+			"bot":   m.Bot,
+		},
+		"sso": map[string]interface{}{
+			"authorizations": map[string]interface{}{
+				"edges": authEdges,
+			},
+		},
+	}
+}
+
+func formatTime(t *time.Time) interface{} {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.Format(time.RFC3339)
+}
+
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("cursor:%d", offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimPrefix(string(b), "cursor:"))
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"errors": []interface{}{
+			map[string]interface{}{"message": message},
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// rewriteTransport sends requests to the target host rather than the one requested
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	req.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
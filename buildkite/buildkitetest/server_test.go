@@ -0,0 +1,112 @@
+package buildkitetest_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/buildkite/buildkitetest"
+)
+
+func members(n int) []buildkite.OrgMember {
+	var members []buildkite.OrgMember
+	for i := 1; i <= n; i++ {
+		members = append(members, buildkite.OrgMember{
+			ID:    fmt.Sprintf("u%d", i),
+			Email: fmt.Sprintf("user%d@acme.com", i),
+			Name:  fmt.Sprintf("User %d", i),
+			Role:  "MEMBER",
+		})
+	}
+	return members
+}
+
+func TestClientPagination(t *testing.T) {
+	s := buildkitetest.NewServer()
+	defer s.Close()
+	s.PageSize = 2
+	s.AddOrg("acme", members(5)...)
+
+	client, err := s.Client("test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := client.GetOrgMembers("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 {
+		t.Fatalf("got %d members, want 5", len(got))
+	}
+	for i, m := range got {
+		if want := fmt.Sprintf("user%d@acme.com", i+1); m.Email != want {
+			t.Errorf("member %d is %s, want %s", i, m.Email, want)
+		}
+	}
+	if n := s.Requests(); n != 3 {
+		t.Errorf("got %d requests, want a page of two per request", n)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		message string
+		err     error
+	}{
+		{name: "unauthorized", status: http.StatusUnauthorized, message: "Authentication required", err: buildkite.ErrUnauthorized},
+		{name: "server error", status: http.StatusBadGateway, message: "Bad gateway", err: buildkite.ErrUnavailable},
+		{name: "graphql error", status: http.StatusOK, message: "Field doesn't exist", err: buildkite.ErrGraphQL},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := buildkitetest.NewServer()
+			defer s.Close()
+			s.AddOrg("acme", members(1)...)
+
+			client, err := s.Client("test-token")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s.FailNext(tc.status, tc.message)
+			if _, err := client.GetOrgMembers("acme"); !errors.Is(err, tc.err) {
+				t.Fatalf("GetOrgMembers() error = %v, want %v", err, tc.err)
+			}
+
+			// only the next request fails
+			if _, err := client.GetOrgMembers("acme"); err != nil {
+				t.Fatalf("GetOrgMembers() after a failure error = %v", err)
+			}
+		})
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	s := buildkitetest.NewServer()
+	defer s.Close()
+	s.PageSize = 1
+	s.AddOrg("acme", members(3)...)
+	s.SetRateLimit(100, 2)
+
+	client, err := s.Client("test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the third page is over the limit
+	if _, err := client.GetOrgMembers("acme"); !errors.Is(err, buildkite.ErrRateLimited) {
+		t.Fatalf("GetOrgMembers() error = %v, want %v", err, buildkite.ErrRateLimited)
+	}
+
+	stats := client.Stats()
+	if stats.RateLimit != 100 || stats.RateLimitStart != 1 || stats.RateLimitRemaining != 0 {
+		t.Errorf("rate limit = %d with %d then %d remaining, want 100 with 1 then 0",
+			stats.RateLimit, stats.RateLimitStart, stats.RateLimitRemaining)
+	}
+}
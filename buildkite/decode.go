@@ -0,0 +1,129 @@
+package buildkite
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	errors "golang.org/x/xerrors"
+)
+
+// ErrUnexpectedShape is a response missing fields the decode struct expects,
+// or with nulls where it can't hold them, which usually means the schema
+// changed
+var ErrUnexpectedShape = errors.New("unexpected response shape")
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// decodeStrict decodes JSON into v, failing if any field v expects is missing
+// from the JSON or is null without being a pointer, slice or map. Fields
+// tagged omitempty may be missing or null, like those only selected on some
+// members of a union.
+func decodeStrict(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	return checkShape(reflect.TypeOf(v), raw, "")
+}
+
+func checkShape(t reflect.Type, raw interface{}, path string) error {
+	switch t.Kind() {
+	case reflect.Ptr:
+		if raw == nil {
+			return nil
+		}
+		return checkShape(t.Elem(), raw, path)
+
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			if item == nil {
+				if nullable(t.Elem()) {
+					continue
+				}
+				return errors.Errorf("%s[%d] is null: %w", path, i, ErrUnexpectedShape)
+			}
+			if err := checkShape(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Struct:
+		if t == timeType {
+			return nil
+		}
+
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("%s isn't an object: %w", path, ErrUnexpectedShape)
+		}
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+
+			name, optional := jsonField(f)
+			if name == "-" {
+				continue
+			}
+
+			fieldPath := strings.TrimPrefix(path+"."+name, ".")
+
+			value, present := obj[name]
+			if !present {
+				if optional {
+					continue
+				}
+				return errors.Errorf("missing %s: %w", fieldPath, ErrUnexpectedShape)
+			}
+
+			if value == nil {
+				if optional || nullable(f.Type) {
+					continue
+				}
+				return errors.Errorf("%s is null: %w", fieldPath, ErrUnexpectedShape)
+			}
+
+			if err := checkShape(f.Type, value, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// jsonField returns the JSON name of a field and whether it's omitempty
+func jsonField(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	name, opts := tag, ""
+	if i := strings.Index(tag, ","); i >= 0 {
+		name, opts = tag[:i], tag[i+1:]
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, strings.Contains(","+opts+",", ",omitempty,")
+}
+
+func nullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	}
+	return false
+}
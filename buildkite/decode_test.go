@@ -0,0 +1,143 @@
+package buildkite
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	errors "golang.org/x/xerrors"
+)
+
+// fixtureDir holds responses recorded with --record from a fake server, which
+// has the same schema as the Buildkite API
+const fixtureDir = "testdata/fixtures"
+
+// recordedMembersPage returns the data of the recorded first page of alpaca
+// members, as generic JSON to change before decoding
+func recordedMembersPage(t *testing.T) map[string]interface{} {
+	b, err := ioutil.ReadFile(filepath.Join(fixtureDir, "9858af1039a2e40f.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		t.Fatal(err)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(f.Body), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Data
+}
+
+// firstNode returns the first member node of a members page
+func firstNode(data map[string]interface{}) map[string]interface{} {
+	org := data["organization"].(map[string]interface{})
+	edges := org["members"].(map[string]interface{})["edges"].([]interface{})
+	return edges[0].(map[string]interface{})["node"].(map[string]interface{})
+}
+
+// firstAuthorization returns the authorization of the first member node
+func firstAuthorization(data map[string]interface{}) map[string]interface{} {
+	sso := firstNode(data)["sso"].(map[string]interface{})
+	edges := sso["authorizations"].(map[string]interface{})["edges"].([]interface{})
+	return edges[0].(map[string]interface{})["node"].(map[string]interface{})
+}
+
+func TestDecodeStrict(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(data map[string]interface{})
+		err    error
+	}{
+		{
+			name:   "recorded",
+			change: func(data map[string]interface{}) {},
+		},
+		{
+			name:   "missing field",
+			change: func(data map[string]interface{}) { delete(firstNode(data)["user"].(map[string]interface{}), "email") },
+			err:    ErrUnexpectedShape,
+		},
+		{
+			name:   "missing nullable field",
+			change: func(data map[string]interface{}) { delete(firstAuthorization(data), "revokedAt") },
+			err:    ErrUnexpectedShape,
+		},
+		{
+			name:   "null field",
+			change: func(data map[string]interface{}) { firstNode(data)["createdAt"] = nil },
+			err:    ErrUnexpectedShape,
+		},
+		{
+			name:   "null nullable field",
+			change: func(data map[string]interface{}) { firstAuthorization(data)["expiredAt"] = nil },
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data := recordedMembersPage(t)
+			tc.change(data)
+
+			b, err := json.Marshal(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var resp OrgMembersPageResponse
+			err = decodeStrict(b, &resp)
+			if tc.err == nil && err != nil {
+				t.Fatalf("decodeStrict() error = %v", err)
+			} else if tc.err != nil && !errors.Is(err, tc.err) {
+				t.Fatalf("decodeStrict() error = %v, want %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestGetOrgMembersFromRecordedFixtures(t *testing.T) {
+	client, err := NewClient("test-token", WithHTTPClient(&http.Client{
+		Transport: &ReplayTransport{Dir: fixtureDir},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	members, err := client.GetOrgMembers("alpaca")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auths := map[string]*Authorization{}
+	for _, m := range members {
+		if m.Authorization == nil {
+			t.Fatalf("%s has no authorization", m.Email)
+		}
+		auths[m.Email] = m.Authorization
+	}
+
+	// llama@llamas.com expires and jgarcia@gmail.com was revoked, with the
+	// other recorded as null
+	if a := auths["llama@llamas.com"]; a == nil || a.ExpireAt == nil || a.RevokedAt != nil {
+		t.Errorf("llama@llamas.com authorization = %+v, want an expiry and a nil revocation", a)
+	}
+	if a := auths["jgarcia@gmail.com"]; a == nil || a.RevokedAt == nil || a.ExpireAt != nil {
+		t.Errorf("jgarcia@gmail.com authorization = %+v, want a revocation and a nil expiry", a)
+	}
+
+	// the llama members span two recorded pages
+	members, err = client.GetOrgMembers("llama")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 3 {
+		t.Errorf("got %d llama members, want 3", len(members))
+	}
+}
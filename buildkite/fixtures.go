@@ -0,0 +1,135 @@
+package buildkite
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	errors "golang.org/x/xerrors"
+)
+
+// fixture is a recorded GraphQL request and its raw response
+type fixture struct {
+	Request json.RawMessage `json:"request"`
+	Status  int             `json:"status"`
+	Header  http.Header     `json:"header,omitempty"`
+	Body    string          `json:"body"`
+}
+
+func fixturePath(dir string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return filepath.Join(dir, hex.EncodeToString(sum[:])[:16]+".json")
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, errors.Errorf("failed to read request body: %w", err)
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// RecordingTransport is an http.RoundTripper that writes every request and
+// response to a fixture file in Dir for later replay
+type RecordingTransport struct {
+	Dir       string
+	Transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Errorf("failed to read body: %w", err)
+	}
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	f := fixture{
+		Request: body,
+		Status:  resp.StatusCode,
+		Header:  resp.Header,
+		Body:    string(data),
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, errors.Errorf("failed to marshal fixture: %w", err)
+	}
+
+	if err := os.MkdirAll(t.Dir, 0700); err != nil {
+		return nil, errors.Errorf("failed to create fixture dir: %w", err)
+	}
+
+	if err := ioutil.WriteFile(fixturePath(t.Dir, body), b, 0600); err != nil {
+		return nil, errors.Errorf("failed to write fixture: %w", err)
+	}
+
+	return resp, nil
+}
+
+// ReplayTransport is an http.RoundTripper that serves responses from fixture
+// files previously written by RecordingTransport, without network access
+type ReplayTransport struct {
+	Dir string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fixturePath(t.Dir, body)
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("no fixture recorded for request at %s", path)
+	} else if err != nil {
+		return nil, errors.Errorf("failed to read fixture: %w", err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, errors.Errorf("failed to decode fixture %s: %w", path, err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Header,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}, nil
+}
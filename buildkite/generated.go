@@ -0,0 +1,290 @@
+// Code generated by github.com/Khan/genqlient, DO NOT EDIT.
+
+package buildkite
+
+import (
+	"context"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// OrgMemberConnection includes the requested fields of the GraphQL type OrganizationMemberConnection.
+type OrgMemberConnection struct {
+	PageInfo OrgMemberConnectionPageInfo `json:"pageInfo"`
+	Edges    []*OrgMemberEdge            `json:"edges"`
+}
+
+// GetPageInfo returns OrgMemberConnection.PageInfo, and is useful for accessing the field via an interface.
+func (v *OrgMemberConnection) GetPageInfo() OrgMemberConnectionPageInfo { return v.PageInfo }
+
+// GetEdges returns OrgMemberConnection.Edges, and is useful for accessing the field via an interface.
+func (v *OrgMemberConnection) GetEdges() []*OrgMemberEdge { return v.Edges }
+
+// OrgMemberConnectionPageInfo includes the requested fields of the GraphQL type PageInfo.
+type OrgMemberConnectionPageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+// GetHasNextPage returns OrgMemberConnectionPageInfo.HasNextPage, and is useful for accessing the field via an interface.
+func (v *OrgMemberConnectionPageInfo) GetHasNextPage() bool { return v.HasNextPage }
+
+// GetEndCursor returns OrgMemberConnectionPageInfo.EndCursor, and is useful for accessing the field via an interface.
+func (v *OrgMemberConnectionPageInfo) GetEndCursor() *string { return v.EndCursor }
+
+// OrgMemberEdge includes the requested fields of the GraphQL type OrganizationMemberEdge.
+type OrgMemberEdge struct {
+	Node *OrgMemberNode `json:"node"`
+}
+
+// GetNode returns OrgMemberEdge.Node, and is useful for accessing the field via an interface.
+func (v *OrgMemberEdge) GetNode() *OrgMemberNode { return v.Node }
+
+// OrgMemberNode includes the requested fields of the GraphQL type OrganizationMember.
+type OrgMemberNode struct {
+	Id            string                 `json:"id"`
+	CreatedAt     time.Time              `json:"createdAt"`
+	Role          OrganizationMemberRole `json:"role"`
+	Complimentary bool                   `json:"complimentary"`
+	User          OrgMemberUser          `json:"user"`
+	Sso           OrgMemberSSO           `json:"sso"`
+}
+
+// GetId returns OrgMemberNode.Id, and is useful for accessing the field via an interface.
+func (v *OrgMemberNode) GetId() string { return v.Id }
+
+// GetCreatedAt returns OrgMemberNode.CreatedAt, and is useful for accessing the field via an interface.
+func (v *OrgMemberNode) GetCreatedAt() time.Time { return v.CreatedAt }
+
+// GetRole returns OrgMemberNode.Role, and is useful for accessing the field via an interface.
+func (v *OrgMemberNode) GetRole() OrganizationMemberRole { return v.Role }
+
+// GetComplimentary returns OrgMemberNode.Complimentary, and is useful for accessing the field via an interface.
+func (v *OrgMemberNode) GetComplimentary() bool { return v.Complimentary }
+
+// GetUser returns OrgMemberNode.User, and is useful for accessing the field via an interface.
+func (v *OrgMemberNode) GetUser() OrgMemberUser { return v.User }
+
+// GetSso returns OrgMemberNode.Sso, and is useful for accessing the field via an interface.
+func (v *OrgMemberNode) GetSso() OrgMemberSSO { return v.Sso }
+
+// OrgMemberSSO includes the requested fields of the GraphQL type OrganizationMemberSSO.
+type OrgMemberSSO struct {
+	Authorizations *SSOAuthorizationConnection `json:"authorizations"`
+}
+
+// GetAuthorizations returns OrgMemberSSO.Authorizations, and is useful for accessing the field via an interface.
+func (v *OrgMemberSSO) GetAuthorizations() *SSOAuthorizationConnection { return v.Authorizations }
+
+// OrgMemberUser includes the requested fields of the GraphQL type User.
+type OrgMemberUser struct {
+	Id    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	Bot   bool   `json:"bot"`
+}
+
+// GetId returns OrgMemberUser.Id, and is useful for accessing the field via an interface.
+func (v *OrgMemberUser) GetId() string { return v.Id }
+
+// GetEmail returns OrgMemberUser.Email, and is useful for accessing the field via an interface.
+func (v *OrgMemberUser) GetEmail() string { return v.Email }
+
+// GetName returns OrgMemberUser.Name, and is useful for accessing the field via an interface.
+func (v *OrgMemberUser) GetName() string { return v.Name }
+
+// GetBot returns OrgMemberUser.Bot, and is useful for accessing the field via an interface.
+func (v *OrgMemberUser) GetBot() bool { return v.Bot }
+
+// OrgMembersPageOrganization includes the requested fields of the GraphQL type Organization.
+type OrgMembersPageOrganization struct {
+	Members *OrgMemberConnection `json:"members"`
+}
+
+// GetMembers returns OrgMembersPageOrganization.Members, and is useful for accessing the field via an interface.
+func (v *OrgMembersPageOrganization) GetMembers() *OrgMemberConnection { return v.Members }
+
+// OrgMembersPageResponse is returned by OrgMembersPage on success.
+type OrgMembersPageResponse struct {
+	Organization *OrgMembersPageOrganization `json:"organization"`
+}
+
+// GetOrganization returns OrgMembersPageResponse.Organization, and is useful for accessing the field via an interface.
+func (v *OrgMembersPageResponse) GetOrganization() *OrgMembersPageOrganization { return v.Organization }
+
+type OrganizationMemberRole string
+
+const (
+	OrganizationMemberRoleAdmin  OrganizationMemberRole = "ADMIN"
+	OrganizationMemberRoleMember OrganizationMemberRole = "MEMBER"
+)
+
+var AllOrganizationMemberRole = []OrganizationMemberRole{
+	OrganizationMemberRoleAdmin,
+	OrganizationMemberRoleMember,
+}
+
+// SSOAuthorizationConnection includes the requested fields of the GraphQL type SSOAuthorizationConnection.
+type SSOAuthorizationConnection struct {
+	Edges []*SSOAuthorizationEdge `json:"edges"`
+}
+
+// GetEdges returns SSOAuthorizationConnection.Edges, and is useful for accessing the field via an interface.
+func (v *SSOAuthorizationConnection) GetEdges() []*SSOAuthorizationEdge { return v.Edges }
+
+// SSOAuthorizationEdge includes the requested fields of the GraphQL type SSOAuthorizationEdge.
+type SSOAuthorizationEdge struct {
+	Node *SSOAuthorizationNode `json:"node"`
+}
+
+// GetNode returns SSOAuthorizationEdge.Node, and is useful for accessing the field via an interface.
+func (v *SSOAuthorizationEdge) GetNode() *SSOAuthorizationNode { return v.Node }
+
+// SSOAuthorizationIdentity includes the requested fields of the GraphQL type SSOAuthorizationIdentity.
+type SSOAuthorizationIdentity struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+}
+
+// GetName returns SSOAuthorizationIdentity.Name, and is useful for accessing the field via an interface.
+func (v *SSOAuthorizationIdentity) GetName() *string { return v.Name }
+
+// GetEmail returns SSOAuthorizationIdentity.Email, and is useful for accessing the field via an interface.
+func (v *SSOAuthorizationIdentity) GetEmail() *string { return v.Email }
+
+// SSOAuthorizationNode includes the requested fields of the GraphQL type SSOAuthorization.
+type SSOAuthorizationNode struct {
+	Id                     string                    `json:"id"`
+	Identity               *SSOAuthorizationIdentity `json:"identity"`
+	CreatedAt              time.Time                 `json:"createdAt"`
+	ExpiredAt              *time.Time                `json:"expiredAt"`
+	RevokedAt              *time.Time                `json:"revokedAt"`
+	UserSessionDestroyedAt *time.Time                `json:"userSessionDestroyedAt"`
+	State                  SSOAuthorizationState     `json:"state"`
+}
+
+// GetId returns SSOAuthorizationNode.Id, and is useful for accessing the field via an interface.
+func (v *SSOAuthorizationNode) GetId() string { return v.Id }
+
+// GetIdentity returns SSOAuthorizationNode.Identity, and is useful for accessing the field via an interface.
+func (v *SSOAuthorizationNode) GetIdentity() *SSOAuthorizationIdentity { return v.Identity }
+
+// GetCreatedAt returns SSOAuthorizationNode.CreatedAt, and is useful for accessing the field via an interface.
+func (v *SSOAuthorizationNode) GetCreatedAt() time.Time { return v.CreatedAt }
+
+// GetExpiredAt returns SSOAuthorizationNode.ExpiredAt, and is useful for accessing the field via an interface.
+func (v *SSOAuthorizationNode) GetExpiredAt() *time.Time { return v.ExpiredAt }
+
+// GetRevokedAt returns SSOAuthorizationNode.RevokedAt, and is useful for accessing the field via an interface.
+func (v *SSOAuthorizationNode) GetRevokedAt() *time.Time { return v.RevokedAt }
+
+// GetUserSessionDestroyedAt returns SSOAuthorizationNode.UserSessionDestroyedAt, and is useful for accessing the field via an interface.
+func (v *SSOAuthorizationNode) GetUserSessionDestroyedAt() *time.Time {
+	return v.UserSessionDestroyedAt
+}
+
+// GetState returns SSOAuthorizationNode.State, and is useful for accessing the field via an interface.
+func (v *SSOAuthorizationNode) GetState() SSOAuthorizationState { return v.State }
+
+type SSOAuthorizationState string
+
+const (
+	SSOAuthorizationStateCreated  SSOAuthorizationState = "CREATED"
+	SSOAuthorizationStateVerified SSOAuthorizationState = "VERIFIED"
+	SSOAuthorizationStateExpired  SSOAuthorizationState = "EXPIRED"
+	SSOAuthorizationStateRevoked  SSOAuthorizationState = "REVOKED"
+)
+
+var AllSSOAuthorizationState = []SSOAuthorizationState{
+	SSOAuthorizationStateCreated,
+	SSOAuthorizationStateVerified,
+	SSOAuthorizationStateExpired,
+	SSOAuthorizationStateRevoked,
+}
+
+// __OrgMembersPageInput is used internally by genqlient
+type __OrgMembersPageInput struct {
+	OrgSlug string  `json:"orgSlug"`
+	After   *string `json:"after"`
+}
+
+// GetOrgSlug returns __OrgMembersPageInput.OrgSlug, and is useful for accessing the field via an interface.
+func (v *__OrgMembersPageInput) GetOrgSlug() string { return v.OrgSlug }
+
+// GetAfter returns __OrgMembersPageInput.After, and is useful for accessing the field via an interface.
+func (v *__OrgMembersPageInput) GetAfter() *string { return v.After }
+
+// The query executed by OrgMembersPage.
+const OrgMembersPage_Operation = `
+query OrgMembersPage ($orgSlug: ID!, $after: String) {
+	organization(slug: $orgSlug) {
+		members(first: 100, after: $after) {
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+			edges {
+				node {
+					id
+					createdAt
+					role
+					complimentary
+					user {
+						id
+						email
+						name
+						bot
+					}
+					sso {
+						authorizations(first: 1) {
+							edges {
+								node {
+									id
+									identity {
+										name
+										email
+									}
+									createdAt
+									expiredAt
+									revokedAt
+									userSessionDestroyedAt
+									state
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+`
+
+// A page of org members with their most recent SSO authorization
+func OrgMembersPage(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	orgSlug string,
+	after *string,
+) (data_ *OrgMembersPageResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName: "OrgMembersPage",
+		Query:  OrgMembersPage_Operation,
+		Variables: &__OrgMembersPageInput{
+			OrgSlug: orgSlug,
+			After:   after,
+		},
+	}
+
+	data_ = &OrgMembersPageResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
@@ -0,0 +1,66 @@
+package buildkite
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	errors "golang.org/x/xerrors"
+)
+
+//go:generate go tool genqlient
+
+// graphqlClient makes the requests of the queries generated by genqlient from
+// queries/, through Client.Do so they share its stats, request budget and
+// circuit breaker
+type graphqlClient struct {
+	c *Client
+}
+
+// MakeRequest implements graphql.Client
+func (g graphqlClient) MakeRequest(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+	vars, err := variables(req.Variables)
+	if err != nil {
+		return err
+	}
+
+	r, err := g.c.DoContext(ctx, req.Query, vars)
+	if err != nil {
+		return err
+	}
+
+	t := time.Now()
+	defer func() {
+		addDecodeTime(ctx, time.Since(t))
+	}()
+
+	// decode data into the generated type, which the response only holds as
+	// an interface{}
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := r.DecodeInto(&body); err != nil {
+		return err
+	}
+
+	if err := decodeStrict(body.Data, resp.Data); err != nil {
+		return errors.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// variables converts generated input structs to the variables Do sends
+func variables(input interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(input)
+	if err != nil {
+		return nil, errors.Errorf("failed to marshal vars: %w", err)
+	}
+
+	var vars map[string]interface{}
+	if err := json.Unmarshal(b, &vars); err != nil {
+		return nil, errors.Errorf("failed to marshal vars: %w", err)
+	}
+
+	return vars, nil
+}
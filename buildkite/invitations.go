@@ -0,0 +1,174 @@
+package buildkite
+
+import (
+	"io/ioutil"
+	"time"
+
+	errors "golang.org/x/xerrors"
+)
+
+// Invitation is an invitation to join an org
+type Invitation struct {
+	ID    string
+	Email string
+	// Role is ADMIN or MEMBER
+	Role string
+	// State is like PENDING, ACCEPTED, EXPIRED or REVOKED
+	State     string
+	CreatedAt time.Time
+	// InvitedBy is the email of who created the invitation, if known
+	InvitedBy string
+}
+
+const invitationsQuery = `query ($orgSlug: ID!, $after: String) {
+	organization(slug: $orgSlug) {
+		invitations(first: 100, after: $after, state: PENDING) {
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+			edges {
+				node {
+					id
+					email
+					role
+					state
+					createdAt
+					createdBy {
+						email
+					}
+				}
+			}
+		}
+	}
+}`
+
+// GetPendingInvitations gets the invitations to an org that are yet to be
+// accepted
+func (c *Client) GetPendingInvitations(orgSlug string) ([]Invitation, error) {
+	after := ""
+	var result []Invitation
+
+	for {
+		resp, err := c.Do(invitationsQuery, map[string]interface{}{
+			`orgSlug`: orgSlug,
+			`after`:   after,
+		})
+		if err != nil {
+			return nil, errors.Errorf("failed to get invitations: %w", err)
+		}
+
+		var r struct {
+			Data struct {
+				Organization *struct {
+					Invitations struct {
+						PageInfo pageInfo `json:"pageInfo"`
+						Edges    []struct {
+							Node struct {
+								ID        string    `json:"id"`
+								Email     string    `json:"email"`
+								Role      string    `json:"role"`
+								State     string    `json:"state"`
+								CreatedAt time.Time `json:"createdAt"`
+								CreatedBy *struct {
+									Email string `json:"email"`
+								} `json:"createdBy"`
+							} `json:"node"`
+						} `json:"edges"`
+					} `json:"invitations"`
+				} `json:"organization"`
+			} `json:"data"`
+		}
+
+		if err := resp.DecodeInto(&r); err != nil {
+			return nil, err
+		}
+
+		if r.Data.Organization == nil {
+			return nil, errors.Errorf("failed to get invitations: %w", ErrOrgNotFound)
+		}
+
+		for _, edge := range r.Data.Organization.Invitations.Edges {
+			inv := Invitation{
+				ID:        edge.Node.ID,
+				Email:     edge.Node.Email,
+				Role:      edge.Node.Role,
+				State:     edge.Node.State,
+				CreatedAt: edge.Node.CreatedAt,
+			}
+			if edge.Node.CreatedBy != nil {
+				inv.InvitedBy = edge.Node.CreatedBy.Email
+			}
+			result = append(result, inv)
+		}
+
+		pageInfo := r.Data.Organization.Invitations.PageInfo
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == "" {
+			break
+		}
+
+		after = pageInfo.EndCursor
+	}
+
+	return result, nil
+}
+
+const revokeInvitationMutation = `mutation ($id: ID!) {
+	organizationInvitationRevoke(input: {id: $id}) {
+		organizationInvitation {
+			id
+			state
+		}
+	}
+}`
+
+// RevokeInvitation revokes an invitation by its ID, returning the response
+// body for auditing, even if it failed
+func (c *Client) RevokeInvitation(invitationID string) ([]byte, error) {
+	resp, err := c.Do(revokeInvitationMutation, map[string]interface{}{
+		`id`: invitationID,
+	})
+
+	var body []byte
+	if resp != nil {
+		body, _ = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	if err != nil {
+		return body, errors.Errorf("failed to revoke invitation: %w", err)
+	}
+	return body, nil
+}
+
+const createInvitationMutation = `mutation ($orgID: ID!, $email: String!, $role: OrganizationMemberRole!) {
+	organizationInvitationCreate(input: {organizationID: $orgID, emails: [$email], role: $role}) {
+		invitationEdges {
+			node {
+				id
+				email
+			}
+		}
+	}
+}`
+
+// CreateInvitation invites an email to an org by the org's ID with a role of
+// ADMIN or MEMBER, returning the response body for auditing, even if it failed
+func (c *Client) CreateInvitation(orgID, email, role string) ([]byte, error) {
+	resp, err := c.Do(createInvitationMutation, map[string]interface{}{
+		`orgID`: orgID,
+		`email`: email,
+		`role`:  role,
+	})
+
+	var body []byte
+	if resp != nil {
+		body, _ = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	if err != nil {
+		return body, errors.Errorf("failed to create invitation: %w", err)
+	}
+	return body, nil
+}
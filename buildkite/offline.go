@@ -0,0 +1,19 @@
+package buildkite
+
+import (
+	"net/http"
+
+	errors "golang.org/x/xerrors"
+)
+
+// ErrOffline is returned for requests made by a client in offline mode
+var ErrOffline = errors.New("offline, requests to the API aren't allowed")
+
+// OfflineTransport is an http.RoundTripper that fails every request with
+// ErrOffline, guaranteeing nothing is sent over the network
+type OfflineTransport struct{}
+
+// RoundTrip implements http.RoundTripper
+func (OfflineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, ErrOffline
+}
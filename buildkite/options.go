@@ -0,0 +1,87 @@
+package buildkite
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	errors "golang.org/x/xerrors"
+)
+
+// Option configures a Client created by NewClient
+type Option func(c *Client) error
+
+// Logf logs a formatted message
+type Logf func(format string, v ...interface{})
+
+// retryPolicy is how queries that fail with ErrUnavailable are retried
+type retryPolicy struct {
+	retries int
+	backoff time.Duration
+}
+
+// WithHTTPClient makes requests with the provided http.Client, rather than
+// http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) error {
+		if httpClient == nil {
+			return errors.New("http client is nil")
+		}
+		c.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithEndpoint makes requests to an alternate endpoint, like a caching proxy,
+// rather than DefaultEndpoint
+func WithEndpoint(endpoint string) Option {
+	return func(c *Client) error {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return errors.Errorf("failed to parse graphql endpoint url: %w", err)
+		}
+		c.endpoint = u
+		return nil
+	}
+}
+
+// WithLogger logs retries, and the requests and responses dumped when DEBUG
+// is set, which otherwise go to stdout
+func WithLogger(logf Logf) Option {
+	return func(c *Client) error {
+		c.logf = logf
+		return nil
+	}
+}
+
+// WithRetry retries queries that fail with ErrUnavailable up to retries
+// times, waiting backoff before the first retry and doubling the wait for
+// each after it. Mutations are never retried, a failed response doesn't mean
+// the change wasn't made. By default nothing is retried
+func WithRetry(retries int, backoff time.Duration) Option {
+	return func(c *Client) error {
+		if retries < 0 || backoff < 0 {
+			return errors.Errorf("invalid retry policy of %d retries with %v backoff", retries, backoff)
+		}
+		c.retry = retryPolicy{retries: retries, backoff: backoff}
+		return nil
+	}
+}
+
+// WithTraceContext makes the span in ctx the parent of the spans of fetching
+// org members and of requests made without a context
+func WithTraceContext(ctx context.Context) Option {
+	return func(c *Client) error {
+		c.traceCtx = ctx
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with requests
+func WithUserAgent(ua string) Option {
+	return func(c *Client) error {
+		c.SetUserAgent(ua)
+		return nil
+	}
+}
@@ -0,0 +1,343 @@
+package buildkite
+
+import (
+	"time"
+
+	errors "golang.org/x/xerrors"
+)
+
+// Pipeline is a pipeline in an org
+type Pipeline struct {
+	ID    string
+	Slug  string
+	Name  string
+	Teams []PipelineTeam
+}
+
+// PipelineTeam is a team with access to a pipeline
+type PipelineTeam struct {
+	Slug string
+	// Maintainers are the emails of the team's maintainers
+	Maintainers []string
+}
+
+const pipelinesQuery = `query ($orgSlug: ID!, $after: String) {
+	organization(slug: $orgSlug) {
+		pipelines(first: 100, after: $after) {
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+			edges {
+				node {
+					id
+					slug
+					name
+					teams(first: 25) {
+						edges {
+							node {
+								team {
+									slug
+									members(first: 100, role: MAINTAINER) {
+										edges {
+											node {
+												user {
+													email
+												}
+											}
+										}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+// GetPipelines gets the pipelines in an org, with up to 25 teams each and up
+// to 100 maintainers of each team
+func (c *Client) GetPipelines(orgSlug string) ([]Pipeline, error) {
+	after := ""
+	var result []Pipeline
+
+	for {
+		resp, err := c.Do(pipelinesQuery, map[string]interface{}{
+			`orgSlug`: orgSlug,
+			`after`:   after,
+		})
+		if err != nil {
+			return nil, errors.Errorf("failed to get pipelines: %w", err)
+		}
+
+		var r struct {
+			Data struct {
+				Organization *struct {
+					Pipelines struct {
+						PageInfo pageInfo `json:"pageInfo"`
+						Edges    []struct {
+							Node struct {
+								ID    string `json:"id"`
+								Slug  string `json:"slug"`
+								Name  string `json:"name"`
+								Teams struct {
+									Edges []struct {
+										Node struct {
+											Team struct {
+												Slug    string `json:"slug"`
+												Members struct {
+													Edges []struct {
+														Node struct {
+															User struct {
+																Email string `json:"email"`
+															} `json:"user"`
+														} `json:"node"`
+													} `json:"edges"`
+												} `json:"members"`
+											} `json:"team"`
+										} `json:"node"`
+									} `json:"edges"`
+								} `json:"teams"`
+							} `json:"node"`
+						} `json:"edges"`
+					} `json:"pipelines"`
+				} `json:"organization"`
+			} `json:"data"`
+		}
+
+		if err := resp.DecodeInto(&r); err != nil {
+			return nil, err
+		}
+
+		if r.Data.Organization == nil {
+			return nil, errors.Errorf("failed to get pipelines: %w", ErrOrgNotFound)
+		}
+
+		for _, edge := range r.Data.Organization.Pipelines.Edges {
+			p := Pipeline{
+				ID:   edge.Node.ID,
+				Slug: edge.Node.Slug,
+				Name: edge.Node.Name,
+			}
+
+			for _, teamEdge := range edge.Node.Teams.Edges {
+				team := PipelineTeam{Slug: teamEdge.Node.Team.Slug}
+				for _, memberEdge := range teamEdge.Node.Team.Members.Edges {
+					team.Maintainers = append(team.Maintainers, memberEdge.Node.User.Email)
+				}
+				p.Teams = append(p.Teams, team)
+			}
+
+			result = append(result, p)
+		}
+
+		pageInfo := r.Data.Organization.Pipelines.PageInfo
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == "" {
+			break
+		}
+
+		after = pageInfo.EndCursor
+	}
+
+	return result, nil
+}
+
+// Build is a build of a pipeline
+type Build struct {
+	ID         string
+	Number     int
+	State      string
+	Org        string
+	Pipeline   string
+	CreatedAt  time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	// Creator is who created the build, nil for builds created by triggers
+	// and schedules
+	Creator *BuildCreator
+	Jobs    []Job
+}
+
+// BuildCreator is the user that created a build, unregistered users have no ID
+type BuildCreator struct {
+	ID    string
+	Name  string
+	Email string
+}
+
+// Job is a command job in a build
+type Job struct {
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// Duration returns how long the job ran for, or zero if it didn't finish
+func (j Job) Duration() time.Duration {
+	if j.StartedAt == nil || j.FinishedAt == nil {
+		return 0
+	}
+	return j.FinishedAt.Sub(*j.StartedAt)
+}
+
+const buildsQuery = `query ($slug: ID!, $after: String, $from: DateTime, $to: DateTime) {
+	pipeline(slug: $slug) {
+		builds(first: 100, after: $after, createdAtFrom: $from, createdAtTo: $to) {
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+			edges {
+				node {
+					id
+					number
+					state
+					createdAt
+					startedAt
+					finishedAt
+					createdBy {
+						__typename
+						... on User {
+							id
+							name
+							email
+						}
+						... on UnregisteredUser {
+							name
+							email
+						}
+					}
+					jobs(first: 100, type: COMMAND) {
+						edges {
+							node {
+								... on JobTypeCommand {
+									startedAt
+									finishedAt
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+// GetBuilds gets the builds of a pipeline created between from and to, with
+// up to the first 100 command jobs of each
+func (c *Client) GetBuilds(orgSlug string, pipelineSlug string, from, to time.Time) ([]Build, error) {
+	after := ""
+	var result []Build
+
+	for {
+		resp, err := c.Do(buildsQuery, map[string]interface{}{
+			`slug`:  orgSlug + "/" + pipelineSlug,
+			`after`: after,
+			`from`:  from.UTC().Format(time.RFC3339),
+			`to`:    to.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return nil, errors.Errorf("failed to get builds: %w", err)
+		}
+
+		var r struct {
+			Data struct {
+				Pipeline *struct {
+					Builds struct {
+						PageInfo pageInfo `json:"pageInfo"`
+						Edges    []struct {
+							Node struct {
+								ID         string     `json:"id"`
+								Number     int        `json:"number"`
+								State      string     `json:"state"`
+								CreatedAt  time.Time  `json:"createdAt"`
+								StartedAt  *time.Time `json:"startedAt"`
+								FinishedAt *time.Time `json:"finishedAt"`
+								CreatedBy  *struct {
+									Typename string `json:"__typename"`
+									// only selected on some types of creator
+									ID    string `json:"id,omitempty"`
+									Name  string `json:"name,omitempty"`
+									Email string `json:"email,omitempty"`
+								} `json:"createdBy"`
+								Jobs struct {
+									Edges []struct {
+										Node struct {
+											StartedAt  *time.Time `json:"startedAt"`
+											FinishedAt *time.Time `json:"finishedAt"`
+										} `json:"node"`
+									} `json:"edges"`
+								} `json:"jobs"`
+							} `json:"node"`
+						} `json:"edges"`
+					} `json:"builds"`
+				} `json:"pipeline"`
+			} `json:"data"`
+		}
+
+		if err := resp.DecodeInto(&r); err != nil {
+			return nil, err
+		}
+
+		// a pipeline that was deleted since it was listed has no builds
+		if r.Data.Pipeline == nil {
+			break
+		}
+
+		for _, edge := range r.Data.Pipeline.Builds.Edges {
+			b := Build{
+				ID:         edge.Node.ID,
+				Number:     edge.Node.Number,
+				State:      edge.Node.State,
+				Org:        orgSlug,
+				Pipeline:   pipelineSlug,
+				CreatedAt:  edge.Node.CreatedAt,
+				StartedAt:  edge.Node.StartedAt,
+				FinishedAt: edge.Node.FinishedAt,
+			}
+
+			if cb := edge.Node.CreatedBy; cb != nil && cb.Email != "" {
+				b.Creator = &BuildCreator{ID: cb.ID, Name: cb.Name, Email: cb.Email}
+			}
+
+			for _, jobEdge := range edge.Node.Jobs.Edges {
+				b.Jobs = append(b.Jobs, Job{
+					StartedAt:  jobEdge.Node.StartedAt,
+					FinishedAt: jobEdge.Node.FinishedAt,
+				})
+			}
+
+			result = append(result, b)
+		}
+
+		pageInfo := r.Data.Pipeline.Builds.PageInfo
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == "" {
+			break
+		}
+
+		after = pageInfo.EndCursor
+	}
+
+	return result, nil
+}
+
+// GetOrgBuilds gets the builds of every pipeline in an org created between
+// from and to
+func (c *Client) GetOrgBuilds(orgSlug string, from, to time.Time) ([]Build, error) {
+	pipelines, err := c.GetPipelines(orgSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Build
+	for _, p := range pipelines {
+		builds, err := c.GetBuilds(orgSlug, p.Slug, from, to)
+		if err != nil {
+			return nil, errors.Errorf("%s: %w", p.Slug, err)
+		}
+		result = append(result, builds...)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,39 @@
+package buildkite
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"reflect"
+)
+
+// OrgMembersShape returns a short hash of the org members query and the
+// OrgMember struct its results decode into, which changes when either does.
+// It's used to key saved members so they aren't decoded by an incompatible
+// version of this tool
+func OrgMembersShape() string {
+	h := sha256.New()
+	h.Write([]byte(OrgMembersPage_Operation))
+	writeTypeShape(h, reflect.TypeOf(OrgMember{}))
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// writeTypeShape writes the fields of a struct and any structs from this
+// package it contains
+func writeTypeShape(h hash.Hash, t reflect.Type) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.PkgPath() != reflect.TypeOf(OrgMember{}).PkgPath() {
+		return
+	}
+
+	fmt.Fprintf(h, "%s{", t.Name())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fmt.Fprintf(h, "%s %s %q;", f.Name, f.Type, f.Tag)
+		writeTypeShape(h, f.Type)
+	}
+	fmt.Fprint(h, "}")
+}
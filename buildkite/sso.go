@@ -0,0 +1,93 @@
+package buildkite
+
+import (
+	"time"
+
+	errors "golang.org/x/xerrors"
+)
+
+// SSOProvider is an SSO provider configured for an org
+type SSOProvider struct {
+	// Type is like SAML, GOOGLE_GSUITE or GITHUB
+	Type string
+	// State is CREATED, ENABLED or DISABLED
+	State string
+	// SessionDuration is how long an SSO session lasts, zero if unset
+	SessionDuration time.Duration
+	EmailDomain     string
+	EnabledAt       *time.Time
+}
+
+// Enforced returns whether members must authorize with the provider, which is
+// the case once it's enabled
+func (p SSOProvider) Enforced() bool {
+	return p.State == "ENABLED"
+}
+
+const ssoProvidersQuery = `query ($orgSlug: ID!) {
+	organization(slug: $orgSlug) {
+		ssoProviders(first: 100) {
+			edges {
+				node {
+					type
+					state
+					sessionDurationInHours
+					emailDomain
+					enabledAt
+				}
+			}
+		}
+	}
+}`
+
+// GetSSOProviders gets the SSO providers configured for an org
+func (c *Client) GetSSOProviders(orgSlug string) ([]SSOProvider, error) {
+	resp, err := c.Do(ssoProvidersQuery, map[string]interface{}{
+		`orgSlug`: orgSlug,
+	})
+	if err != nil {
+		return nil, errors.Errorf("failed to get sso providers: %w", err)
+	}
+
+	var r struct {
+		Data struct {
+			Organization *struct {
+				SSOProviders struct {
+					Edges []struct {
+						Node struct {
+							Type                   string     `json:"type"`
+							State                  string     `json:"state"`
+							SessionDurationInHours *int       `json:"sessionDurationInHours"`
+							EmailDomain            *string    `json:"emailDomain"`
+							EnabledAt              *time.Time `json:"enabledAt"`
+						} `json:"node"`
+					} `json:"edges"`
+				} `json:"ssoProviders"`
+			} `json:"organization"`
+		} `json:"data"`
+	}
+
+	if err := resp.DecodeInto(&r); err != nil {
+		return nil, err
+	}
+
+	if r.Data.Organization == nil {
+		return nil, errors.Errorf("failed to get sso providers: %w", ErrOrgNotFound)
+	}
+
+	var result []SSOProvider
+	for _, edge := range r.Data.Organization.SSOProviders.Edges {
+		p := SSOProvider{
+			Type:        edge.Node.Type,
+			State:       edge.Node.State,
+			EmailDomain: stringValue(edge.Node.EmailDomain),
+			EnabledAt:   edge.Node.EnabledAt,
+		}
+		if edge.Node.SessionDurationInHours != nil {
+			p.SessionDuration = time.Duration(*edge.Node.SessionDurationInHours) * time.Hour
+		}
+		result = append(result, p)
+	}
+
+	return result, nil
+}
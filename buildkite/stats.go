@@ -0,0 +1,145 @@
+package buildkite
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Stats is an accounting of the API usage of a Client
+type Stats struct {
+	Requests      int
+	BytesSent     int64
+	BytesReceived int64
+	Duration      time.Duration
+
+	// RateLimit is the rate limit reported by the most recent response, and
+	// RateLimitStart and RateLimitRemaining the remaining points reported by
+	// the first and most recent responses
+	RateLimit          int
+	RateLimitStart     int
+	RateLimitRemaining int
+
+	// Rejected is the number of requests refused because the client's request
+	// budget was exhausted
+	Rejected int
+
+	Orgs map[string]OrgStats
+}
+
+// RateLimitConsumed returns how many rate limit points were consumed over the run
+func (s Stats) RateLimitConsumed() int {
+	if s.RateLimitStart < s.RateLimitRemaining {
+		return 0
+	}
+	return s.RateLimitStart - s.RateLimitRemaining
+}
+
+// OrgStats is an accounting of the API usage for a single org
+type OrgStats struct {
+	Pages    int
+	Members  int
+	Duration time.Duration
+
+	// PageDurations is how long each page took to fetch and decode, in order.
+	// Pages fetched in a batch are timed for the org that fetched the batch
+	PageDurations []time.Duration
+	// Decode is the part of Duration spent decoding pages rather than waiting
+	// on the API
+	Decode time.Duration
+}
+
+type statsRecorder struct {
+	sync.Mutex
+	stats   Stats
+	started int
+}
+
+// reserve counts a request about to be sent, returning false if max requests
+// have already been started
+func (r *statsRecorder) reserve(max int) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	if max > 0 && r.started >= max {
+		r.stats.Rejected++
+		return false
+	}
+	r.started++
+	return true
+}
+
+func (r *statsRecorder) recordRequest(sent int, resp *http.Response, d time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.stats.Requests++
+	r.stats.BytesSent += int64(sent)
+	r.stats.Duration += d
+
+	if resp == nil {
+		return
+	}
+
+	if resp.ContentLength > 0 {
+		r.stats.BytesReceived += resp.ContentLength
+	}
+
+	if limit, err := strconv.Atoi(resp.Header.Get(`RateLimit-Limit`)); err == nil {
+		r.stats.RateLimit = limit
+	}
+
+	if remaining, err := strconv.Atoi(resp.Header.Get(`RateLimit-Remaining`)); err == nil {
+		if r.stats.RateLimitStart == 0 {
+			r.stats.RateLimitStart = remaining
+		}
+		r.stats.RateLimitRemaining = remaining
+	}
+}
+
+func (r *statsRecorder) recordOrg(orgSlug string, o OrgStats) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.stats.Orgs == nil {
+		r.stats.Orgs = make(map[string]OrgStats)
+	}
+
+	s := r.stats.Orgs[orgSlug]
+	s.Pages += o.Pages
+	s.Members += o.Members
+	s.Duration += o.Duration
+	s.PageDurations = append(s.PageDurations, o.PageDurations...)
+	s.Decode += o.Decode
+	r.stats.Orgs[orgSlug] = s
+}
+
+func (r *statsRecorder) snapshot() Stats {
+	r.Lock()
+	defer r.Unlock()
+
+	s := r.stats
+	s.Orgs = make(map[string]OrgStats, len(r.stats.Orgs))
+	for k, v := range r.stats.Orgs {
+		v.PageDurations = append([]time.Duration(nil), v.PageDurations...)
+		s.Orgs[k] = v
+	}
+	return s
+}
+
+type decodeTimerKey struct{}
+
+// withDecodeTimer returns a context that adds the time spent decoding the
+// responses to requests made with it to d
+func withDecodeTimer(ctx context.Context, d *time.Duration) context.Context {
+	return context.WithValue(ctx, decodeTimerKey{}, d)
+}
+
+// addDecodeTime adds to the decode timer of the context, if it has one
+func addDecodeTime(ctx context.Context, d time.Duration) {
+	if timer, ok := ctx.Value(decodeTimerKey{}).(*time.Duration); ok {
+		*timer += d
+	}
+}
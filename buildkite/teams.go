@@ -0,0 +1,297 @@
+package buildkite
+
+import (
+	"io/ioutil"
+
+	errors "golang.org/x/xerrors"
+)
+
+// Team is a team in an org
+type Team struct {
+	ID      string
+	Slug    string
+	Name    string
+	Members []TeamMember
+}
+
+// TeamMember is a member of a team
+type TeamMember struct {
+	// ID identifies the team membership for mutations
+	ID     string
+	UserID string
+	Email  string
+	// Role is MEMBER or MAINTAINER
+	Role string
+}
+
+const teamsQuery = `query ($orgSlug: ID!, $after: String) {
+	organization(slug: $orgSlug) {
+		teams(first: 100, after: $after) {
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+			edges {
+				node {
+					id
+					slug
+					name
+					members(first: 100) {
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
+						edges {
+							node {
+								id
+								role
+								user {
+									id
+									email
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+const teamMembersQuery = `query ($slug: ID!, $after: String) {
+	team(slug: $slug) {
+		members(first: 100, after: $after) {
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+			edges {
+				node {
+					id
+					role
+					user {
+						id
+						email
+					}
+				}
+			}
+		}
+	}
+}`
+
+type teamMembers struct {
+	PageInfo pageInfo `json:"pageInfo"`
+	Edges    []struct {
+		Node struct {
+			ID   string `json:"id"`
+			Role string `json:"role"`
+			User struct {
+				ID    string `json:"id"`
+				Email string `json:"email"`
+			} `json:"user"`
+		} `json:"node"`
+	} `json:"edges"`
+}
+
+func (m teamMembers) members() []TeamMember {
+	var result []TeamMember
+	for _, edge := range m.Edges {
+		result = append(result, TeamMember{
+			ID:     edge.Node.ID,
+			UserID: edge.Node.User.ID,
+			Email:  edge.Node.User.Email,
+			Role:   edge.Node.Role,
+		})
+	}
+	return result
+}
+
+// GetTeams gets the teams in an org with all of their members
+func (c *Client) GetTeams(orgSlug string) ([]Team, error) {
+	after := ""
+	var result []Team
+
+	for {
+		resp, err := c.Do(teamsQuery, map[string]interface{}{
+			`orgSlug`: orgSlug,
+			`after`:   after,
+		})
+		if err != nil {
+			return nil, errors.Errorf("failed to get teams: %w", err)
+		}
+
+		var r struct {
+			Data struct {
+				Organization *struct {
+					Teams struct {
+						PageInfo pageInfo `json:"pageInfo"`
+						Edges    []struct {
+							Node struct {
+								ID      string      `json:"id"`
+								Slug    string      `json:"slug"`
+								Name    string      `json:"name"`
+								Members teamMembers `json:"members"`
+							} `json:"node"`
+						} `json:"edges"`
+					} `json:"teams"`
+				} `json:"organization"`
+			} `json:"data"`
+		}
+
+		if err := resp.DecodeInto(&r); err != nil {
+			return nil, err
+		}
+
+		if r.Data.Organization == nil {
+			return nil, errors.Errorf("failed to get teams: %w", ErrOrgNotFound)
+		}
+
+		for _, edge := range r.Data.Organization.Teams.Edges {
+			t := Team{
+				ID:      edge.Node.ID,
+				Slug:    edge.Node.Slug,
+				Name:    edge.Node.Name,
+				Members: edge.Node.Members.members(),
+			}
+
+			if pageInfo := edge.Node.Members.PageInfo; pageInfo.HasNextPage && pageInfo.EndCursor != "" {
+				rest, err := c.getTeamMembers(orgSlug+"/"+t.Slug, pageInfo.EndCursor)
+				if err != nil {
+					return nil, err
+				}
+				t.Members = append(t.Members, rest...)
+			}
+
+			result = append(result, t)
+		}
+
+		pageInfo := r.Data.Organization.Teams.PageInfo
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == "" {
+			break
+		}
+
+		after = pageInfo.EndCursor
+	}
+
+	return result, nil
+}
+
+// getTeamMembers gets the members of a team by its org/team slug, starting
+// after the provided cursor
+func (c *Client) getTeamMembers(slug string, after string) ([]TeamMember, error) {
+	var result []TeamMember
+
+	for {
+		resp, err := c.Do(teamMembersQuery, map[string]interface{}{
+			`slug`:  slug,
+			`after`: after,
+		})
+		if err != nil {
+			return nil, errors.Errorf("failed to get team members: %w", err)
+		}
+
+		var r struct {
+			Data struct {
+				Team *struct {
+					Members teamMembers `json:"members"`
+				} `json:"team"`
+			} `json:"data"`
+		}
+
+		if err := resp.DecodeInto(&r); err != nil {
+			return nil, err
+		}
+
+		if r.Data.Team == nil {
+			return nil, errors.Errorf("failed to get team members: team %s not found", slug)
+		}
+
+		result = append(result, r.Data.Team.Members.members()...)
+
+		pageInfo := r.Data.Team.Members.PageInfo
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == "" {
+			break
+		}
+
+		after = pageInfo.EndCursor
+	}
+
+	return result, nil
+}
+
+const addTeamMemberMutation = `mutation ($teamID: ID!, $userID: ID!, $role: TeamMemberRole!) {
+	teamMemberCreate(input: {teamID: $teamID, userID: $userID, role: $role}) {
+		teamMemberEdge {
+			node {
+				id
+			}
+		}
+	}
+}`
+
+// AddTeamMember adds a user to a team with a role of MEMBER or MAINTAINER,
+// returning the response body for auditing, even if it failed
+func (c *Client) AddTeamMember(teamID, userID, role string) ([]byte, error) {
+	body, err := c.mutate(addTeamMemberMutation, map[string]interface{}{
+		`teamID`: teamID,
+		`userID`: userID,
+		`role`:   role,
+	})
+	if err != nil {
+		return body, errors.Errorf("failed to add team member: %w", err)
+	}
+	return body, nil
+}
+
+const updateTeamMemberMutation = `mutation ($id: ID!, $role: TeamMemberRole!) {
+	teamMemberUpdate(input: {id: $id, role: $role}) {
+		teamMember {
+			id
+			role
+		}
+	}
+}`
+
+// UpdateTeamMember changes the role of a team membership, returning the
+// response body for auditing, even if it failed
+func (c *Client) UpdateTeamMember(teamMemberID, role string) ([]byte, error) {
+	body, err := c.mutate(updateTeamMemberMutation, map[string]interface{}{
+		`id`:   teamMemberID,
+		`role`: role,
+	})
+	if err != nil {
+		return body, errors.Errorf("failed to update team member: %w", err)
+	}
+	return body, nil
+}
+
+const removeTeamMemberMutation = `mutation ($id: ID!) {
+	teamMemberDelete(input: {id: $id}) {
+		deletedTeamMemberID
+	}
+}`
+
+// RemoveTeamMember removes a team membership, returning the response body
+// for auditing, even if it failed
+func (c *Client) RemoveTeamMember(teamMemberID string) ([]byte, error) {
+	body, err := c.mutate(removeTeamMemberMutation, map[string]interface{}{
+		`id`: teamMemberID,
+	})
+	if err != nil {
+		return body, errors.Errorf("failed to remove team member: %w", err)
+	}
+	return body, nil
+}
+
+// mutate sends a mutation and returns the response body, even if it failed
+func (c *Client) mutate(mutation string, vars map[string]interface{}) ([]byte, error) {
+	resp, err := c.Do(mutation, vars)
+
+	var body []byte
+	if resp != nil {
+		body, _ = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	return body, err
+}
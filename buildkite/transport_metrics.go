@@ -0,0 +1,161 @@
+package buildkite
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds of the latency histogram of a
+// MetricsTransport without buckets of its own
+var DefaultLatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// HTTPMetrics are the metrics of the requests made through a MetricsTransport
+type HTTPMetrics struct {
+	// Requests counts requests by response status code, or "error" for
+	// requests that failed without a response
+	Requests map[string]int
+	// Retries counts requests that retried an earlier failed request
+	Retries       int
+	BytesSent     int64
+	BytesReceived int64
+	// Latency is the time to each response's headers
+	Latency LatencyHistogram
+}
+
+// LatencyHistogram counts latencies in buckets, cumulatively like Prometheus,
+// so Counts[i] is the number at or under Buckets[i]
+type LatencyHistogram struct {
+	Buckets []time.Duration
+	Counts  []int
+	Count   int
+	Sum     time.Duration
+}
+
+// MetricsTransport is an http.RoundTripper that records the count, latency,
+// status codes, retries and bytes of the requests it makes
+type MetricsTransport struct {
+	Transport http.RoundTripper
+	// Buckets are the upper bounds of the latency histogram, defaulting to
+	// DefaultLatencyBuckets
+	Buckets []time.Duration
+
+	mu      sync.Mutex
+	metrics HTTPMetrics
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *MetricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+	latency := time.Since(start)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+
+	t.mu.Lock()
+	t.init()
+	m := &t.metrics
+	m.Requests[status]++
+	if RetryAttempt(req) > 0 {
+		m.Retries++
+	}
+	if req.ContentLength > 0 {
+		m.BytesSent += req.ContentLength
+	}
+	m.Latency.Count++
+	m.Latency.Sum += latency
+	for i, bound := range m.Latency.Buckets {
+		if latency <= bound {
+			m.Latency.Counts[i]++
+		}
+	}
+	t.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = &countingBody{ReadCloser: resp.Body, t: t}
+	return resp, nil
+}
+
+// Metrics returns a copy of the metrics of the requests made so far
+func (t *MetricsTransport) Metrics() HTTPMetrics {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.init()
+
+	m := t.metrics
+	m.Requests = make(map[string]int, len(t.metrics.Requests))
+	for status, n := range t.metrics.Requests {
+		m.Requests[status] = n
+	}
+	m.Latency.Buckets = append([]time.Duration(nil), t.metrics.Latency.Buckets...)
+	m.Latency.Counts = append([]int(nil), t.metrics.Latency.Counts...)
+	return m
+}
+
+// init sets up the metrics before the first request, with t.mu held
+func (t *MetricsTransport) init() {
+	if t.metrics.Requests != nil {
+		return
+	}
+	buckets := t.Buckets
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	t.metrics.Requests = map[string]int{}
+	t.metrics.Latency.Buckets = buckets
+	t.metrics.Latency.Counts = make([]int, len(buckets))
+}
+
+// countingBody adds the bytes read from a response body to its transport's
+// metrics
+type countingBody struct {
+	io.ReadCloser
+	t *MetricsTransport
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.t.mu.Lock()
+		b.t.metrics.BytesReceived += int64(n)
+		b.t.mu.Unlock()
+	}
+	return n, err
+}
+
+type retryAttemptKey struct{}
+
+// withRetryAttempt returns a context for the requests of the nth retry
+func withRetryAttempt(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, retryAttemptKey{}, n)
+}
+
+// RetryAttempt returns which retry a request made by a Client is, or zero for
+// its first attempt
+func RetryAttempt(req *http.Request) int {
+	n, _ := req.Context().Value(retryAttemptKey{}).(int)
+	return n
+}
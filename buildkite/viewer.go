@@ -0,0 +1,108 @@
+package buildkite
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	errors "golang.org/x/xerrors"
+)
+
+const (
+	// DefaultRESTEndpoint is the Buildkite REST API
+	DefaultRESTEndpoint = "https://api.buildkite.com/v2"
+)
+
+// Viewer is the user a token belongs to
+type Viewer struct {
+	ID    string
+	Name  string
+	Email string
+}
+
+const viewerQuery = `query {
+	viewer {
+		user {
+			id
+			name
+			email
+		}
+	}
+}`
+
+// GetViewer gets the user the token belongs to
+func (c *Client) GetViewer() (*Viewer, error) {
+	resp, err := c.Do(viewerQuery, nil)
+	if err != nil {
+		return nil, errors.Errorf("failed to get viewer: %w", err)
+	}
+
+	var r struct {
+		Data struct {
+			Viewer struct {
+				User struct {
+					ID    string `json:"id"`
+					Name  string `json:"name"`
+					Email string `json:"email"`
+				} `json:"user"`
+			} `json:"viewer"`
+		} `json:"data"`
+	}
+
+	if err := resp.DecodeInto(&r); err != nil {
+		return nil, err
+	}
+
+	return &Viewer{
+		ID:    r.Data.Viewer.User.ID,
+		Name:  r.Data.Viewer.User.Name,
+		Email: r.Data.Viewer.User.Email,
+	}, nil
+}
+
+// AccessToken describes the token the client uses
+type AccessToken struct {
+	UUID   string
+	Scopes []string
+}
+
+// GetAccessToken gets the token's scopes from the REST API at restEndpoint,
+// as the GraphQL API doesn't expose them
+func (c *Client) GetAccessToken(restEndpoint string) (*AccessToken, error) {
+	if !c.stats.reserve(c.maxRequests) {
+		return nil, errors.Errorf("%d requests made: %w", c.maxRequests, ErrRequestBudgetExceeded)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(restEndpoint, "/")+"/access-token", nil)
+	if err != nil {
+		return nil, errors.Errorf("failed to create http request: %w", err)
+	}
+	req.Header = c.header
+
+	t := time.Now()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.stats.recordRequest(0, nil, time.Since(t))
+		return nil, errors.Errorf("failed to get access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	err = checkResponseForErrors(resp)
+	c.stats.recordRequest(0, resp, time.Since(t))
+	if err != nil {
+		return nil, errors.Errorf("failed to get access token: %w", err)
+	}
+
+	var r struct {
+		UUID   string   `json:"uuid"`
+		Scopes []string `json:"scopes"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, errors.Errorf("error decoding access token: %w", err)
+	}
+
+	return &AccessToken{UUID: r.UUID, Scopes: r.Scopes}, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// legacyCacheDir is where the cache was kept before it defaulted to the user
+// cache dir
+const legacyCacheDir = ".cache"
+
+// resolveCacheDir defaults --cache-dir to buildkite-accounter in the user
+// cache dir, moving a cache from the old ./.cache default there if it has one
+func (c *cli) resolveCacheDir() {
+	if c.CacheDir != "" {
+		return
+	}
+
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		c.CacheDir = legacyCacheDir
+		return
+	}
+	c.CacheDir = filepath.Join(userCacheDir, "buildkite-accounter")
+
+	if !isLegacyCache(legacyCacheDir) {
+		return
+	}
+
+	if _, err := os.Stat(c.CacheDir); err == nil {
+		log.Printf("Warning: ignoring the old cache in ./%s, the cache is now in %s", legacyCacheDir, c.CacheDir)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.CacheDir), 0700); err == nil {
+		if err = os.Rename(legacyCacheDir, c.CacheDir); err == nil {
+			log.Printf("Moved the cache from ./%s to %s", legacyCacheDir, c.CacheDir)
+			return
+		}
+	}
+
+	// e.g. the user cache dir is on another filesystem
+	log.Printf("Warning: using the old cache in ./%s, move it to %s or set --cache-dir", legacyCacheDir, c.CacheDir)
+	c.CacheDir = legacyCacheDir
+}
+
+// isLegacyCache returns whether dir has files written by this tool, rather
+// than being some other tool's .cache
+func isLegacyCache(dir string) bool {
+	for _, pattern := range []string{"members", "checkpoints", "audit.log", "run.lock", "last-run-*"} {
+		if matches, _ := filepath.Glob(filepath.Join(dir, pattern)); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
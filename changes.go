@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+	"github.com/lox/buildkite-accounter/internal/state"
+)
+
+// lastRun is the state kept about the last complete run
+type lastRun struct {
+	Time    time.Time       `json:"time"`
+	Members []report.Member `json:"members"`
+}
+
+// loadLastRun returns the last complete run for the current set of orgs, or
+// nil if there wasn't one
+func (c *cli) loadLastRun(store state.Store) (*lastRun, error) {
+	data, err := store.Get(c.lastRunKey("json"))
+	if errors.Is(err, state.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var run lastRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// saveLastRun records the report's members as the last complete run
+func (c *cli) saveLastRun(store state.Store, rep *report.Report, t time.Time) error {
+	data, err := json.Marshal(lastRun{Time: t, Members: rep.Members})
+	if err != nil {
+		return err
+	}
+	return store.Put(c.lastRunKey("json"), data)
+}
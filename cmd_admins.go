@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type adminsCmd struct {
+	MaxAdmins int    `flag:"" help:"Exit with status 5 if any org has more admins than this, zero for no limit"`
+	Output    string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+// orgAdmins is the number of admins in an org
+type orgAdmins struct {
+	Org    string `json:"org"`
+	Admins int    `json:"admins"`
+}
+
+func (a *adminsCmd) Run(c *cli) error {
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	rep, err := c.buildReport(client, report.FilterOptions{}, nil)
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, org := range rep.Orgs {
+		counts[org] = 0
+	}
+
+	admins := []report.Member{}
+	for _, m := range rep.Members {
+		if m.Role == `admin` {
+			admins = append(admins, m)
+			counts[m.Org]++
+		}
+	}
+	sort.SliceStable(admins, func(i, j int) bool {
+		if admins[i].Org != admins[j].Org {
+			return admins[i].Org < admins[j].Org
+		}
+		return admins[i].Email < admins[j].Email
+	})
+
+	perOrg := make([]orgAdmins, 0, len(counts))
+	for _, org := range rep.Orgs {
+		perOrg = append(perOrg, orgAdmins{Org: org, Admins: counts[org]})
+	}
+
+	rows := make([][]string, 0, len(admins))
+	for _, m := range admins {
+		lastAuth := ""
+		if m.LastAuth != nil {
+			lastAuth = m.LastAuth.Format(`2006-01-02 15:04:05`)
+		}
+		rows = append(rows, []string{m.Org, m.Email, m.Name, lastAuth})
+	}
+
+	switch a.Output {
+	case `json`:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Admins []report.Member `json:"admins"`
+			Orgs   []orgAdmins     `json:"orgs"`
+		}{admins, perOrg}); err != nil {
+			return err
+		}
+	case `csv`:
+		if err := writeTable(a.Output, nil, []string{"org", "email", "name", "last_sso_auth"}, rows); err != nil {
+			return err
+		}
+	default:
+		// the table is followed by the counts per org
+		if err := writeTable(a.Output, nil, []string{"org", "email", "name", "last_sso_auth"}, rows); err != nil {
+			return err
+		}
+		fmt.Println()
+		countRows := make([][]string, 0, len(perOrg))
+		for _, o := range perOrg {
+			countRows = append(countRows, []string{o.Org, fmt.Sprint(o.Admins)})
+		}
+		if err := writeTable(a.Output, nil, []string{"org", "admins"}, countRows); err != nil {
+			return err
+		}
+	}
+
+	breaches, _ := report.CheckThresholds(rep.Orgs, rep.Members, report.Thresholds{MaxAdmins: a.MaxAdmins})
+	for _, b := range breaches {
+		log.Print(b.Summary)
+	}
+	if len(breaches) > 0 {
+		return &exitError{
+			code: exitPolicyViolated,
+			err:  errors.New("orgs have more admins than --max-admins"),
+		}
+	}
+
+	return nil
+}
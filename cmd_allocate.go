@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type allocateCmd struct {
+	By           string  `flag:"" help:"The member field to allocate seats by, like domain, org, labels.cost_center or enrichment.team from --enrich-csv" default:"domain"`
+	Separator    string  `flag:"" help:"Split the field into several values on this separator, for members in several teams"`
+	Multi        string  `flag:"" help:"How to allocate the seat of a member with several values: split it evenly, charge the first, or charge each the whole seat" enum:"split,first,each" default:"split"`
+	PricePerSeat float64 `flag:"" help:"The monthly price of a seat" required:""`
+	Months       int     `flag:"" help:"The number of months to allocate, e.g. 3 for a quarter" default:"1"`
+	Output       string  `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (a *allocateCmd) Run(c *cli) error {
+	filter, err := c.filterOptions()
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	rep, err := c.buildReport(client, filter, nil)
+	if err != nil {
+		return err
+	}
+
+	members, err := report.FilterMembers(rep.Members, filter)
+	if err != nil {
+		return err
+	}
+
+	allocations, err := report.Allocate(members, report.AllocateOptions{
+		By:        a.By,
+		Separator: a.Separator,
+		Multi:     a.Multi,
+		SeatPrice: a.PricePerSeat * float64(a.Months),
+	})
+	if err != nil {
+		return err
+	}
+
+	var seats, cost float64
+	for _, al := range allocations {
+		seats += al.Seats
+		cost += al.Cost
+	}
+
+	rows := make([][]string, 0, len(allocations)+1)
+	for _, al := range allocations {
+		rows = append(rows, []string{al.Group, formatSeats(al.Seats), fmt.Sprintf("%.2f", al.Cost), fmt.Sprintf("%.1f%%", al.Seats/seats*100)})
+	}
+	if len(allocations) > 0 {
+		rows = append(rows, []string{"total", formatSeats(seats), fmt.Sprintf("%.2f", cost), "100.0%"})
+	}
+
+	return writeTable(a.Output, allocations, []string{a.By, "seats", "cost", "share"}, rows)
+}
+
+// formatSeats formats a number of seats to two decimal places, as they can be
+// fractional when they're split between groups
+func formatSeats(n float64) string {
+	return strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
+}
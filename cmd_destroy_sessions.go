@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/audit"
+)
+
+type destroySessionsCmd struct {
+	guardrails `embed:""`
+
+	Email  string `arg:"" help:"The account or SSO email of the member to log out"`
+	DryRun bool   `flag:"" help:"Only show and audit the sessions that would be destroyed, --no-dry-run destroys them" default:"true" negatable:""`
+}
+
+func (d *destroySessionsCmd) Run(c *cli) error {
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	// fail before changing anything if mutations can't be audited
+	auditLog, err := c.openAuditLog()
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+
+	memberships, err := findMemberships(c, client, d.Email)
+	if err != nil {
+		return err
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	if len(memberships) == 0 {
+		return fmt.Errorf("%s isn't a member of any org", d.Email)
+	}
+
+	ok, err := d.approve(describeMemberships(memberships), d.DryRun, fmt.Sprintf("Destroy the sessions of %s in %d orgs?", d.Email, len(memberships)))
+	if err != nil || !ok {
+		return err
+	}
+
+	destroyed := "Destroyed"
+	if d.DryRun {
+		destroyed = "Would destroy"
+	}
+
+	var failed int
+	for _, m := range memberships {
+		if err := destroySessions(client, auditLog, m, d.DryRun); err != nil {
+			log.Printf("Failed to destroy sessions of %s in %s: %v", m.Member.Email, m.Org, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s sessions of %s in %s\n", destroyed, m.Member.Email, m.Org)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to destroy sessions in %d orgs", failed)
+	}
+
+	return nil
+}
+
+// membership is a member of an org
+type membership struct {
+	Org    string
+	Member buildkite.OrgMember
+}
+
+// findMemberships returns the members of the selected orgs with the account
+// or SSO email, fetched fresh rather than from the cache as they're changed
+func findMemberships(c *cli, client *buildkite.Client, email string) ([]membership, error) {
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []membership
+	for _, orgSlug := range orgSlugs {
+		if c.Debug {
+			log.Printf("Finding %s in %s", email, orgSlug)
+		}
+
+		members, err := client.GetOrgMembers(orgSlug)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", orgSlug, err)
+		}
+
+		for _, m := range members {
+			if memberHasEmail(m, email) {
+				result = append(result, membership{Org: orgSlug, Member: m})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// describeMemberships describes each membership for confirmation
+func describeMemberships(memberships []membership) []string {
+	result := make([]string, 0, len(memberships))
+	for _, m := range memberships {
+		result = append(result, fmt.Sprintf("%s (%s) in %s", m.Member.Email, m.Member.Name, m.Org))
+	}
+	return result
+}
+
+// destroySessions logs a member out of their org, or just audits it with dryRun
+func destroySessions(client *buildkite.Client, auditLog *audit.Log, m membership, dryRun bool) error {
+	if m.Member.MembershipID == "" {
+		return fmt.Errorf("no membership id for %s", m.Member.Email)
+	}
+
+	entry := audit.Entry{
+		Action:   "destroy_user_sessions",
+		Target:   m.Member.Email,
+		TargetID: m.Member.MembershipID,
+		Org:      m.Org,
+		DryRun:   dryRun,
+	}
+
+	return auditMutation(auditLog, entry, func() ([]byte, error) {
+		return client.DestroyUserSessions(m.Member.MembershipID)
+	})
+}
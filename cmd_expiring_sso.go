@@ -0,0 +1,63 @@
+package main
+
+import (
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type expiringSSOCmd struct {
+	Within    string `flag:"" help:"List authorizations expiring within this long" default:"14d"`
+	NoExpired bool   `flag:"" name:"no-expired" help:"Leave out authorizations that have already expired"`
+	Output    string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (e *expiringSSOCmd) Run(c *cli) error {
+	within, err := report.ParseDuration(e.Within)
+	if err != nil {
+		return err
+	}
+
+	filter, err := c.filterOptions()
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	// expiry is part of the SSO details, which are otherwise left out
+	c.WithSSODetails = true
+
+	rep, err := c.buildReport(client, filter, nil)
+	if err != nil {
+		return err
+	}
+
+	var members []report.Member
+	for _, m := range rep.Members {
+		ok, err := filter.Matches(m)
+		if err != nil {
+			return err
+		}
+		if ok {
+			members = append(members, m)
+		}
+	}
+
+	now := time.Now()
+	expiring := report.ExpiringSSOAuthorizations(members, now, within, !e.NoExpired)
+
+	rows := make([][]string, 0, len(expiring))
+	for _, x := range expiring {
+		in := formatAge(x.ExpiresAt.Sub(now))
+		if x.Expired {
+			in = "expired " + formatAge(now.Sub(x.ExpiresAt)) + " ago"
+		}
+		rows = append(rows, []string{x.Org, x.Email, x.Name, x.Role, x.State, x.ExpiresAt.Format("2006-01-02 15:04"), in})
+	}
+
+	return writeTable(e.Output, expiring, []string{"org", "email", "name", "role", "state", "expires_at", "expires_in"}, rows)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type forecastCmd struct {
+	Months       []int   `flag:"" help:"How many months ahead to project seats" default:"3,6,12"`
+	Window       string  `flag:"" help:"How much recent history to fit the trend to" default:"180d"`
+	PricePerSeat float64 `flag:"" help:"The monthly price of a seat, to project the monthly cost"`
+	Output       string  `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (f *forecastCmd) Run(c *cli) error {
+	window, err := report.ParseDuration(f.Window)
+	if err != nil {
+		return err
+	}
+
+	store, err := c.stateStore()
+	if err != nil {
+		return err
+	}
+
+	history, err := loadSeatHistory(store)
+	if err != nil {
+		return err
+	}
+
+	for org := range history {
+		ok, err := c.includesOrg(org)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			delete(history, org)
+		}
+	}
+
+	if len(history) == 0 {
+		return fmt.Errorf("no seat history in the state store yet, it's recorded by each complete report run")
+	}
+
+	sort.Ints(f.Months)
+	forecasts := report.ForecastSeats(history, window, f.Months, f.PricePerSeat)
+
+	header := []string{"org", "seats", "samples", "per_month"}
+	for _, m := range f.Months {
+		header = append(header, fmt.Sprintf("in_%dm", m))
+		if f.PricePerSeat > 0 {
+			header = append(header, fmt.Sprintf("cost_in_%dm", m))
+		}
+	}
+
+	total := report.Forecast{Org: "total", Projections: make([]report.Projection, len(f.Months))}
+	rows := make([][]string, 0, len(forecasts)+1)
+	for _, fc := range forecasts {
+		rows = append(rows, forecastRow(fc, f.PricePerSeat > 0))
+		total.Seats += fc.Seats
+		total.Samples += fc.Samples
+		total.PerMonth += fc.PerMonth
+		for i, p := range fc.Projections {
+			total.Projections[i].Seats += p.Seats
+			total.Projections[i].MonthlyCost += p.MonthlyCost
+		}
+	}
+	if len(forecasts) > 1 {
+		rows = append(rows, forecastRow(total, f.PricePerSeat > 0))
+	}
+
+	return writeTable(f.Output, forecasts, header, rows)
+}
+
+func forecastRow(f report.Forecast, withCost bool) []string {
+	row := []string{f.Org, fmt.Sprint(f.Seats), fmt.Sprint(f.Samples), fmt.Sprintf("%+.1f", f.PerMonth)}
+	for _, p := range f.Projections {
+		row = append(row, fmt.Sprintf("%.0f", p.Seats))
+		if withCost {
+			row = append(row, fmt.Sprintf("%.2f", p.MonthlyCost))
+		}
+	}
+	return row
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type historyCmd struct {
+	Member historyMemberCmd `cmd:"" help:"Show when a member appeared in or disappeared from each org, and how their role and details changed"`
+	Org    historyOrgCmd    `cmd:"" help:"Show the members added to, removed from and changed in an org"`
+}
+
+type historyMemberCmd struct {
+	Email  string `arg:"" help:"The member's email"`
+	Since  string `flag:"" help:"Only show changes within this long, like 90d"`
+	Output string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (h *historyMemberCmd) Run(c *cli) error {
+	history, err := loadHistory(c)
+	if err != nil {
+		return err
+	}
+
+	var events []report.HistoryEvent
+	for org, orgHistory := range history {
+		ok, err := c.includesOrg(org)
+		if err != nil {
+			return err
+		}
+		if ok {
+			events = append(events, report.MemberHistory(orgHistory, h.Email)...)
+		}
+	}
+
+	events, err = eventsSince(events, h.Since)
+	if err != nil {
+		return err
+	}
+
+	return writeHistory(h.Output, events)
+}
+
+type historyOrgCmd struct {
+	Slug   string `arg:"" help:"The org's slug"`
+	Since  string `flag:"" help:"Only show changes within this long, like 90d"`
+	Output string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (h *historyOrgCmd) Run(c *cli) error {
+	history, err := loadHistory(c)
+	if err != nil {
+		return err
+	}
+
+	orgHistory, ok := history[h.Slug]
+	if !ok {
+		return fmt.Errorf("no history for org %s, it's recorded by each complete report run that includes it", h.Slug)
+	}
+
+	events, err := eventsSince(orgHistory, h.Since)
+	if err != nil {
+		return err
+	}
+
+	return writeHistory(h.Output, events)
+}
+
+func loadHistory(c *cli) (map[string][]report.HistoryEvent, error) {
+	store, err := c.stateStore()
+	if err != nil {
+		return nil, err
+	}
+	return loadMemberHistory(store)
+}
+
+// eventsSince returns the events within a duration like 90d, or every event
+// if it's empty, oldest first
+func eventsSince(events []report.HistoryEvent, since string) ([]report.HistoryEvent, error) {
+	var filtered []report.HistoryEvent
+	if since == "" {
+		filtered = append(filtered, events...)
+	} else {
+		d, err := report.ParseDuration(since)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			if time.Since(e.Time) <= d {
+				filtered = append(filtered, e)
+			}
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Time.Before(filtered[j].Time)
+	})
+
+	if filtered == nil {
+		filtered = []report.HistoryEvent{}
+	}
+	return filtered, nil
+}
+
+func writeHistory(output string, events []report.HistoryEvent) error {
+	rows := make([][]string, 0, len(events))
+	for _, e := range events {
+		m := e.Member
+		rows = append(rows, []string{
+			e.Time.Format("2006-01-02 15:04:05"),
+			m.Org,
+			e.Type,
+			m.Email,
+			m.Name,
+			m.Role,
+			historyDetails(e),
+		})
+	}
+
+	return writeTable(output, events, []string{"time", "org", "event", "email", "name", "role", "details"}, rows)
+}
+
+// historyDetails describes what changed, like "role member -> admin"
+func historyDetails(e report.HistoryEvent) string {
+	if e.Before == nil {
+		return ""
+	}
+
+	var details []string
+	for _, f := range e.Fields {
+		var before, after string
+		switch f {
+		case "email":
+			before, after = e.Before.Email, e.Member.Email
+		case "name":
+			before, after = e.Before.Name, e.Member.Name
+		case "role":
+			before, after = e.Before.Role, e.Member.Role
+		case "complimentary":
+			before, after = fmt.Sprint(e.Before.Complimentary), fmt.Sprint(e.Member.Complimentary)
+		default:
+			details = append(details, f)
+			continue
+		}
+		details = append(details, fmt.Sprintf("%s %s -> %s", f, before, after))
+	}
+	return strings.Join(details, ", ")
+}
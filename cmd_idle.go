@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type idleCmd struct {
+	BuildsAfter string `flag:"" help:"How long since a member's last build before it no longer counts as activity" default:"90d"`
+	Reclaimable bool   `flag:"" help:"Only list idle members, whose seats are safe to reclaim"`
+	Output      string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (i *idleCmd) Run(c *cli) error {
+	authAfter, err := report.ParseDuration(c.StaleAfter)
+	if err != nil {
+		return err
+	}
+
+	buildsAfter, err := report.ParseDuration(i.BuildsAfter)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return err
+	}
+
+	fetch, err := c.fetchFunc(client)
+	if err != nil {
+		return err
+	}
+
+	members, err := report.Load(fetch, orgSlugs, report.LoadOptions{Logf: c.logf()})
+	if err != nil {
+		return err
+	}
+
+	builds, err := c.recentBuilds(client, orgSlugs, buildsAfter)
+	if err != nil {
+		return err
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	activity := []report.MemberActivity{}
+	for _, a := range report.ClassifyActivity(members, builds, authAfter, buildsAfter) {
+		if !i.Reclaimable || a.Reclaimable() {
+			activity = append(activity, a)
+		}
+	}
+
+	rows := make([][]string, 0, len(activity))
+	for _, a := range activity {
+		rows = append(rows, []string{
+			a.Org,
+			a.Email,
+			a.Role,
+			formatDate(a.LastAuth),
+			formatDate(a.LastBuild),
+			a.Activity,
+		})
+	}
+
+	return writeTable(i.Output, activity, []string{"org", "email", "role", "last_auth", "last_build", "activity"}, rows)
+}
+
+// recentBuilds returns the builds created in each org within the last since
+func (c *cli) recentBuilds(client *buildkite.Client, orgSlugs []string, since time.Duration) ([]buildkite.Build, error) {
+	to := time.Now()
+	from := to.Add(-since)
+
+	var builds []buildkite.Build
+	for _, orgSlug := range orgSlugs {
+		if c.Debug {
+			log.Printf("Finding builds in %s", orgSlug)
+		}
+		b, err := client.GetOrgBuilds(orgSlug, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", orgSlug, err)
+		}
+		builds = append(builds, b...)
+	}
+
+	return builds, nil
+}
+
+// formatDate formats an optional time as a date, or never if it's nil
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Format("2006-01-02")
+}
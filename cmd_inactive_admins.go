@@ -0,0 +1,87 @@
+package main
+
+import (
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type inactiveAdminsCmd struct {
+	WithBuilds  bool   `flag:"" help:"Also fetch recent builds, counting admins who created one within --builds-after as active"`
+	BuildsAfter string `flag:"" help:"How long since an admin's last build before it no longer counts as activity, with --with-builds" default:"90d"`
+	Output      string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (i *inactiveAdminsCmd) Run(c *cli) error {
+	authAfter, err := report.ParseDuration(c.StaleAfter)
+	if err != nil {
+		return err
+	}
+
+	buildsAfter, err := report.ParseDuration(i.BuildsAfter)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return err
+	}
+
+	fetch, err := c.fetchFunc(client)
+	if err != nil {
+		return err
+	}
+
+	members, err := report.Load(fetch, orgSlugs, report.LoadOptions{Logf: c.logf()})
+	if err != nil {
+		return err
+	}
+
+	var admins []report.Member
+	for _, m := range members {
+		if m.Role == `admin` {
+			admins = append(admins, m)
+		}
+	}
+
+	// without builds, classified admins are at best auth-only
+	var builds []buildkite.Build
+	if i.WithBuilds {
+		builds, err = c.recentBuilds(client, orgSlugs, buildsAfter)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	inactive := []report.MemberActivity{}
+	for _, a := range report.ClassifyActivity(admins, builds, authAfter, buildsAfter) {
+		if a.Reclaimable() {
+			inactive = append(inactive, a)
+		}
+	}
+
+	header := []string{"org", "email", "name", "last_auth"}
+	if i.WithBuilds {
+		header = append(header, "last_build")
+	}
+
+	rows := make([][]string, 0, len(inactive))
+	for _, a := range inactive {
+		row := []string{a.Org, a.Email, a.Name, formatDate(a.LastAuth)}
+		if i.WithBuilds {
+			row = append(row, formatDate(a.LastBuild))
+		}
+		rows = append(rows, row)
+	}
+
+	return writeTable(i.Output, inactive, header, rows)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/audit"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type invitationsCmd struct {
+	List   invitationsListCmd   `cmd:"" help:"List the pending invitations to each org, oldest first"`
+	Revoke invitationsRevokeCmd `cmd:"" help:"Revoke pending invitations older than --older-than"`
+}
+
+// invitation is a pending invitation to an org
+type invitation struct {
+	Org       string    `json:"org"`
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	InvitedBy string    `json:"invited_by"`
+}
+
+type invitationsListCmd struct {
+	OlderThan string `flag:"" help:"Only list invitations older than this, like 30d"`
+	Output    string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (l *invitationsListCmd) Run(c *cli) error {
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	invitations, err := pendingInvitations(c, client, l.OlderThan)
+	if err != nil {
+		return err
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	rows := make([][]string, 0, len(invitations))
+	for _, inv := range invitations {
+		rows = append(rows, []string{
+			inv.Org,
+			inv.Email,
+			inv.Role,
+			inv.CreatedAt.Format("2006-01-02"),
+			formatAge(time.Since(inv.CreatedAt)),
+			inv.InvitedBy,
+		})
+	}
+
+	return writeTable(l.Output, invitations, []string{"org", "email", "role", "created_at", "age", "invited_by"}, rows)
+}
+
+type invitationsRevokeCmd struct {
+	guardrails `embed:""`
+
+	OlderThan string `flag:"" help:"Revoke pending invitations older than this" default:"30d"`
+	DryRun    bool   `flag:"" help:"Only show and audit the invitations that would be revoked, --no-dry-run revokes them" default:"true" negatable:""`
+}
+
+func (r *invitationsRevokeCmd) Run(c *cli) error {
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	// fail before revoking anything if mutations can't be audited
+	auditLog, err := c.openAuditLog()
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+
+	invitations, err := pendingInvitations(c, client, r.OlderThan)
+	if err != nil {
+		return err
+	}
+
+	if len(invitations) == 0 {
+		fmt.Printf("No pending invitations older than %s\n", r.OlderThan)
+		return nil
+	}
+
+	changes := make([]string, 0, len(invitations))
+	for _, inv := range invitations {
+		changes = append(changes, fmt.Sprintf("%s to %s, invited %s ago", inv.Email, inv.Org, formatAge(time.Since(inv.CreatedAt))))
+	}
+
+	ok, err := r.approve(changes, r.DryRun, fmt.Sprintf("Revoke %d invitations?", len(invitations)))
+	if err != nil || !ok {
+		return err
+	}
+
+	var revoked []invitation
+	var failed []string
+	for _, inv := range invitations {
+		if err := r.revoke(client, auditLog, inv); err != nil {
+			failed = append(failed, fmt.Sprintf("%s to %s: %v", inv.Email, inv.Org, err))
+			continue
+		}
+		revoked = append(revoked, inv)
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	summary, verb := "Revoked", "revoked"
+	if r.DryRun {
+		summary, verb = "Would revoke", "would revoke"
+	}
+
+	fmt.Printf("\n%s %d of %d invitations older than %s, %d failed\n",
+		summary, len(revoked), len(invitations), r.OlderThan, len(failed))
+	for _, inv := range revoked {
+		fmt.Printf("  %s %s to %s, invited %s\n", verb, inv.Email, inv.Org, inv.CreatedAt.Format("2006-01-02"))
+	}
+	for _, f := range failed {
+		fmt.Printf("  failed to revoke %s\n", f)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to revoke %d invitations", len(failed))
+	}
+
+	return nil
+}
+
+// revoke revokes an invitation, or just audits it with --dry-run
+func (r *invitationsRevokeCmd) revoke(client *buildkite.Client, auditLog *audit.Log, inv invitation) error {
+	entry := audit.Entry{
+		Action:   "revoke_org_invitation",
+		Target:   inv.Email,
+		TargetID: inv.ID,
+		Org:      inv.Org,
+		DryRun:   r.DryRun,
+	}
+
+	if r.DryRun {
+		return auditLog.Record(entry, nil, nil)
+	}
+
+	response, err := client.RevokeInvitation(inv.ID)
+	if aerr := auditLog.Record(entry, response, err); aerr != nil {
+		return fmt.Errorf("failed to write audit log: %w", aerr)
+	}
+	return err
+}
+
+// pendingInvitations returns the pending invitations to each org created
+// before olderThan ago, or all of them if it's empty, oldest first
+func pendingInvitations(c *cli, client *buildkite.Client, olderThan string) ([]invitation, error) {
+	var age time.Duration
+	if olderThan != "" {
+		var err error
+		age, err = report.ParseDuration(olderThan)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := []invitation{}
+	for _, orgSlug := range orgSlugs {
+		if c.Debug {
+			log.Printf("Finding pending invitations in %s", orgSlug)
+		}
+
+		pending, err := client.GetPendingInvitations(orgSlug)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", orgSlug, err)
+		}
+
+		for _, inv := range pending {
+			if time.Since(inv.CreatedAt) < age {
+				continue
+			}
+			invitations = append(invitations, invitation{
+				Org:       orgSlug,
+				ID:        inv.ID,
+				Email:     inv.Email,
+				Role:      strings.ToLower(inv.Role),
+				CreatedAt: inv.CreatedAt,
+				InvitedBy: inv.InvitedBy,
+			})
+		}
+	}
+
+	sort.SliceStable(invitations, func(i, j int) bool {
+		return invitations[i].CreatedAt.Before(invitations[j].CreatedAt)
+	})
+	return invitations, nil
+}
+
+// formatAge formats a duration in whole days, like 45d
+func formatAge(d time.Duration) string {
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
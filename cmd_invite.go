@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"strings"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/audit"
+)
+
+type inviteCmd struct {
+	guardrails `embed:""`
+
+	FromFile string `flag:"" name:"from-file" help:"A CSV of emails to invite, with an email column and optionally a role column" type:"existingfile" required:""`
+	Org      string `flag:"" help:"The slug of the org to invite them to" required:""`
+	Role     string `flag:"" help:"The role to invite them with, unless the CSV has a role column" enum:"member,admin" default:"member"`
+	DryRun   bool   `flag:"" help:"Only show and audit the invitations that would be created, --no-dry-run creates them" default:"true" negatable:""`
+	Output   string `flag:"" help:"How to output the results" enum:"table,json,csv" default:"table"`
+}
+
+// Results of inviting a row of the CSV
+const (
+	inviteInvited        = "invited"
+	inviteWouldInvite    = "would_invite"
+	inviteAlreadyMember  = "already_member"
+	inviteAlreadyInvited = "already_invited"
+	inviteDuplicate      = "duplicate"
+	inviteInvalid        = "invalid"
+	inviteFailed         = "failed"
+)
+
+// inviteResult is the outcome of inviting an email
+type inviteResult struct {
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Result string `json:"result"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// inviteRow is an email to invite from the CSV
+type inviteRow struct {
+	line  int
+	email string
+	role  string
+}
+
+func (i *inviteCmd) Run(c *cli) error {
+	rows, err := readInvites(i.FromFile, i.Role)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	// fail before inviting anyone if mutations can't be audited
+	auditLog, err := c.openAuditLog()
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+
+	org, err := client.GetOrganization(i.Org)
+	if err != nil {
+		return err
+	}
+
+	fetch, err := c.fetchFunc(client)
+	if err != nil {
+		return err
+	}
+
+	members, err := fetch(i.Org)
+	if err != nil {
+		return fmt.Errorf("%s: %w", i.Org, err)
+	}
+
+	pending, err := client.GetPendingInvitations(i.Org)
+	if err != nil {
+		return fmt.Errorf("%s: %w", i.Org, err)
+	}
+
+	existing := map[string]string{}
+	for _, m := range members {
+		existing[strings.ToLower(m.Email)] = inviteAlreadyMember
+		if m.Authorization != nil && m.Authorization.Email != "" {
+			existing[strings.ToLower(m.Authorization.Email)] = inviteAlreadyMember
+		}
+	}
+	for _, inv := range pending {
+		if _, ok := existing[strings.ToLower(inv.Email)]; !ok {
+			existing[strings.ToLower(inv.Email)] = inviteAlreadyInvited
+		}
+	}
+
+	results := []inviteResult{}
+	seen := map[string]int{}
+
+	// every row is checked before inviting any, so the invitations can be
+	// confirmed and checked against --max-changes, as indexes of rows and
+	// their results
+	var invites []int
+	var changes []string
+
+	for _, row := range rows {
+		res := inviteResult{Email: row.email, Role: row.role}
+		key := strings.ToLower(row.email)
+
+		switch {
+		case !validEmail(row.email):
+			res.Result, res.Detail = inviteInvalid, fmt.Sprintf("line %d isn't an email address", row.line)
+		case row.role != "member" && row.role != "admin":
+			res.Result, res.Detail = inviteInvalid, fmt.Sprintf("line %d has role %q, expected member or admin", row.line, row.role)
+		case seen[key] > 0:
+			res.Result, res.Detail = inviteDuplicate, fmt.Sprintf("line %d repeats line %d", row.line, seen[key])
+		case existing[key] != "":
+			res.Result = existing[key]
+		default:
+			res.Result = inviteWouldInvite
+			invites = append(invites, len(results))
+			changes = append(changes, fmt.Sprintf("%s as %s", row.email, row.role))
+		}
+
+		if seen[key] == 0 {
+			seen[key] = row.line
+		}
+		results = append(results, res)
+	}
+
+	ok, err := i.approve(changes, i.DryRun, fmt.Sprintf("Invite %d emails to %s?", len(changes), i.Org))
+	if err != nil || !ok {
+		return err
+	}
+
+	failed := 0
+	for _, n := range invites {
+		if err := i.invite(client, auditLog, org.ID, rows[n]); err != nil {
+			results[n].Result, results[n].Detail = inviteFailed, err.Error()
+			failed++
+		} else if !i.DryRun {
+			results[n].Result = inviteInvited
+		}
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	table := make([][]string, 0, len(results))
+	for _, res := range results {
+		table = append(table, []string{res.Email, res.Role, res.Result, res.Detail})
+	}
+
+	if err := writeTable(i.Output, results, []string{"email", "role", "result", "detail"}, table); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to invite %d of %d emails", failed, len(rows))
+	}
+
+	return nil
+}
+
+// invite creates an invitation, or just audits it with --dry-run
+func (i *inviteCmd) invite(client *buildkite.Client, auditLog *audit.Log, orgID string, row inviteRow) error {
+	entry := audit.Entry{
+		Action: "create_org_invitation",
+		Target: row.email,
+		Org:    i.Org,
+		DryRun: i.DryRun,
+	}
+
+	if i.DryRun {
+		return auditLog.Record(entry, nil, nil)
+	}
+
+	response, err := client.CreateInvitation(orgID, row.email, strings.ToUpper(row.role))
+	if aerr := auditLog.Record(entry, response, err); aerr != nil {
+		return fmt.Errorf("failed to write audit log: %w", aerr)
+	}
+	return err
+}
+
+// readInvites reads the emails to invite from a CSV with a header row naming
+// an email column and optionally a role column, or from a CSV of only emails
+func readInvites(path, defaultRole string) ([]inviteRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	emailCol, roleCol := 0, -1
+	var rows []inviteRow
+
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if first {
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+			if !strings.Contains(record[0], "@") {
+				emailCol = -1
+				for col, name := range record {
+					switch strings.ToLower(strings.TrimSpace(name)) {
+					case "email":
+						emailCol = col
+					case "role":
+						roleCol = col
+					}
+				}
+				if emailCol < 0 {
+					return nil, fmt.Errorf("%s: no email column, add a header row with an email column", path)
+				}
+				continue
+			}
+		}
+
+		line, _ := r.FieldPos(0)
+		row := inviteRow{line: line, role: defaultRole}
+		if emailCol < len(record) {
+			row.email = strings.TrimSpace(record[emailCol])
+		}
+		if roleCol >= 0 && roleCol < len(record) && strings.TrimSpace(record[roleCol]) != "" {
+			row.role = strings.ToLower(strings.TrimSpace(record[roleCol]))
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// validEmail returns whether s is a bare email address
+func validEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
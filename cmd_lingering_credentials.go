@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type lingeringCredentialsCmd struct {
+	Since  string `flag:"" help:"How far back to look for builds" default:"30d"`
+	Output string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (l *lingeringCredentialsCmd) Run(c *cli) error {
+	since, err := report.ParseDuration(l.Since)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return err
+	}
+
+	fetch, err := c.fetchFunc(client)
+	if err != nil {
+		return err
+	}
+
+	to := time.Now()
+	from := to.Add(-since)
+
+	lingering := []report.LingeringCredential{}
+	for _, orgSlug := range orgSlugs {
+		if c.Debug {
+			log.Printf("Finding members and builds in %s", orgSlug)
+		}
+
+		members, err := fetch(orgSlug)
+		if err != nil {
+			return fmt.Errorf("%s: %w", orgSlug, err)
+		}
+
+		builds, err := client.GetOrgBuilds(orgSlug, from, to)
+		if err != nil {
+			return fmt.Errorf("%s: %w", orgSlug, err)
+		}
+
+		lingering = append(lingering, report.LingeringCredentials(orgSlug, members, builds)...)
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	rows := make([][]string, 0, len(lingering))
+	for _, m := range lingering {
+		rows = append(rows, []string{
+			m.Org,
+			m.Email,
+			m.SSOState,
+			m.SSOEndedAt.Format("2006-01-02"),
+			strconv.Itoa(m.Builds),
+			m.LastBuild.Format("2006-01-02"),
+		})
+	}
+
+	return writeTable(l.Output, lingering, []string{"org", "email", "sso_state", "sso_ended", "builds", "last_build"}, rows)
+}
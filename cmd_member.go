@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type memberCmd struct {
+	Show memberShowCmd `cmd:"" help:"Show everything known about a person across orgs: memberships, roles, SSO, teams and duplicates"`
+}
+
+type memberShowCmd struct {
+	Email  string `arg:"" help:"The person's account or SSO email"`
+	Output string `flag:"" help:"How to output the person" enum:"text,json" default:"text"`
+}
+
+func (m *memberShowCmd) Run(c *cli) error {
+	groupOpts, err := c.groupOptions()
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	orgSlugs, members, err := fetchAllMembers(c, client)
+	if err != nil {
+		return err
+	}
+
+	// teams aren't cached, so they're only known online
+	teams := map[string][]buildkite.Team{}
+	if !c.Offline {
+		for _, orgSlug := range orgSlugs {
+			if !hasMember(members[orgSlug], m.Email) {
+				continue
+			}
+			if c.Debug {
+				log.Printf("Finding teams in %s", orgSlug)
+			}
+			teams[orgSlug], err = client.GetTeams(orgSlug)
+			if err != nil {
+				return fmt.Errorf("%s: %w", orgSlug, err)
+			}
+		}
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	person, err := report.FindPerson(m.Email, orgSlugs, members, teams, groupOpts)
+	if err != nil {
+		return err
+	}
+
+	if len(person.Memberships) == 0 {
+		return fmt.Errorf("no member of %s has the email %s", strings.Join(orgSlugs, ", "), m.Email)
+	}
+
+	if m.Output == `json` {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(person)
+	}
+
+	return writePerson(person, !c.Offline)
+}
+
+// hasMember returns whether any of the members has the account or SSO email
+func hasMember(members []buildkite.OrgMember, email string) bool {
+	for _, m := range members {
+		if memberHasEmail(m, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// memberHasEmail returns whether the member's account or SSO email is email
+func memberHasEmail(m buildkite.OrgMember, email string) bool {
+	return strings.EqualFold(m.Email, email) || (m.Authorization != nil && strings.EqualFold(m.Authorization.Email, email))
+}
+
+// writePerson writes a person's memberships and duplicates as text
+func writePerson(p report.Person, withTeams bool) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for i, m := range p.Memberships {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s\n", m.Org)
+		fmt.Fprintf(w, "  ID:\t%s\n", m.ID)
+		fmt.Fprintf(w, "  Name:\t%s\n", m.Name)
+		fmt.Fprintf(w, "  Email:\t%s\n", m.AccountEmail)
+		fmt.Fprintf(w, "  Role:\t%s\n", memberRole(m.Member))
+		fmt.Fprintf(w, "  Created:\t%s\n", formatDate(&m.CreatedAt))
+		fmt.Fprintf(w, "  SSO:\t%s\n", ssoSummary(m))
+		if withTeams {
+			fmt.Fprintf(w, "  Teams:\t%s\n", teamsSummary(m.Teams))
+		}
+	}
+
+	if len(p.EmailDuplicates) > 0 {
+		fmt.Fprintln(w, "\nEmail duplicates:")
+		for _, d := range p.EmailDuplicates {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", d.Org, d.ID, d.Email, d.Name)
+		}
+	}
+
+	if len(p.NameDuplicates) > 0 {
+		fmt.Fprintln(w, "\nName duplicates:")
+		for _, d := range p.NameDuplicates {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%.2f\n", d.Org, d.ID, d.Email, d.Name, d.Similarity)
+		}
+	}
+
+	return w.Flush()
+}
+
+// memberRole returns the member's role, noting complimentary seats and bots
+func memberRole(m report.Member) string {
+	role := m.Role
+	if m.Complimentary {
+		role += " (complimentary)"
+	}
+	if m.Bot {
+		role += " (bot)"
+	}
+	return role
+}
+
+// ssoSummary describes a membership's SSO authorization
+func ssoSummary(m report.Membership) string {
+	if m.SSO == nil {
+		return "none"
+	}
+
+	s := fmt.Sprintf("%s as %s, authorized %s", m.SSO.State, m.Email, formatDate(&m.SSO.CreatedAt))
+	if m.SSO.ExpiredAt != nil {
+		s += ", expires " + formatDate(m.SSO.ExpiredAt)
+	}
+	if m.SSO.RevokedAt != nil {
+		s += ", revoked " + formatDate(m.SSO.RevokedAt)
+	}
+	if m.SSO.SessionDestroyedAt != nil {
+		s += ", session destroyed " + formatDate(m.SSO.SessionDestroyedAt)
+	}
+	return s
+}
+
+// teamsSummary lists teams and the member's role in each
+func teamsSummary(teams []report.MembershipTeam) string {
+	if len(teams) == 0 {
+		return "none"
+	}
+
+	names := make([]string, 0, len(teams))
+	for _, t := range teams {
+		names = append(names, fmt.Sprintf("%s (%s)", t.Slug, t.Role))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type mismatchesCmd struct {
+	Output string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (m *mismatchesCmd) Run(c *cli) error {
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return err
+	}
+
+	fetch, err := c.fetchFunc(client)
+	if err != nil {
+		return err
+	}
+
+	mismatches := []report.Mismatch{}
+	for _, orgSlug := range orgSlugs {
+		if c.Debug {
+			log.Printf("Finding members in %s", orgSlug)
+		}
+
+		members, err := fetch(orgSlug)
+		if err != nil {
+			return fmt.Errorf("%s: %w", orgSlug, err)
+		}
+
+		mismatches = append(mismatches, report.Mismatches(orgSlug, members)...)
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	rows := make([][]string, 0, len(mismatches))
+	for _, mm := range mismatches {
+		rows = append(rows, []string{
+			mm.Org,
+			mm.AccountEmail,
+			mm.SSOEmail,
+			mm.AccountName,
+			mm.SSOName,
+			strings.Join(mm.Fields, ","),
+		})
+	}
+
+	return writeTable(m.Output, mismatches, []string{"org", "account_email", "sso_email", "account_name", "sso_name", "differs"}, rows)
+}
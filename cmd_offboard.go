@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/audit"
+)
+
+type offboardCmd struct {
+	guardrails `embed:""`
+
+	Email  string   `arg:"" help:"The account or SSO email of the member to offboard"`
+	Orgs   []string `flag:"" help:"The orgs to offboard the member from, or all for every org the token can see" required:""`
+	DryRun bool     `flag:"" help:"Only show and audit the steps that would be taken, --no-dry-run takes them" default:"true" negatable:""`
+	Output string   `flag:"" help:"How to output the result of each step" enum:"table,json,csv" default:"table"`
+}
+
+// Steps of offboarding a member from an org, in the order they're taken
+const (
+	stepRevokeSSO       = "revoke_sso_authorization"
+	stepDestroySessions = "destroy_user_sessions"
+	stepRemoveMember    = "remove_org_member"
+)
+
+// Results of an offboarding step
+const (
+	resultDone      = "done"
+	resultDryRun    = "dry_run"
+	resultNotNeeded = "not_needed"
+	resultFailed    = "failed"
+	resultSkipped   = "skipped"
+)
+
+// offboardStep is the result of a step of offboarding a member from an org
+type offboardStep struct {
+	Org    string `json:"org"`
+	Email  string `json:"email"`
+	Step   string `json:"step"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (o *offboardCmd) Run(c *cli) error {
+	if len(o.Orgs) == 1 && strings.EqualFold(o.Orgs[0], "all") {
+		c.OrgSlugs = []string{"*"}
+	} else {
+		c.OrgSlugs = o.Orgs
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	// fail before changing anything if mutations can't be audited
+	auditLog, err := c.openAuditLog()
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+
+	memberships, err := findMemberships(c, client, o.Email)
+	if err != nil {
+		return err
+	}
+
+	if len(memberships) == 0 {
+		return fmt.Errorf("%s isn't a member of any of the orgs", o.Email)
+	}
+
+	// check every membership can be offboarded before changing any of them
+	for _, m := range memberships {
+		if m.Member.MembershipID == "" {
+			return fmt.Errorf("no membership id for %s in %s", m.Member.Email, m.Org)
+		}
+	}
+
+	ok, err := o.approve(describeMemberships(memberships), o.DryRun, fmt.Sprintf("Revoke SSO, destroy the sessions of and remove %s from %d orgs?", o.Email, len(memberships)))
+	if err != nil || !ok {
+		return err
+	}
+
+	// each org's steps are taken in order, stopping at the first failure so
+	// that a member is never removed while they can still sign in, and
+	// running again picks up where it stopped
+	var steps []offboardStep
+	var failed error
+	for _, m := range memberships {
+		for _, step := range []string{stepRevokeSSO, stepDestroySessions, stepRemoveMember} {
+			result := offboardStep{Org: m.Org, Email: m.Member.Email, Step: step, Result: resultSkipped}
+
+			if failed == nil {
+				result.Result, err = o.takeStep(client, auditLog, m, step)
+				if err != nil {
+					result.Result, result.Error = resultFailed, err.Error()
+					failed = fmt.Errorf("failed to %s for %s in %s: %w", strings.ReplaceAll(step, "_", " "), m.Member.Email, m.Org, err)
+				}
+			}
+
+			steps = append(steps, result)
+		}
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	rows := make([][]string, 0, len(steps))
+	for _, s := range steps {
+		rows = append(rows, []string{s.Org, s.Email, s.Step, s.Result, s.Error})
+	}
+
+	if err := writeTable(o.Output, steps, []string{"org", "email", "step", "result", "error"}, rows); err != nil {
+		return err
+	}
+
+	return failed
+}
+
+// takeStep takes a step of offboarding a member from an org, or just audits
+// it with --dry-run, returning its result
+func (o *offboardCmd) takeStep(client *buildkite.Client, auditLog *audit.Log, m membership, step string) (string, error) {
+	done := resultDone
+	if o.DryRun {
+		done = resultDryRun
+	}
+
+	entry := audit.Entry{
+		Action:   step,
+		Target:   m.Member.Email,
+		TargetID: m.Member.MembershipID,
+		Org:      m.Org,
+		DryRun:   o.DryRun,
+	}
+
+	var err error
+	switch step {
+	case stepRevokeSSO:
+		a := m.Member.Authorization
+		if a == nil || a.RevokedAt != nil {
+			return resultNotNeeded, nil
+		}
+		if a.ID == "" {
+			return "", fmt.Errorf("no sso authorization id")
+		}
+		entry.TargetID = a.ID
+		err = auditMutation(auditLog, entry, func() ([]byte, error) {
+			return client.RevokeSSOAuthorization(a.ID)
+		})
+	case stepDestroySessions:
+		err = destroySessions(client, auditLog, m, o.DryRun)
+	case stepRemoveMember:
+		err = auditMutation(auditLog, entry, func() ([]byte, error) {
+			return client.RemoveOrgMember(m.Member.MembershipID)
+		})
+	default:
+		return "", fmt.Errorf("unknown step %q", step)
+	}
+
+	if err != nil {
+		return "", err
+	}
+	return done, nil
+}
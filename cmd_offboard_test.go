@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lox/buildkite-accounter/buildkite/buildkitetest"
+)
+
+func TestOffboardDryRunByDefault(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "default", args: []string{"offboard", "bob@acme.com", "--orgs", "acme"}, want: []string{"alice@acme.com", "bob@acme.com"}},
+		{name: "no dry run", args: []string{"offboard", "bob@acme.com", "--orgs", "acme", "--no-dry-run", "--yes"}, want: []string{"alice@acme.com"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := buildkitetest.NewServer()
+			defer s.Close()
+			s.AddOrg("acme", neverAuthorized("alice", "alice@acme.com"), neverAuthorized("bob", "bob@acme.com"))
+
+			if err := runCLI(t, s, tc.args...); err != nil {
+				t.Fatal(err)
+			}
+			if got := orgEmails(t, s, "acme"); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("members after offboarding = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type orphanedPipelinesCmd struct {
+	Since  string `flag:"" help:"How far back to look for build creators" default:"90d"`
+	Output string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (o *orphanedPipelinesCmd) Run(c *cli) error {
+	since, err := report.ParseDuration(o.Since)
+	if err != nil {
+		return err
+	}
+
+	staleAfter, err := report.ParseDuration(c.StaleAfter)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return err
+	}
+
+	fetch, err := c.fetchFunc(client)
+	if err != nil {
+		return err
+	}
+
+	members, err := report.Load(fetch, orgSlugs, report.LoadOptions{Logf: c.logf()})
+	if err != nil {
+		return err
+	}
+
+	to := time.Now()
+	from := to.Add(-since)
+
+	orphans := []report.OrphanedPipeline{}
+	for _, orgSlug := range orgSlugs {
+		if c.Debug {
+			log.Printf("Finding pipelines and builds in %s", orgSlug)
+		}
+
+		pipelines, err := client.GetPipelines(orgSlug)
+		if err != nil {
+			return fmt.Errorf("%s: %w", orgSlug, err)
+		}
+
+		builds, err := client.GetOrgBuilds(orgSlug, from, to)
+		if err != nil {
+			return fmt.Errorf("%s: %w", orgSlug, err)
+		}
+
+		orphans = append(orphans, report.OrphanedPipelines(orgSlug, pipelines, builds, members, staleAfter)...)
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	rows := make([][]string, 0, len(orphans))
+	for _, p := range orphans {
+		lastBuild := ""
+		if p.LastBuild != nil {
+			lastBuild = p.LastBuild.Format("2006-01-02")
+		}
+		rows = append(rows, []string{
+			p.Org,
+			p.Pipeline,
+			lastBuild,
+			strings.Join(p.Creators, " "),
+			strings.Join(p.Maintainers, " "),
+		})
+	}
+
+	return writeTable(o.Output, orphans, []string{"org", "pipeline", "last_build", "creators", "maintainers"}, rows)
+}
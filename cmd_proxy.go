@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/proxy"
+)
+
+type proxyCmd struct {
+	Listen         string        `flag:"" help:"The address to listen on" default:"127.0.0.1:8090"`
+	TTL            time.Duration `flag:"" help:"How long to cache responses for" default:"1h"`
+	AuthToken      string        `flag:"" help:"Require this bearer token of callers, which then use it as their --api-token" env:"BUILDKITE_ACCOUNTER_AUTH_TOKEN"`
+	InsecureNoAuth bool          `flag:"" help:"Proxy without an --auth-token, serving members fetched with this token to anyone who can reach --listen"`
+}
+
+func (p *proxyCmd) Run(c *cli) error {
+	if p.AuthToken == "" && !p.InsecureNoAuth {
+		return errors.New("proxy needs an --auth-token to protect members, or --insecure-no-auth to serve them to anyone")
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	handler := proxy.New(client, p.TTL)
+	handler.AuthToken = p.AuthToken
+	if c.Debug {
+		handler.Logf = log.Printf
+	}
+
+	log.Printf("Proxying %s on %s", c.Endpoint, p.Listen)
+	return http.ListenAndServe(p.Listen, handler)
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lox/buildkite-accounter/buildkite/buildkitetest"
+)
+
+func TestProxyRequiresAuthToken(t *testing.T) {
+	s := buildkitetest.NewServer()
+	defer s.Close()
+
+	err := runCLI(t, s, "proxy", "--listen", "127.0.0.1:0")
+	if err == nil || !strings.Contains(err.Error(), "--insecure-no-auth") {
+		t.Fatalf("proxy error = %v, want it to need an auth token", err)
+	}
+}
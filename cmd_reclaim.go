@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/audit"
+	"github.com/lox/buildkite-accounter/internal/report"
+	"github.com/mattn/go-isatty"
+)
+
+type reclaimCmd struct {
+	guardrails `embed:""`
+
+	Interactive  bool    `flag:"" help:"Confirm each recommended removal, including those only recommended for review"`
+	PricePerSeat float64 `flag:"" help:"The monthly price of a seat, to report savings"`
+	DryRun       bool    `flag:"" help:"Only show and audit the members that would be removed, --no-dry-run removes them" default:"true" negatable:""`
+}
+
+// reclaimSummary is the outcome of a reclaim run
+type reclaimSummary struct {
+	removed []report.Recommendation
+	skipped int
+	failed  []string
+	savings float64
+}
+
+func (r *reclaimCmd) Run(c *cli) error {
+	if r.Interactive && r.Yes {
+		return fmt.Errorf("--interactive and --yes can't be used together")
+	}
+
+	if r.Interactive && !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		return errNotTerminal
+	}
+
+	staleAfter, err := report.ParseDuration(c.StaleAfter)
+	if err != nil {
+		return err
+	}
+
+	filter, err := c.filterOptions()
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	// fail before removing anyone if mutations can't be audited
+	auditLog, err := c.openAuditLog()
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+
+	rep, err := c.buildReport(client, filter, nil)
+	if err != nil {
+		return err
+	}
+
+	// only members matching --email and --filter are ever removed
+	members, err := report.FilterMembers(rep.Members, filter)
+	if err != nil {
+		return err
+	}
+
+	var recommendations []report.Recommendation
+	for _, rec := range report.Recommend(members, staleAfter, r.PricePerSeat) {
+		if rec.Action == report.ActionRemove || r.Interactive {
+			recommendations = append(recommendations, rec)
+		}
+	}
+
+	if r.Interactive {
+		if err := r.checkMaxChanges(len(recommendations)); err != nil {
+			return err
+		}
+	} else {
+		changes := make([]string, 0, len(recommendations))
+		for _, rec := range recommendations {
+			changes = append(changes, rec.String())
+		}
+
+		ok, err := r.approve(changes, r.DryRun, fmt.Sprintf("Remove %d members?", len(recommendations)))
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	summary := &reclaimSummary{}
+	defer r.printSummary(summary, len(recommendations))
+
+	in := bufio.NewReader(os.Stdin)
+
+	for i, rec := range recommendations {
+		if r.Interactive {
+			printEvidence(i+1, len(recommendations), rec)
+
+			answer, err := prompt(in, "Remove? [y/N/q] ")
+			if err != nil || answer == "q" {
+				summary.skipped += len(recommendations) - i
+				return err
+			}
+			if answer != "y" && answer != "yes" {
+				summary.skipped++
+				continue
+			}
+		}
+
+		if err := r.removeMember(client, auditLog, rec.Member); err != nil {
+			summary.failed = append(summary.failed, fmt.Sprintf("%s from %s: %v", rec.Member.Email, rec.Member.Org, err))
+			continue
+		}
+
+		summary.removed = append(summary.removed, rec)
+		summary.savings += rec.MonthlySavings
+	}
+
+	if len(summary.failed) > 0 {
+		return fmt.Errorf("failed to remove %d members", len(summary.failed))
+	}
+
+	return nil
+}
+
+// removeMember removes a member, or just audits it with --dry-run
+func (r *reclaimCmd) removeMember(client *buildkite.Client, auditLog *audit.Log, m report.Member) error {
+	if m.MembershipID == "" {
+		return fmt.Errorf("no membership id, cached members may predate it so try without --cache")
+	}
+
+	entry := audit.Entry{
+		Action:   "remove_org_member",
+		Target:   m.Email,
+		TargetID: m.MembershipID,
+		Org:      m.Org,
+		DryRun:   r.DryRun,
+	}
+
+	if r.DryRun {
+		return auditLog.Record(entry, nil, nil)
+	}
+
+	response, err := client.RemoveOrgMember(m.MembershipID)
+	if aerr := auditLog.Record(entry, response, err); aerr != nil {
+		return fmt.Errorf("failed to write audit log: %w", aerr)
+	}
+	return err
+}
+
+func printEvidence(n, total int, rec report.Recommendation) {
+	m := rec.Member
+
+	lastAuth := "never"
+	if m.LastAuth != nil {
+		lastAuth = m.LastAuth.Format("2006-01-02")
+	}
+
+	fmt.Printf("\n[%d/%d] %s\n", n, total, rec)
+	fmt.Printf("  name: %s, role: %s, last auth: %s\n", m.Name, m.Role, lastAuth)
+}
+
+// prompt asks a question on stdout and returns the lowercased answer
+func prompt(in *bufio.Reader, question string) (string, error) {
+	fmt.Print(question)
+	answer, err := in.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(strings.TrimSpace(answer)), nil
+}
+
+func (r *reclaimCmd) printSummary(s *reclaimSummary, total int) {
+	summary, removed := "Removed", "removed"
+	if r.DryRun {
+		summary, removed = "Would remove", "would remove"
+	}
+
+	fmt.Printf("\n%s %d of %d recommended members, skipped %d, %d failed\n",
+		summary, len(s.removed), total, s.skipped, len(s.failed))
+
+	for _, rec := range s.removed {
+		fmt.Printf("  %s %s from %s\n", removed, rec.Member.Email, rec.Member.Org)
+	}
+	for _, f := range s.failed {
+		fmt.Printf("  failed to remove %s\n", f)
+	}
+
+	if r.PricePerSeat > 0 {
+		fmt.Printf("Estimated savings: %.2f per month\n", s.savings)
+	}
+}
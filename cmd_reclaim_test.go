@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lox/buildkite-accounter/buildkite/buildkitetest"
+)
+
+func TestReclaimOnlyRemovesFilteredMembers(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "filter",
+			args: []string{"--filter", `member.domain == "contractor.com"`, "reclaim", "--no-dry-run", "--yes"},
+			want: []string{"alice@acme.com"},
+		},
+		{
+			name: "email",
+			args: []string{"--email", "bob@contractor.com", "reclaim", "--no-dry-run", "--yes"},
+			want: []string{"alice@acme.com", "carol@contractor.com"},
+		},
+		{
+			name: "dry run",
+			args: []string{"--filter", `member.domain == "contractor.com"`, "reclaim", "--dry-run"},
+			want: []string{"alice@acme.com", "bob@contractor.com", "carol@contractor.com"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := buildkitetest.NewServer()
+			defer s.Close()
+
+			s.AddOrg("acme",
+				neverAuthorized("alice", "alice@acme.com"),
+				neverAuthorized("bob", "bob@contractor.com"),
+				neverAuthorized("carol", "carol@contractor.com"),
+			)
+
+			args := append([]string{"--org-slugs", "acme"}, tc.args...)
+			if err := runCLI(t, s, args...); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := orgEmails(t, s, "acme"); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("members after reclaim = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
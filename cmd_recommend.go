@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type recommendCmd struct {
+	PricePerSeat float64 `flag:"" help:"The monthly price of a seat, to estimate savings"`
+	Output       string  `flag:"" help:"How to output recommendations" enum:"text,json" default:"text"`
+}
+
+func (r *recommendCmd) Run(c *cli) error {
+	staleAfter, err := report.ParseDuration(c.StaleAfter)
+	if err != nil {
+		return err
+	}
+
+	filter, err := c.filterOptions()
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	rep, err := c.buildReport(client, filter, nil)
+	if err != nil {
+		return err
+	}
+
+	members, err := report.FilterMembers(rep.Members, filter)
+	if err != nil {
+		return err
+	}
+
+	recommendations := report.Recommend(members, staleAfter, r.PricePerSeat)
+
+	if r.Output == `json` {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(recommendations)
+	}
+
+	var savings float64
+	removals := 0
+	for _, rec := range recommendations {
+		fmt.Println(rec)
+		if rec.Action == report.ActionRemove {
+			removals++
+			savings += rec.MonthlySavings
+		}
+	}
+
+	fmt.Printf("\n%d removals and %d to review across %d seats", removals, len(recommendations)-removals, len(members))
+	if r.PricePerSeat > 0 {
+		fmt.Printf(", saving an estimated %.2f per month", savings)
+	}
+	fmt.Println()
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/directory"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type reconcileCmd struct {
+	GitHub  reconcileGitHubCmd  `cmd:"" name:"github" help:"Report members who aren't in a GitHub organization, and GitHub organization members who aren't in Buildkite"`
+	GitLab  reconcileGitLabCmd  `cmd:"" name:"gitlab" help:"Report members who aren't in a GitLab group, and GitLab group members who aren't in Buildkite"`
+	AzureAD reconcileAzureADCmd `cmd:"" name:"azuread" help:"Report members whose Microsoft Entra ID (Azure AD) account is disabled or missing"`
+}
+
+type reconcileGitHubCmd struct {
+	GitHubOrg   string `flag:"" name:"github-org" help:"The login of the GitHub organization" required:""`
+	GitHubToken string `flag:"" name:"github-token" help:"A GitHub token with read:org, and admin:org to read SAML identities" env:"GITHUB_TOKEN" required:""`
+	GitHubURL   string `flag:"" name:"github-url" help:"The GitHub GraphQL endpoint, for GitHub Enterprise Server" default:"https://api.github.com/graphql"`
+	Output      string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (g *reconcileGitHubCmd) Run(c *cli) error {
+	gh := &directory.GitHub{Org: g.GitHubOrg, Token: g.GitHubToken, URL: g.GitHubURL}
+
+	if c.Debug {
+		log.Printf("Finding members of github org %s", g.GitHubOrg)
+	}
+
+	people, err := gh.Members()
+	if err != nil {
+		return err
+	}
+
+	return reconcile(c, "github", people, g.Output)
+}
+
+type reconcileGitLabCmd struct {
+	GitLabGroup string `flag:"" name:"gitlab-group" help:"The ID or full path of the GitLab group, like acme/engineering" required:""`
+	GitLabToken string `flag:"" name:"gitlab-token" help:"A GitLab token with read_api" env:"GITLAB_TOKEN" required:""`
+	GitLabURL   string `flag:"" name:"gitlab-url" help:"The GitLab instance, for self-managed GitLab" default:"https://gitlab.com"`
+	Output      string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (g *reconcileGitLabCmd) Run(c *cli) error {
+	gl := &directory.GitLab{Group: g.GitLabGroup, Token: g.GitLabToken, URL: g.GitLabURL}
+
+	if c.Debug {
+		log.Printf("Finding members of gitlab group %s", g.GitLabGroup)
+	}
+
+	people, err := gl.Members()
+	if err != nil {
+		return err
+	}
+
+	return reconcile(c, "gitlab", people, g.Output)
+}
+
+type reconcileAzureADCmd struct {
+	AzureADTenant       string `flag:"" name:"azuread-tenant" help:"The ID or domain of the Entra ID tenant" env:"AZURE_TENANT_ID" required:""`
+	AzureADClientID     string `flag:"" name:"azuread-client-id" help:"The client ID of an app registration with the User.Read.All application permission" env:"AZURE_CLIENT_ID" required:""`
+	AzureADClientSecret string `flag:"" name:"azuread-client-secret" help:"A client secret of the app registration" env:"AZURE_CLIENT_SECRET" required:""`
+	AzureADLoginURL     string `flag:"" name:"azuread-login-url" help:"The Microsoft identity platform endpoint, for national clouds" default:"https://login.microsoftonline.com"`
+	AzureADGraphURL     string `flag:"" name:"azuread-graph-url" help:"The Microsoft Graph endpoint, for national clouds" default:"https://graph.microsoft.com"`
+	Output              string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (a *reconcileAzureADCmd) Run(c *cli) error {
+	ad := &directory.AzureAD{
+		Tenant:       a.AzureADTenant,
+		ClientID:     a.AzureADClientID,
+		ClientSecret: a.AzureADClientSecret,
+		LoginURL:     a.AzureADLoginURL,
+		GraphURL:     a.AzureADGraphURL,
+	}
+
+	if c.Debug {
+		log.Printf("Finding users of azure ad tenant %s", a.AzureADTenant)
+	}
+
+	people, err := ad.Users()
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	orgSlugs, members, err := fetchAllMembers(c, client)
+	if err != nil {
+		return err
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	deprovisioned := report.DeprovisionedMembers(orgSlugs, members, people)
+	if deprovisioned == nil {
+		deprovisioned = []report.Deprovisioned{}
+	}
+
+	rows := make([][]string, 0, len(deprovisioned))
+	for _, d := range deprovisioned {
+		rows = append(rows, []string{d.Status, d.Org, d.Email, d.Name, d.ID, d.Role})
+	}
+
+	return writeTable(a.Output, deprovisioned, []string{"status", "org", "email", "name", "id", "role"}, rows)
+}
+
+// reconcile reports the members of the orgs who aren't among people, and the
+// people who aren't members
+func reconcile(c *cli, source string, people []directory.Person, output string) error {
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	orgSlugs, members, err := fetchAllMembers(c, client)
+	if err != nil {
+		return err
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	unreconciled := report.Reconcile(source, orgSlugs, members, people)
+	if unreconciled == nil {
+		unreconciled = []report.Unreconciled{}
+	}
+
+	rows := make([][]string, 0, len(unreconciled))
+	for _, u := range unreconciled {
+		rows = append(rows, []string{u.OnlyIn, u.Org, u.Email, u.Name, u.ID, u.Role})
+	}
+
+	return writeTable(output, unreconciled, []string{"only_in", "org", "email", "name", "id", "role"}, rows)
+}
+
+// fetchAllMembers returns the members of each org
+func fetchAllMembers(c *cli, client *buildkite.Client) ([]string, map[string][]buildkite.OrgMember, error) {
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fetch, err := c.fetchFunc(client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	members := map[string][]buildkite.OrgMember{}
+	for _, orgSlug := range orgSlugs {
+		if c.Debug {
+			log.Printf("Finding members in %s", orgSlug)
+		}
+
+		members[orgSlug], err = fetch(orgSlug)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", orgSlug, err)
+		}
+	}
+
+	return orgSlugs, members, nil
+}
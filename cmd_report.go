@@ -0,0 +1,356 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/lock"
+	"github.com/lox/buildkite-accounter/internal/metrics"
+	"github.com/lox/buildkite-accounter/internal/notify"
+	"github.com/lox/buildkite-accounter/internal/report"
+	"github.com/lox/buildkite-accounter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type reportCmd struct {
+	Output              []string `flag:"" help:"How to output rows, one or more of count, json, csv, clusters, html, pdf, template, delta or gsheet, optionally written to a file with format=path" default:"json"`
+	SheetID             string   `flag:"" help:"The ID of the Google Sheet written by --output gsheet, from its URL"`
+	SheetTab            string   `flag:"" help:"The tab of the Google Sheet to replace with members, added if it doesn't exist" default:"Members"`
+	GoogleCredentials   string   `flag:"" help:"A Google service account key file for --output gsheet, defaults to application default credentials like GOOGLE_APPLICATION_CREDENTIALS" type:"existingfile"`
+	AirtableBase        string   `flag:"" help:"Upsert members into a table of this Airtable base ID after each complete run, keyed by member ID and org"`
+	AirtableTable       string   `flag:"" help:"The name or ID of the Airtable table to upsert members into" default:"Members"`
+	AirtableToken       string   `flag:"" help:"An Airtable personal access token with write access to --airtable-base" env:"AIRTABLE_TOKEN"`
+	NotionDatabase      string   `flag:"" help:"Upsert a page per member into this Notion database ID after each complete run"`
+	NotionToken         string   `flag:"" help:"A Notion integration token with access to --notion-database" env:"NOTION_TOKEN"`
+	CreateJiraTickets   bool     `flag:"" help:"Open or update a Jira issue for each member that became stale since the last run or matches --jira-policy"`
+	JiraURL             string   `flag:"" help:"The Jira Cloud site, like https://acme.atlassian.net" env:"JIRA_URL"`
+	JiraUser            string   `flag:"" help:"The email of the Jira account that opens issues" env:"JIRA_USER"`
+	JiraToken           string   `flag:"" help:"An API token of the Jira account" env:"JIRA_API_TOKEN"`
+	JiraProject         string   `flag:"" help:"The key of the Jira project to open issues in"`
+	JiraIssueType       string   `flag:"" help:"The type of the Jira issues to open" default:"Task"`
+	JiraPolicy          string   `flag:"" help:"Also open an issue for members matching this expression, like --filter"`
+	PagerdutyRoutingKey string   `flag:"" help:"Trigger a PagerDuty alert through the Events API with this integration key when an org breaches --max-seats, --max-admins or --allowed-domains" env:"PAGERDUTY_ROUTING_KEY"`
+	PagerdutySeverity   string   `flag:"" help:"The severity of PagerDuty alerts" enum:"critical,error,warning,info" default:"warning"`
+	MaxSeats            int      `flag:"" help:"The most paid seats an org should have before alerting"`
+	MaxAdmins           int      `flag:"" help:"The most admins an org should have before alerting"`
+	AllowedDomains      []string `flag:"" help:"The email domains members should have, alerting on others"`
+	DeltaFormat         string   `flag:"" help:"The format of --output delta, the members added, removed or changed since the last run" enum:"json,csv,count" default:"json"`
+	Query               string   `flag:"" help:"A jq query applied to the JSON output, e.g. 'map(.email)'"`
+	ReportTemplate      string   `flag:"" help:"A text/template file used to render --output template" type:"existingfile"`
+	NoColor             bool     `flag:"" help:"Disable colored output, also set by NO_COLOR"`
+	Compact             bool     `flag:"" help:"Disable indentation of JSON output"`
+	Quiet               bool     `flag:"" help:"Only write output to stdout if memberships changed since the last quiet run"`
+	ChangesOnly         bool     `flag:"" help:"Only output members added, removed or changed since the last run, with json, csv or count output"`
+	AppendTimeseries    string   `flag:"" help:"Append a row per org of seat counts to this CSV file, or SQLite database if it ends in .db, .sqlite or .sqlite3, after each complete run" type:"path"`
+	NotifyWebhook       string   `flag:"" help:"POST a JSON payload of members added, removed, changed or newly stale since the last run to this URL"`
+	NotifyTeams         string   `flag:"" help:"POST an adaptive card summarizing members added, removed, changed or newly stale since the last run to this Microsoft Teams incoming webhook URL" env:"BUILDKITE_ACCOUNTER_TEAMS_WEBHOOK"`
+	NotifyEvents        []string `flag:"" help:"Only notify --notify-webhook and --notify-teams of these types of events, e.g. role_changed to watch for privilege escalation" enum:"added,removed,role_changed,changed,stale"`
+	NotifyWebhookSecret string   `flag:"" help:"Sign --notify-webhook payloads with HMAC-SHA256 using this secret" env:"BUILDKITE_ACCOUNTER_WEBHOOK_SECRET"`
+	MaxRequests         int      `flag:"" help:"Stop fetching after this many GraphQL requests, writing partial results and exiting with status 4"`
+	OnInterrupt         string   `flag:"" help:"What to do with partial results when interrupted by SIGINT or SIGTERM, prompt asks if stdin is a terminal and discards otherwise" enum:"prompt,write,discard" default:"prompt"`
+	DryRun              bool     `flag:"" help:"Print the GraphQL queries that would be executed without executing them"`
+}
+
+func (r *reportCmd) Run(c *cli) error {
+	if r.DryRun {
+		return c.printQueries()
+	}
+
+	filter, err := c.filterOptions()
+	if err != nil {
+		return err
+	}
+
+	outputs, err := r.outputTargets()
+	if err != nil {
+		return err
+	}
+
+	if r.AirtableBase != "" && r.AirtableToken == "" {
+		return fmt.Errorf("--airtable-base requires --airtable-token or AIRTABLE_TOKEN")
+	}
+
+	if r.NotionDatabase != "" && r.NotionToken == "" {
+		return fmt.Errorf("--notion-database requires --notion-token or NOTION_TOKEN")
+	}
+
+	if r.CreateJiraTickets && (r.JiraURL == "" || r.JiraUser == "" || r.JiraToken == "" || r.JiraProject == "") {
+		return fmt.Errorf("--create-jira-tickets requires --jira-url, --jira-user, --jira-token and --jira-project")
+	}
+
+	var jiraPolicy *report.Expression
+	if r.JiraPolicy != "" {
+		jiraPolicy, err = report.CompileExpression(r.JiraPolicy)
+		if err != nil {
+			return err
+		}
+	}
+
+	var query *report.Query
+	if r.Query != "" {
+		query, err = report.CompileQuery(r.Query)
+		if err != nil {
+			return err
+		}
+	}
+
+	var tmpl *template.Template
+	if r.ReportTemplate != "" {
+		tmpl, err = report.ParseTemplate(r.ReportTemplate)
+		if err != nil {
+			return err
+		}
+	}
+
+	store, err := c.stateStore()
+	if err != nil {
+		return err
+	}
+
+	lockFile := c.LockFile
+	if lockFile == "" && (r.Quiet || c.writesCache()) {
+		lockFile = filepath.Join(c.CacheDir, "run.lock")
+	}
+
+	if lockFile != "" {
+		l, err := lock.TryLock(lockFile)
+		if errors.Is(err, lock.ErrLocked) {
+			return fmt.Errorf("another run is in progress: %w", err)
+		} else if err != nil {
+			return err
+		}
+		defer l.Release()
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	client.SetMaxRequests(r.MaxRequests)
+
+	interrupt, stop := notifyInterrupt()
+	defer stop()
+
+	t := time.Now()
+	rep, err := c.buildReport(client, filter, interrupt)
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	run := metrics.Run{
+		Duration:    time.Since(t),
+		APIRequests: client.Stats().Requests,
+	}
+
+	if err != nil {
+		run.Errors = 1
+		// report the failed run, but the original error is more useful
+		if merr := c.publishMetrics(nil, run); merr != nil && c.Debug {
+			log.Printf("Failed to publish metrics: %v", merr)
+		}
+		return err
+	}
+
+	if rep.Partial && client.Stats().Rejected > 0 {
+		return r.writeBudgetExceeded(rep, outputs, query, tmpl)
+	} else if rep.Partial {
+		return r.writePartial(rep, outputs, query, tmpl)
+	}
+
+	run.Errors = len(rep.Failures)
+
+	// failures are notable, and partial results shouldn't become the last run
+	if len(rep.Failures) == 0 {
+		previous, err := c.loadLastRun(store)
+		if err != nil {
+			return err
+		}
+
+		if previous != nil {
+			rep.Changes = report.Diff(previous.Members, rep.Members)
+			log.Printf("Since last run: %d added, %d removed, %d changed",
+				len(rep.Changes.Added), len(rep.Changes.Removed), len(rep.Changes.Changed))
+			for _, rc := range report.RoleChanges(rep.Changes) {
+				log.Printf("Role of %s in %s changed from %s to %s", rc.Email, rc.Org, rc.From, rc.To)
+			}
+		}
+
+		// notify before saving, so changes are sent again if it fails
+		if previous != nil && (r.NotifyWebhook != "" || r.NotifyTeams != "") {
+			if err := r.notifyWebhook(c, rep, previous, t); err != nil {
+				return err
+			}
+		}
+
+		// likewise, so newly stale members are ticketed again if it fails
+		if r.CreateJiraTickets {
+			if err := r.createJiraTickets(c, rep, previous, jiraPolicy); err != nil {
+				return err
+			}
+		}
+
+		if err := c.saveLastRun(store, rep, t); err != nil {
+			return err
+		}
+
+		if err := recordSeatHistory(store, rep, t); err != nil {
+			return err
+		}
+
+		if err := recordMemberHistory(store, rep, t); err != nil {
+			return err
+		}
+
+		if r.AppendTimeseries != "" {
+			staleAfter, err := report.ParseDuration(c.StaleAfter)
+			if err != nil {
+				return err
+			}
+			if err := appendTimeseries(r.AppendTimeseries, timeseriesRows(rep, staleAfter, t)); err != nil {
+				return fmt.Errorf("failed to append to %s: %w", r.AppendTimeseries, err)
+			}
+		}
+	}
+
+	quiet := false
+	if r.Quiet && len(rep.Failures) == 0 {
+		changed, err := c.changedSinceLastRun(store, rep)
+		if err != nil {
+			return err
+		}
+		quiet = !changed
+	}
+
+	for _, o := range outputs {
+		if quiet && o.path == "-" {
+			continue
+		}
+		_, span := tracing.Start(c.ctx, "write output", attribute.String("format", o.format), attribute.String("path", o.path))
+		err := r.writeOutput(o, rep, query, tmpl)
+		tracing.End(span, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.AirtableBase != "" {
+		if err := r.syncAirtable(rep); err != nil {
+			return err
+		}
+	}
+
+	if r.NotionDatabase != "" {
+		if err := r.syncNotion(c, rep); err != nil {
+			return err
+		}
+	}
+
+	if r.PagerdutyRoutingKey != "" && len(rep.Failures) == 0 {
+		if err := r.alertPagerDuty(rep); err != nil {
+			return err
+		}
+	}
+
+	if err := c.publishMetrics(rep, run); err != nil {
+		return err
+	}
+
+	if len(rep.Failures) > 0 {
+		return &exitError{
+			code: exitPartialResults,
+			err:  &report.PartialError{Failures: rep.Failures},
+		}
+	}
+
+	return nil
+}
+
+// notifyWebhook posts the changes since the previous run to --notify-webhook
+// and --notify-teams, if there are any
+func (r *reportCmd) notifyWebhook(c *cli, rep *report.Report, previous *lastRun, t time.Time) error {
+	staleAfter, err := report.ParseDuration(c.StaleAfter)
+	if err != nil {
+		return err
+	}
+
+	stale := report.BecameStale(previous.Members, previous.Time, rep.Members, staleAfter)
+	events := notify.Events(rep.Changes, stale)
+	if len(r.NotifyEvents) > 0 {
+		events = notify.FilterEvents(events, r.NotifyEvents)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	payload := notify.Payload{
+		Generated: t,
+		Orgs:      rep.Orgs,
+		Events:    events,
+	}
+
+	if r.NotifyWebhook != "" {
+		w := &notify.Webhook{URL: r.NotifyWebhook, Secret: r.NotifyWebhookSecret}
+		if err := w.Send(payload); err != nil {
+			return err
+		}
+	}
+
+	if r.NotifyTeams != "" {
+		teams := &notify.Teams{URL: r.NotifyTeams}
+		if err := teams.Send(payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeBudgetExceeded writes the results of a run stopped by --max-requests,
+// without publishing metrics or recording it as the last run
+func (r *reportCmd) writeBudgetExceeded(rep *report.Report, outputs []outputTarget, query *report.Query, tmpl *template.Template) error {
+	log.Printf("Warning: request budget of %d exhausted, results are partial", r.MaxRequests)
+
+	for _, o := range outputs {
+		if err := r.writeOutput(o, rep, query, tmpl); err != nil {
+			return err
+		}
+	}
+
+	return &exitError{
+		code: exitRequestBudgetExceeded,
+		err:  fmt.Errorf("request budget exceeded, wrote partial results for %d orgs", len(rep.Orgs)),
+	}
+}
+
+// writePartial writes the results of an interrupted run if requested, without
+// publishing metrics or recording it as the last run
+func (r *reportCmd) writePartial(rep *report.Report, outputs []outputTarget, query *report.Query, tmpl *template.Template) error {
+	write := r.OnInterrupt == `write`
+	if r.OnInterrupt == `prompt` {
+		write = confirmPartial(len(rep.Orgs))
+	}
+
+	if !write {
+		return &exitError{
+			code: exitInterrupted,
+			err:  fmt.Errorf("interrupted, partial results discarded"),
+		}
+	}
+
+	for _, o := range outputs {
+		if err := r.writeOutput(o, rep, query, tmpl); err != nil {
+			return err
+		}
+	}
+
+	return &exitError{
+		code: exitInterrupted,
+		err:  fmt.Errorf("interrupted, wrote partial results for %d orgs", len(rep.Orgs)),
+	}
+}
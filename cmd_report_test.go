@@ -0,0 +1,94 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lox/buildkite-accounter/buildkite/buildkitetest"
+	"github.com/lox/buildkite-accounter/internal/lock"
+)
+
+func TestReportLocksCacheDir(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		locked bool
+	}{
+		{name: "cache", args: []string{"--cache"}, locked: true},
+		{name: "swr", args: []string{"--cache", "--cache-strategy", "swr"}, locked: true},
+		{name: "fallback to cache", args: []string{"--fallback-to-cache"}, locked: true},
+		{name: "no cache", locked: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := buildkitetest.NewServer()
+			defer s.Close()
+			s.AddOrg("acme", neverAuthorized("alice", "alice@acme.com"))
+
+			cacheDir := t.TempDir()
+			l, err := lock.TryLock(filepath.Join(cacheDir, "run.lock"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Release()
+
+			args := append([]string{"--cache-dir", cacheDir}, tc.args...)
+			err = runCLI(t, s, append(args, "report", "--org-slugs", "acme", "--output", "count=/dev/null")...)
+			if tc.locked && (err == nil || !strings.Contains(err.Error(), "another run is in progress")) {
+				t.Fatalf("report error = %v, want another run in progress", err)
+			} else if !tc.locked && err != nil {
+				t.Fatalf("report error = %v", err)
+			}
+		})
+	}
+}
+
+func TestReportDryRunPrintsResolvedQueries(t *testing.T) {
+	s := buildkitetest.NewServer()
+	defer s.Close()
+	s.AddOrg("acme-eu", neverAuthorized("alice", "alice@acme.com"))
+	s.AddOrg("acme-us", neverAuthorized("bob", "bob@acme.com"))
+	s.AddOrg("llama", neverAuthorized("carol", "carol@llama.com"))
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = runCLI(t, s, "--org-slugs", "acme-*,llama", "--batch-orgs", "2", "report", "--dry-run")
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"# Expanded acme-*, llama to acme-eu, acme-us, llama",
+		"# Check llama exists\nquery ($orgSlug: ID!)",
+		"# First pages of members of acme-eu, acme-us\nquery OrgMembersBatch ($org0: ID!, $org1: ID!)",
+		"# Members of acme-eu\n# Only after the first page from the batch",
+		"# Members of llama\n# Repeated",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("dry run doesn't print %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(string(out), `"acme-*"`) {
+		t.Errorf("dry run prints queries for an unexpanded pattern:\n%s", out)
+	}
+
+	// only listing orgs to expand the pattern is sent
+	if n := s.Requests(); n != 1 {
+		t.Errorf("dry run sent %d requests, want 1", n)
+	}
+}
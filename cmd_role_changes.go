@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type roleChangesCmd struct {
+	FailOnPromotion bool   `flag:"" help:"Exit with status 5 if any member was promoted to admin"`
+	Output          string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (r *roleChangesCmd) Run(c *cli) error {
+	store, err := c.stateStore()
+	if err != nil {
+		return err
+	}
+
+	previous, err := c.loadLastRun(store)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		return fmt.Errorf("no previous run of these orgs to compare with, run report first")
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	rep, err := c.buildReport(client, report.FilterOptions{}, nil)
+	if err != nil {
+		return err
+	}
+	if len(rep.Failures) > 0 {
+		return &report.PartialError{Failures: rep.Failures}
+	}
+
+	changes := report.RoleChanges(report.Diff(previous.Members, rep.Members))
+
+	rows := make([][]string, 0, len(changes))
+	promotions := 0
+	for _, rc := range changes {
+		rows = append(rows, []string{rc.Org, rc.Email, rc.Name, rc.From, rc.To, fmt.Sprint(rc.Promotion)})
+		if rc.Promotion {
+			promotions++
+		}
+	}
+
+	if err := writeTable(r.Output, changes, []string{"org", "email", "name", "from", "to", "promotion"}, rows); err != nil {
+		return err
+	}
+
+	if r.FailOnPromotion && promotions > 0 {
+		return &exitError{
+			code: exitPolicyViolated,
+			err:  fmt.Errorf("%d members were promoted to admin since the last run", promotions),
+		}
+	}
+
+	return nil
+}
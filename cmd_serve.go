@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/metrics"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type serveCmd struct {
+	Listen         string        `flag:"" help:"The address to serve health, readiness and metrics endpoints on" default:":8080"`
+	Interval       time.Duration `flag:"" help:"How often to refresh members" default:"1h"`
+	UnhealthyAfter time.Duration `flag:"" help:"How long without a successful refresh before /healthz fails, defaults to three intervals"`
+	PprofListen    string        `flag:"" help:"Serve the net/http/pprof endpoints on this address, like localhost:6060, to profile the daemon"`
+}
+
+func (s *serveCmd) Run(c *cli) error {
+	return s.serve(c, nil)
+}
+
+// serve refreshes members in the background and serves the daemon endpoints,
+// along with any routes added by the routes func
+func (s *serveCmd) serve(c *cli, routes func(mux *http.ServeMux, d *daemon)) error {
+	filter, err := c.filterOptions()
+	if err != nil {
+		return err
+	}
+
+	staleAfter, err := report.ParseDuration(c.StaleAfter)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	unhealthyAfter := s.UnhealthyAfter
+	if unhealthyAfter == 0 {
+		unhealthyAfter = 3 * s.Interval
+	}
+
+	d := &daemon{
+		cli:            c,
+		client:         client,
+		filter:         filter,
+		staleAfter:     staleAfter,
+		started:        time.Now(),
+		unhealthyAfter: unhealthyAfter,
+	}
+
+	if s.PprofListen != "" {
+		go servePprof(s.PprofListen)
+	}
+
+	go d.refreshEvery(s.Interval)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", d.serveHealthz)
+	mux.HandleFunc("/readyz", d.serveReadyz)
+	mux.HandleFunc("/metrics", d.serveMetrics)
+
+	if routes != nil {
+		routes(mux, d)
+	}
+
+	log.Printf("Listening on %s", s.Listen)
+	return http.ListenAndServe(s.Listen, mux)
+}
+
+// daemon holds the periodically refreshed report and the state of refreshes
+type daemon struct {
+	cli            *cli
+	client         *buildkite.Client
+	filter         report.FilterOptions
+	staleAfter     time.Duration
+	started        time.Time
+	unhealthyAfter time.Duration
+
+	mu          sync.RWMutex
+	report      *report.Report
+	lastRefresh time.Time
+	lastSuccess time.Time
+	lastError   error
+}
+
+func (d *daemon) refreshEvery(interval time.Duration) {
+	for {
+		d.refresh()
+		time.Sleep(interval)
+	}
+}
+
+func (d *daemon) refresh() {
+	t := time.Now()
+	requests := d.client.Stats().Requests
+
+	d.cli.forgetMembers()
+	rep, err := d.cli.buildReport(d.client, d.filter, nil)
+
+	run := metrics.Run{
+		Duration:    time.Since(t),
+		APIRequests: d.client.Stats().Requests - requests,
+	}
+
+	if err == nil {
+		run.Errors = len(rep.Failures)
+		err = d.cli.publishMetrics(rep, run)
+	} else {
+		run.Errors = 1
+		if merr := d.cli.publishMetrics(nil, run); merr != nil {
+			log.Printf("Failed to publish metrics: %v", merr)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastRefresh = time.Now()
+	d.lastError = err
+
+	if rep != nil {
+		d.report = rep
+		d.lastSuccess = d.lastRefresh
+	}
+
+	if err != nil {
+		log.Printf("Refresh failed after %v: %v", time.Since(t), err)
+	} else if d.cli.Debug {
+		log.Printf("Refreshed %d members in %v", len(rep.Members), time.Since(t))
+	}
+}
+
+type daemonStatus struct {
+	Status      string     `json:"status"`
+	LastRefresh *time.Time `json:"last_refresh,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+func (d *daemon) status(status string) daemonStatus {
+	s := daemonStatus{Status: status}
+	if !d.lastRefresh.IsZero() {
+		s.LastRefresh = &d.lastRefresh
+	}
+	if !d.lastSuccess.IsZero() {
+		s.LastSuccess = &d.lastSuccess
+	}
+	if d.lastError != nil {
+		s.LastError = d.lastError.Error()
+	}
+	return s
+}
+
+// serveHealthz fails once there hasn't been a successful refresh for a while,
+// e.g. when the token has expired, so the daemon can be restarted
+func (d *daemon) serveHealthz(w http.ResponseWriter, r *http.Request) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	since := d.lastSuccess
+	if since.IsZero() {
+		since = d.started
+	}
+
+	if time.Since(since) > d.unhealthyAfter {
+		writeJSON(w, http.StatusServiceUnavailable, d.status("unhealthy"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, d.status("ok"))
+}
+
+// serveReadyz succeeds once members have been loaded and the most recent
+// refresh succeeded
+func (d *daemon) serveReadyz(w http.ResponseWriter, r *http.Request) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.report == nil || d.lastError != nil {
+		writeJSON(w, http.StatusServiceUnavailable, d.status("not ready"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, d.status("ready"))
+}
+
+func (d *daemon) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.report == nil {
+		http.Error(w, "members not loaded yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	gauges := metrics.Gauges(d.report, d.staleAfter)
+	if d.cli.httpMetrics != nil {
+		gauges = append(gauges, metrics.HTTPGauges(d.cli.httpMetrics.Metrics())...)
+	}
+	_ = metrics.WritePrometheus(w, gauges)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type serveAPICmd struct {
+	serveCmd
+
+	AuthToken      string `flag:"" help:"Require this bearer token for API requests" env:"BUILDKITE_ACCOUNTER_AUTH_TOKEN"`
+	InsecureNoAuth bool   `flag:"" help:"Serve members without an --auth-token, to anyone who can reach --listen"`
+}
+
+func (s *serveAPICmd) Run(c *cli) error {
+	if s.AuthToken == "" && !s.InsecureNoAuth {
+		return errors.New("serve-api needs an --auth-token to protect members, or --insecure-no-auth to serve them to anyone")
+	}
+
+	return s.serve(c, func(mux *http.ServeMux, d *daemon) {
+		mux.Handle("GET /orgs/{slug}/members", s.authorize(http.HandlerFunc(d.serveOrgMembers)))
+		mux.Handle("GET /members", s.authorize(http.HandlerFunc(d.serveMembers)))
+		mux.Handle("GET /summary", s.authorize(http.HandlerFunc(d.serveSummary)))
+	})
+}
+
+func (s *serveAPICmd) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.AuthToken != "" {
+			expected := []byte("Bearer " + s.AuthToken)
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+				writeJSON(w, http.StatusUnauthorized, apiError{Error: "unauthorized"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// currentReport returns the most recently refreshed report and its members
+// matching --email and --filter, writing an error response if members
+// haven't been loaded yet
+func (d *daemon) currentReport(w http.ResponseWriter) (*report.Report, []report.Member, bool) {
+	d.mu.RLock()
+	rep := d.report
+	d.mu.RUnlock()
+
+	if rep == nil {
+		writeJSON(w, http.StatusServiceUnavailable, apiError{Error: "members not loaded yet"})
+		return nil, nil, false
+	}
+
+	members, err := report.FilterMembers(rep.Members, d.filter)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error()})
+		return nil, nil, false
+	}
+	return rep, members, true
+}
+
+func (d *daemon) serveOrgMembers(w http.ResponseWriter, r *http.Request) {
+	rep, filtered, ok := d.currentReport(w)
+	if !ok {
+		return
+	}
+
+	slug := r.PathValue("slug")
+
+	found := false
+	for _, orgSlug := range rep.Orgs {
+		if orgSlug == slug {
+			found = true
+		}
+	}
+	if !found {
+		writeJSON(w, http.StatusNotFound, apiError{Error: "organization not found"})
+		return
+	}
+
+	members := []report.Member{}
+	for _, m := range filtered {
+		if m.Org == slug {
+			members = append(members, m)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
+
+// serveMembers returns members matching the org, domain, email and role query params
+func (d *daemon) serveMembers(w http.ResponseWriter, r *http.Request) {
+	_, filtered, ok := d.currentReport(w)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	members := []report.Member{}
+
+	for _, m := range filtered {
+		if v := q.Get("org"); v != "" && v != m.Org {
+			continue
+		}
+		if v := q.Get("domain"); v != "" && v != m.Domain {
+			continue
+		}
+		if v := q.Get("email"); v != "" && v != m.Email {
+			continue
+		}
+		if v := q.Get("role"); v != "" && v != m.Role {
+			continue
+		}
+		members = append(members, m)
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
+
+type orgSummary struct {
+	Slug          string `json:"slug"`
+	Seats         int    `json:"seats"`
+	Admins        int    `json:"admins"`
+	Complimentary int    `json:"complimentary"`
+	Stale         int    `json:"stale"`
+}
+
+type summary struct {
+	Refreshed time.Time      `json:"refreshed"`
+	Seats     int            `json:"seats"`
+	Orgs      []orgSummary   `json:"orgs"`
+	Domains   map[string]int `json:"domains"`
+}
+
+func (d *daemon) serveSummary(w http.ResponseWriter, r *http.Request) {
+	_, members, ok := d.currentReport(w)
+	if !ok {
+		return
+	}
+
+	d.mu.RLock()
+	s := summary{
+		Refreshed: d.lastSuccess,
+		Seats:     len(members),
+		Domains:   map[string]int{},
+	}
+	d.mu.RUnlock()
+
+	orgs := map[string]*orgSummary{}
+	for _, m := range members {
+		o, ok := orgs[m.Org]
+		if !ok {
+			o = &orgSummary{Slug: m.Org}
+			orgs[m.Org] = o
+		}
+		o.Seats++
+		if m.Role == `admin` {
+			o.Admins++
+		}
+		if m.Complimentary {
+			o.Complimentary++
+		}
+		if m.IsStale(d.staleAfter) {
+			o.Stale++
+		}
+		s.Domains[m.Domain]++
+	}
+
+	for _, o := range orgs {
+		s.Orgs = append(s.Orgs, *o)
+	}
+	sort.Slice(s.Orgs, func(i, j int) bool {
+		return s.Orgs[i].Slug < s.Orgs[j].Slug
+	})
+
+	writeJSON(w, http.StatusOK, s)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite/buildkitetest"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+func TestServeAPIRequiresAuthToken(t *testing.T) {
+	s := buildkitetest.NewServer()
+	defer s.Close()
+
+	err := runCLI(t, s, "serve-api", "--listen", "127.0.0.1:0")
+	if err == nil || !strings.Contains(err.Error(), "--insecure-no-auth") {
+		t.Fatalf("serve-api error = %v, want it to need an auth token", err)
+	}
+}
+
+func TestServeAPIFiltersMembers(t *testing.T) {
+	expr, err := report.CompileExpression(`member.domain == "acme.com"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recent := time.Now().Add(-time.Hour)
+	d := &daemon{
+		filter:     report.FilterOptions{Expression: expr},
+		staleAfter: 24 * time.Hour,
+		report: &report.Report{
+			Orgs: []string{"acme"},
+			Members: []report.Member{
+				{ID: "alice", Email: "alice@acme.com", Domain: "acme.com", Org: "acme", Role: "admin", LastAuth: &recent},
+				{ID: "bob", Email: "bob@acme.com", Domain: "acme.com", Org: "acme", Role: "member"},
+				{ID: "carol", Email: "carol@contractor.com", Domain: "contractor.com", Org: "acme", Role: "member"},
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orgs/{slug}/members", d.serveOrgMembers)
+	mux.HandleFunc("GET /members", d.serveMembers)
+	mux.HandleFunc("GET /summary", d.serveSummary)
+
+	get := func(path string, v interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s returned %d: %s", path, w.Code, w.Body)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, path := range []string{"/members", "/orgs/acme/members"} {
+		var members []report.Member
+		get(path, &members)
+
+		var emails []string
+		for _, m := range members {
+			emails = append(emails, m.Email)
+		}
+		if want := []string{"alice@acme.com", "bob@acme.com"}; !reflect.DeepEqual(emails, want) {
+			t.Errorf("GET %s = %v, want %v", path, emails, want)
+		}
+	}
+
+	var s summary
+	get("/summary", &s)
+	want := []orgSummary{{Slug: "acme", Seats: 2, Admins: 1, Stale: 1}}
+	if s.Seats != 2 || !reflect.DeepEqual(s.Orgs, want) {
+		t.Errorf("GET /summary = %d seats in %+v, want 2 in %+v", s.Seats, s.Orgs, want)
+	}
+}
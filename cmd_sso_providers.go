@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ssoProvidersCmd struct {
+	Output string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+// ssoProvider is an org's SSO provider, or the lack of one
+type ssoProvider struct {
+	Org                    string     `json:"org"`
+	Type                   string     `json:"type"`
+	State                  string     `json:"state"`
+	SessionDurationInHours int        `json:"session_duration_hours,omitempty"`
+	Enforced               bool       `json:"enforced"`
+	EmailDomain            string     `json:"email_domain,omitempty"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+}
+
+func (s *ssoProvidersCmd) Run(c *cli) error {
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return err
+	}
+
+	providers := []ssoProvider{}
+	for _, orgSlug := range orgSlugs {
+		if c.Debug {
+			log.Printf("Finding sso providers in %s", orgSlug)
+		}
+
+		orgProviders, err := client.GetSSOProviders(orgSlug)
+		if err != nil {
+			return fmt.Errorf("%s: %w", orgSlug, err)
+		}
+
+		// orgs without a provider are listed so it's clear last auth is
+		// meaningless for them
+		if len(orgProviders) == 0 {
+			providers = append(providers, ssoProvider{Org: orgSlug, Type: "none"})
+		}
+
+		for _, p := range orgProviders {
+			providers = append(providers, ssoProvider{
+				Org:                    orgSlug,
+				Type:                   strings.ToLower(p.Type),
+				State:                  strings.ToLower(p.State),
+				SessionDurationInHours: int(p.SessionDuration / time.Hour),
+				Enforced:               p.Enforced(),
+				EmailDomain:            p.EmailDomain,
+				EnabledAt:              p.EnabledAt,
+			})
+		}
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	rows := make([][]string, 0, len(providers))
+	for _, p := range providers {
+		session := ""
+		if p.SessionDurationInHours > 0 {
+			session = strconv.Itoa(p.SessionDurationInHours) + "h"
+		}
+		enabledAt := ""
+		if p.EnabledAt != nil {
+			enabledAt = p.EnabledAt.Format("2006-01-02")
+		}
+		rows = append(rows, []string{
+			p.Org,
+			p.Type,
+			p.State,
+			session,
+			strconv.FormatBool(p.Enforced),
+			p.EmailDomain,
+			enabledAt,
+		})
+	}
+
+	return writeTable(s.Output, providers, []string{"org", "type", "state", "session_duration", "enforced", "email_domain", "enabled_at"}, rows)
+}
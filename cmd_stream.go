@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type streamCmd struct{}
+
+// Run writes each member as a line of JSON as soon as its page is fetched,
+// holding no more than a page of members in memory
+func (s *streamCmd) Run(c *cli) error {
+	if c.Offline || c.Cache {
+		return fmt.Errorf("stream reads members straight from the API, it can't be used with --offline or --cache")
+	}
+
+	filter, err := c.filterOptions()
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	var failed int
+
+	for _, orgSlug := range orgSlugs {
+		if c.Debug {
+			log.Printf("Streaming members of %s", orgSlug)
+		}
+
+		for om, err := range client.OrgMembers(orgSlug) {
+			if err == nil {
+				err = s.write(enc, filter, c.WithSSODetails, orgSlug, om)
+			}
+			if err == nil {
+				continue
+			}
+			if !c.ContinueOnError {
+				return fmt.Errorf("%s: %w", orgSlug, err)
+			}
+			log.Printf("Failed to stream members of %s: %v", orgSlug, err)
+			failed++
+			break
+		}
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	if failed > 0 {
+		return &exitError{
+			code: exitPartialResults,
+			err:  fmt.Errorf("failed to stream %d of %d orgs", failed, len(orgSlugs)),
+		}
+	}
+
+	return nil
+}
+
+// write writes a member as a line of JSON if it matches the filter, leaving
+// out their SSO details unless withSSO, like report
+func (s *streamCmd) write(enc *json.Encoder, filter report.FilterOptions, withSSO bool, orgSlug string, om buildkite.OrgMember) error {
+	m, err := report.NewMember(orgSlug, om)
+	if err != nil {
+		return err
+	}
+	if !withSSO {
+		m.SSO = nil
+	}
+
+	ok, err := filter.Matches(m)
+	if err != nil || !ok {
+		return err
+	}
+
+	return enc.Encode(m)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/audit"
+	"github.com/lox/buildkite-accounter/internal/teamsync"
+)
+
+type teamsCmd struct {
+	Sync teamsSyncCmd `cmd:"" help:"Plan, and with --apply make, the team membership changes that match a spec file"`
+}
+
+type teamsSyncCmd struct {
+	guardrails `embed:""`
+
+	Spec   string `flag:"" help:"A YAML file of the desired maintainers and members of teams" type:"existingfile" required:""`
+	Apply  bool   `flag:"" help:"Make the planned changes, rather than only showing them"`
+	Output string `flag:"" help:"How to output the plan" enum:"table,json,csv" default:"table"`
+}
+
+func (t *teamsSyncCmd) Run(c *cli) error {
+	spec, err := teamsync.LoadSpec(t.Spec)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	var auditLog *audit.Log
+	if t.Apply {
+		// fail before changing anything if mutations can't be audited
+		auditLog, err = c.openAuditLog()
+		if err != nil {
+			return err
+		}
+		defer auditLog.Close()
+	}
+
+	teams := map[string][]buildkite.Team{}
+	members := map[string][]buildkite.OrgMember{}
+	for _, orgSlug := range spec.OrgSlugs() {
+		if c.Debug {
+			log.Printf("Finding teams and members in %s", orgSlug)
+		}
+
+		teams[orgSlug], err = client.GetTeams(orgSlug)
+		if err != nil {
+			return fmt.Errorf("%s: %w", orgSlug, err)
+		}
+
+		// changes are made against the current members, never the cache
+		members[orgSlug], err = client.GetOrgMembers(orgSlug)
+		if err != nil {
+			return fmt.Errorf("%s: %w", orgSlug, err)
+		}
+	}
+
+	changes, problems := teamsync.Plan(spec, teams, members)
+
+	for _, p := range problems {
+		if p.Email != "" {
+			log.Printf("Warning: %s in %s/%s is %s", p.Email, p.Org, p.Team, p.Reason)
+		} else {
+			log.Printf("Warning: %s/%s %s", p.Org, p.Team, p.Reason)
+		}
+	}
+
+	rows := make([][]string, 0, len(changes))
+	for _, ch := range changes {
+		rows = append(rows, []string{ch.Action, ch.Org, ch.Team, ch.Email, ch.Role, ch.FromRole})
+	}
+
+	plan := struct {
+		Changes  []teamsync.Change  `json:"changes"`
+		Problems []teamsync.Problem `json:"problems"`
+	}{changes, problems}
+
+	if err := writeTable(t.Output, plan, []string{"action", "org", "team", "email", "role", "from_role"}, rows); err != nil {
+		return err
+	}
+
+	if t.Apply && len(problems) > 0 {
+		return fmt.Errorf("not applying with %d problems in the spec, fix them first", len(problems))
+	}
+
+	lines := make([]string, 0, len(changes))
+	for _, ch := range changes {
+		lines = append(lines, describeTeamChange(ch))
+	}
+
+	ok, err := t.approve(lines, !t.Apply, fmt.Sprintf("Apply %d changes?", len(changes)))
+	if err != nil || !ok {
+		return err
+	}
+
+	if !t.Apply {
+		if c.Debug || c.Stats {
+			printStats(client.Stats())
+		}
+		log.Printf("Planned %d changes, run with --apply to make them", len(changes))
+		return nil
+	}
+
+	var failed int
+	for _, ch := range changes {
+		if err := applyTeamChange(client, auditLog, ch); err != nil {
+			log.Printf("Failed to %s %s in %s/%s: %v", ch.Action, ch.Email, ch.Org, ch.Team, err)
+			failed++
+		}
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	log.Printf("Applied %d of %d changes, %d failed", len(changes)-failed, len(changes), failed)
+
+	if failed > 0 {
+		return fmt.Errorf("failed to apply %d changes", failed)
+	}
+
+	return nil
+}
+
+// applyTeamChange makes a planned change and audits it
+func applyTeamChange(client *buildkite.Client, auditLog *audit.Log, ch teamsync.Change) error {
+	entry := audit.Entry{
+		Target: ch.Email + " in " + ch.Team,
+		Org:    ch.Org,
+	}
+
+	var response []byte
+	var err error
+
+	switch ch.Action {
+	case teamsync.ActionAdd:
+		entry.Action, entry.TargetID = "add_team_member", ch.UserID
+		response, err = client.AddTeamMember(ch.TeamID, ch.UserID, teamRole(ch.Role))
+	case teamsync.ActionUpdate:
+		entry.Action, entry.TargetID = "update_team_member", ch.TeamMemberID
+		response, err = client.UpdateTeamMember(ch.TeamMemberID, teamRole(ch.Role))
+	case teamsync.ActionRemove:
+		entry.Action, entry.TargetID = "remove_team_member", ch.TeamMemberID
+		response, err = client.RemoveTeamMember(ch.TeamMemberID)
+	default:
+		return fmt.Errorf("unknown action %q", ch.Action)
+	}
+
+	if aerr := auditLog.Record(entry, response, err); aerr != nil {
+		return fmt.Errorf("failed to write audit log: %w", aerr)
+	}
+	return err
+}
+
+// describeTeamChange describes a change for confirmation
+func describeTeamChange(ch teamsync.Change) string {
+	switch ch.Action {
+	case teamsync.ActionAdd:
+		return fmt.Sprintf("add %s to %s/%s as %s", ch.Email, ch.Org, ch.Team, ch.Role)
+	case teamsync.ActionUpdate:
+		return fmt.Sprintf("change %s in %s/%s from %s to %s", ch.Email, ch.Org, ch.Team, ch.FromRole, ch.Role)
+	default:
+		return fmt.Sprintf("%s %s from %s/%s", ch.Action, ch.Email, ch.Org, ch.Team)
+	}
+}
+
+// teamRole returns the API's role for a role in a spec
+func teamRole(role string) string {
+	if role == teamsync.RoleMaintainer {
+		return "MAINTAINER"
+	}
+	return "MEMBER"
+}
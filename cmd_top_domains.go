@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type topDomainsCmd struct {
+	Limit  int    `flag:"" help:"How many domains to list, zero for all" default:"20"`
+	Output string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (t *topDomainsCmd) Run(c *cli) error {
+	staleAfter, err := report.ParseDuration(c.StaleAfter)
+	if err != nil {
+		return err
+	}
+
+	filter, err := c.filterOptions()
+	if err != nil {
+		return err
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	rep, err := c.buildReport(client, filter, nil)
+	if err != nil {
+		return err
+	}
+
+	members, err := report.FilterMembers(rep.Members, filter)
+	if err != nil {
+		return err
+	}
+
+	domains := report.TopDomains(members, staleAfter)
+	if t.Limit > 0 && len(domains) > t.Limit {
+		domains = domains[:t.Limit]
+	}
+
+	rows := make([][]string, 0, len(domains))
+	for _, d := range domains {
+		rows = append(rows, []string{d.Domain, fmt.Sprint(d.Seats), fmt.Sprintf("%.1f%%", d.Share*100), fmt.Sprint(d.Stale)})
+	}
+
+	return writeTable(t.Output, domains, []string{"domain", "seats", "share", "stale"}, rows)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+type usageCmd struct {
+	From   string `flag:"" help:"Include builds created on or after this date (YYYY-MM-DD or RFC3339), defaults to 30 days ago"`
+	To     string `flag:"" help:"Include builds created before this date (YYYY-MM-DD or RFC3339), defaults to now"`
+	By     string `flag:"" help:"Whether to sum usage per pipeline or per build creator" enum:"pipeline,creator" default:"pipeline"`
+	Output string `flag:"" help:"How to output rows" enum:"table,json,csv" default:"table"`
+}
+
+func (u *usageCmd) Run(c *cli) error {
+	to := time.Now()
+	if u.To != "" {
+		t, err := parseDate(u.To)
+		if err != nil {
+			return err
+		}
+		to = t
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if u.From != "" {
+		t, err := parseDate(u.From)
+		if err != nil {
+			return err
+		}
+		from = t
+	}
+
+	if !from.Before(to) {
+		return fmt.Errorf("--from must be before --to")
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return err
+	}
+
+	var builds []buildkite.Build
+	for _, orgSlug := range orgSlugs {
+		if c.Debug {
+			log.Printf("Finding builds in %s from %s to %s", orgSlug, from.Format(time.RFC3339), to.Format(time.RFC3339))
+		}
+		b, err := client.GetOrgBuilds(orgSlug, from, to)
+		if err != nil {
+			return fmt.Errorf("%s: %w", orgSlug, err)
+		}
+		builds = append(builds, b...)
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	return u.write(report.Usage(builds, u.By == `creator`))
+}
+
+func (u *usageCmd) write(usage []report.UsageRow) error {
+	rows := make([][]string, 0, len(usage))
+	for _, r := range usage {
+		rows = append(rows, []string{
+			r.Org,
+			r.Pipeline + r.Creator,
+			strconv.Itoa(r.Builds),
+			strconv.Itoa(r.Jobs),
+			strconv.FormatFloat(r.Minutes, 'f', 1, 64),
+		})
+	}
+
+	return writeTable(u.Output, usage, []string{"org", u.By, "builds", "jobs", "minutes"}, rows)
+}
+
+// parseDate parses a date like 2022-01-31 or an RFC3339 timestamp
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC3339", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+type whoamiCmd struct {
+	RESTEndpoint string `flag:"" name:"rest-endpoint" help:"The Buildkite REST API, which has the token's scopes" default:"https://api.buildkite.com/v2"`
+	Output       string `flag:"" help:"How to output who the token belongs to" enum:"text,json" default:"text"`
+}
+
+// whoami is who a token belongs to and what it can access
+type whoami struct {
+	Profile string `json:"profile,omitempty"`
+	User    struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"user"`
+	TokenUUID     string      `json:"token_uuid"`
+	Scopes        []string    `json:"scopes"`
+	Organizations []whoamiOrg `json:"organizations"`
+}
+
+type whoamiOrg struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+func (w *whoamiCmd) Run(c *cli) error {
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	viewer, err := client.GetViewer()
+	if err != nil {
+		return err
+	}
+
+	token, err := client.GetAccessToken(w.RESTEndpoint)
+	if err != nil {
+		return err
+	}
+
+	orgs, err := client.GetOrganizations()
+	if err != nil {
+		return err
+	}
+
+	if c.Debug || c.Stats {
+		printStats(client.Stats())
+	}
+
+	result := whoami{
+		Profile:       c.Profile,
+		TokenUUID:     token.UUID,
+		Scopes:        token.Scopes,
+		Organizations: []whoamiOrg{},
+	}
+	result.User.ID = viewer.ID
+	result.User.Name = viewer.Name
+	result.User.Email = viewer.Email
+	if result.Scopes == nil {
+		result.Scopes = []string{}
+	}
+	for _, org := range orgs {
+		result.Organizations = append(result.Organizations, whoamiOrg{Slug: org.Slug, Name: org.Name})
+	}
+
+	if w.Output == `json` {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if result.Profile != "" {
+		fmt.Fprintf(tw, "Profile:\t%s\n", result.Profile)
+	}
+	fmt.Fprintf(tw, "User:\t%s <%s>\n", viewer.Name, viewer.Email)
+	fmt.Fprintf(tw, "User ID:\t%s\n", viewer.ID)
+	fmt.Fprintf(tw, "Token:\t%s\n", token.UUID)
+	fmt.Fprintf(tw, "Scopes:\t%s\n", strings.Join(token.Scopes, ", "))
+	fmt.Fprintf(tw, "Organizations:\t%d\n", len(orgs))
+	for _, org := range orgs {
+		fmt.Fprintf(tw, "  %s\t%s\n", org.Slug, org.Name)
+	}
+	return tw.Flush()
+}
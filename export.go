@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/export"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+// exportRows returns the rows of the csv output of a report, header first
+func exportRows(t outputTarget, rep *report.Report) ([][]string, error) {
+	var buf bytes.Buffer
+
+	out, err := report.NewOutputWriter(`csv`, &buf, report.OutputOptions{ChangesOnly: t.changesOnly})
+	if err != nil {
+		return nil, err
+	}
+	if err := out.Write(rep); err != nil {
+		return nil, err
+	}
+	if err := out.Flush(); err != nil {
+		return nil, err
+	}
+
+	return csv.NewReader(&buf).ReadAll()
+}
+
+// writeExport writes a report to an external service like Google Sheets
+func (r *reportCmd) writeExport(t outputTarget, rep *report.Report) error {
+	rows, err := exportRows(t, rep)
+	if err != nil {
+		return err
+	}
+
+	switch t.format {
+	case `gsheet`:
+		httpClient, err := export.GoogleClient(context.Background(), r.GoogleCredentials)
+		if err != nil {
+			return fmt.Errorf("failed to authorize with google: %w", err)
+		}
+
+		sheet := &export.GoogleSheet{SpreadsheetID: r.SheetID, Tab: r.SheetTab, HTTPClient: httpClient}
+		return sheet.Write(rows)
+	}
+
+	return fmt.Errorf("unknown export format %q", t.format)
+}
+
+// syncAirtable upserts the members of a report into --airtable-table
+func (r *reportCmd) syncAirtable(rep *report.Report) error {
+	records := make([]map[string]interface{}, 0, len(rep.Members))
+	for _, m := range rep.Members {
+		lastAuth := ""
+		if m.LastAuth != nil {
+			lastAuth = m.LastAuth.UTC().Format(time.RFC3339)
+		}
+		records = append(records, map[string]interface{}{
+			"id":            m.ID,
+			"email":         m.Email,
+			"name":          m.Name,
+			"org":           m.Org,
+			"role":          m.Role,
+			"last_sso_auth": lastAuth,
+		})
+	}
+
+	a := &export.Airtable{Token: r.AirtableToken, Base: r.AirtableBase, Table: r.AirtableTable}
+	return a.Upsert(records, []string{"id", "org"})
+}
+
+// syncNotion upserts a page per member of a report into --notion-database
+func (r *reportCmd) syncNotion(c *cli, rep *report.Report) error {
+	staleAfter, err := report.ParseDuration(c.StaleAfter)
+	if err != nil {
+		return err
+	}
+
+	pages := make([]export.NotionPage, 0, len(rep.Members))
+	for _, m := range rep.Members {
+		title := m.Name
+		if title == "" {
+			title = m.Email
+		}
+		pages = append(pages, export.NotionPage{
+			Key: m.Org + "/" + m.ID,
+			Properties: map[string]interface{}{
+				"Name":      export.NotionTitle(title),
+				"Email":     export.NotionEmail(m.Email),
+				"Org":       export.NotionSelect(m.Org),
+				"Role":      export.NotionSelect(m.Role),
+				"Last Auth": export.NotionDate(m.LastAuth),
+				"Status":    export.NotionSelect(memberStatus(m, staleAfter)),
+			},
+		})
+	}
+
+	n := &export.Notion{Token: r.NotionToken, Database: r.NotionDatabase, KeyProperty: "ID"}
+	return n.Upsert(pages)
+}
+
+// memberStatus describes whether a member is active, stale or has never
+// authorized with SSO
+func memberStatus(m report.Member, staleAfter time.Duration) string {
+	switch {
+	case m.LastAuth == nil:
+		return "never authorized"
+	case m.IsStale(staleAfter):
+		return "stale"
+	}
+	return "active"
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// guardrails are the flags shared by the commands that change members
+type guardrails struct {
+	Yes        bool `flag:"" help:"Make the changes without confirmation, for automation"`
+	MaxChanges int  `flag:"" name:"max-changes" help:"Abort without changing anything if more than this many changes would be made, 0 for no limit"`
+}
+
+// errNotTerminal is returned when confirmation is needed without a terminal
+var errNotTerminal = errors.New("confirmation requires a terminal, use --yes to skip it")
+
+// checkMaxChanges fails if n changes are more than --max-changes
+func (g *guardrails) checkMaxChanges(n int) error {
+	if g.MaxChanges > 0 && n > g.MaxChanges {
+		return fmt.Errorf("aborting as %d changes would be made, more than --max-changes %d", n, g.MaxChanges)
+	}
+	return nil
+}
+
+// approve checks the changes against --max-changes, then unless --yes, dryRun
+// or there are none lists them and asks the question, returning whether to
+// make them
+func (g *guardrails) approve(changes []string, dryRun bool, question string) (bool, error) {
+	if err := g.checkMaxChanges(len(changes)); err != nil {
+		return false, err
+	}
+
+	if g.Yes || dryRun || len(changes) == 0 {
+		return true, nil
+	}
+
+	for _, change := range changes {
+		fmt.Printf("  %s\n", change)
+	}
+
+	return confirm(question)
+}
+
+// confirm asks a yes or no question on stdout, failing with errNotTerminal if
+// stdin isn't a terminal
+func confirm(question string) (bool, error) {
+	if !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		return false, errNotTerminal
+	}
+	answer, err := prompt(bufio.NewReader(os.Stdin), question+" [y/N] ")
+	if err != nil {
+		return false, err
+	}
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+	"github.com/lox/buildkite-accounter/internal/state"
+)
+
+const (
+	// seatHistoryKey is the key of the seats recorded by each complete run
+	seatHistoryKey = "seat-history.json"
+	// seatHistoryRetention is how long seat history is kept
+	seatHistoryRetention = 3 * 365 * 24 * time.Hour
+	// memberHistoryKey is the key of the changes in members of each org
+	// recorded by each complete run
+	memberHistoryKey = "member-history.json"
+)
+
+// loadSeatHistory returns the paid seats of each org recorded by past runs
+func loadSeatHistory(store state.Store) (map[string][]report.SeatSample, error) {
+	data, err := store.Get(seatHistoryKey)
+	if errors.Is(err, state.ErrNotFound) {
+		return map[string][]report.SeatSample{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	history := map[string][]report.SeatSample{}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// recordSeatHistory adds the paid seats of each org in the report to the seat
+// history, keeping one sample per org per day
+func recordSeatHistory(store state.Store, rep *report.Report, t time.Time) error {
+	history, err := loadSeatHistory(store)
+	if err != nil {
+		return err
+	}
+
+	seats := map[string]int{}
+	for _, org := range rep.Orgs {
+		seats[org] = 0
+	}
+	for _, m := range rep.Members {
+		if !m.Complimentary {
+			seats[m.Org]++
+		}
+	}
+
+	t = t.UTC()
+	for org, n := range seats {
+		samples := history[org]
+		if len(samples) > 0 && sameDay(samples[len(samples)-1].Time, t) {
+			samples = samples[:len(samples)-1]
+		}
+		samples = append(samples, report.SeatSample{Time: t, Seats: n})
+
+		for len(samples) > 0 && t.Sub(samples[0].Time) > seatHistoryRetention {
+			samples = samples[1:]
+		}
+		history[org] = samples
+	}
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return store.Put(seatHistoryKey, data)
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.UTC().Date()
+	by, bm, bd := b.UTC().Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// loadMemberHistory returns the history of the members of each org recorded
+// by past runs
+func loadMemberHistory(store state.Store) (map[string][]report.HistoryEvent, error) {
+	data, err := store.Get(memberHistoryKey)
+	if errors.Is(err, state.ErrNotFound) {
+		return map[string][]report.HistoryEvent{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	history := map[string][]report.HistoryEvent{}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// recordMemberHistory adds the changes in the members of each org in the
+// report to the member history
+func recordMemberHistory(store state.Store, rep *report.Report, t time.Time) error {
+	history, err := loadMemberHistory(store)
+	if err != nil {
+		return err
+	}
+
+	members := map[string][]report.Member{}
+	for _, m := range rep.Members {
+		members[m.Org] = append(members[m.Org], m)
+	}
+
+	for _, org := range rep.Orgs {
+		history[org] = report.RecordHistory(history[org], members[org], t.UTC())
+	}
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return store.Put(memberHistoryKey, data)
+}
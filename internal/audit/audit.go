@@ -0,0 +1,95 @@
+// Package audit keeps an append-only log of the mutations made through the
+// API, like removing members
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is a mutation, or one that would have been made in a dry run
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Actor is the fingerprint of the API token that made the mutation
+	Actor  string `json:"actor"`
+	Action string `json:"action"`
+	// Target is who or what was mutated, like a member's email
+	Target   string `json:"target"`
+	TargetID string `json:"target_id,omitempty"`
+	Org      string `json:"org"`
+	DryRun   bool   `json:"dry_run"`
+	// Response is the API response, as JSON if it was valid JSON
+	Response interface{} `json:"response,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// Log appends entries as JSON lines to a file
+type Log struct {
+	mu    sync.Mutex
+	f     *os.File
+	actor string
+}
+
+// Open opens the log at path for appending, creating it if needed, with
+// entries attributed to the fingerprint of token
+func Open(path, token string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Log{f: f, actor: Fingerprint(token)}, nil
+}
+
+// Fingerprint identifies a token without revealing it
+func Fingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// Record appends an entry, syncing it to disk before returning
+func (l *Log) Record(e Entry, response []byte, err error) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	e.Actor = l.actor
+
+	if len(response) > 0 {
+		if json.Valid(response) {
+			e.Response = json.RawMessage(response)
+		} else {
+			e.Response = string(response)
+		}
+	}
+
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	b, merr := json.Marshal(e)
+	if merr != nil {
+		return merr
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return l.f.Sync()
+}
+
+// Close closes the log
+func (l *Log) Close() error {
+	return l.f.Close()
+}
@@ -0,0 +1,53 @@
+// Package config loads the buildkite-accounter YAML config file
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the config file
+type Config struct {
+	// Orgs maps org slugs to labels that are merged into each member of the org
+	Orgs map[string]Labels `yaml:"orgs"`
+}
+
+// Labels are arbitrary key value pairs, e.g. {region: eu, cost_center: 1234}
+type Labels map[string]string
+
+// Load reads a config file
+func Load(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// OrgSlugs returns the slugs of the orgs in the config
+func (c *Config) OrgSlugs() []string {
+	slugs := make([]string, 0, len(c.Orgs))
+	for slug := range c.Orgs {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+	return slugs
+}
+
+// OrgLabels returns the labels for each org
+func (c *Config) OrgLabels() map[string]map[string]string {
+	labels := make(map[string]map[string]string, len(c.Orgs))
+	for slug, l := range c.Orgs {
+		labels[slug] = l
+	}
+	return labels
+}
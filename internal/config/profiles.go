@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profiles are named sets of flag values, like AWS CLI profiles
+type Profiles struct {
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile maps flag names like org_slugs or api-token-file to their values
+type Profile map[string]interface{}
+
+// DefaultProfilesPath returns the profiles file path, which is
+// ~/.config/buildkite-accounter/config unless XDG_CONFIG_HOME is set
+func DefaultProfilesPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "buildkite-accounter", "config"), nil
+}
+
+// LoadProfiles reads a profiles file
+func LoadProfiles(path string) (*Profiles, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Profiles
+	if err := yaml.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles %s: %w", path, err)
+	}
+
+	return &p, nil
+}
+
+// Get returns a named profile
+func (p *Profiles) Get(name string) (Profile, error) {
+	profile, ok := p.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(p.Profiles))
+		for n := range p.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("no profile named %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return profile, nil
+}
+
+// Value returns the value of a flag, treating hyphens and underscores alike
+func (p Profile) Value(flag string) (interface{}, bool) {
+	v, ok := p[profileKey(flag)]
+	return v, ok
+}
+
+// Keys returns the flag names set in the profile
+func (p Profile) Keys() []string {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func profileKey(flag string) string {
+	return strings.ReplaceAll(flag, "-", "_")
+}
+
+// UnmarshalYAML normalizes flag names to use underscores
+func (p *Profile) UnmarshalYAML(value *yaml.Node) error {
+	var m map[string]interface{}
+	if err := value.Decode(&m); err != nil {
+		return err
+	}
+	*p = make(Profile, len(m))
+	for k, v := range m {
+		(*p)[profileKey(k)] = v
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	// DefaultAzureADLoginURL is the Microsoft identity platform of the global cloud
+	DefaultAzureADLoginURL = "https://login.microsoftonline.com"
+	// DefaultAzureADGraphURL is Microsoft Graph in the global cloud
+	DefaultAzureADGraphURL = "https://graph.microsoft.com"
+)
+
+// AzureAD lists the users of a Microsoft Entra ID (Azure AD) tenant through
+// Microsoft Graph, authenticating as an application with a client secret
+type AzureAD struct {
+	Tenant       string
+	ClientID     string
+	ClientSecret string
+	// LoginURL and GraphURL are the endpoints of a national cloud, the
+	// global cloud's if empty
+	LoginURL string
+	GraphURL string
+
+	HTTPClient *http.Client
+}
+
+// Users returns every user of the tenant, including disabled ones, with their
+// mail, user principal name, other mails and SMTP proxy addresses. The
+// application needs the User.Read.All application permission
+func (a *AzureAD) Users() ([]Person, error) {
+	loginURL := a.LoginURL
+	if loginURL == "" {
+		loginURL = DefaultAzureADLoginURL
+	}
+	graphURL := strings.TrimSuffix(a.GraphURL, "/")
+	if graphURL == "" {
+		graphURL = DefaultAzureADGraphURL
+	}
+
+	ctx := context.Background()
+	if a.HTTPClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, a.HTTPClient)
+	}
+
+	config := clientcredentials.Config{
+		ClientID:     a.ClientID,
+		ClientSecret: a.ClientSecret,
+		TokenURL:     fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(loginURL, "/"), a.Tenant),
+		Scopes:       []string{graphURL + "/.default"},
+	}
+	httpClient := config.Client(ctx)
+
+	var people []Person
+	next := graphURL + "/v1.0/users?$select=id,displayName,mail,userPrincipalName,otherMails,proxyAddresses,accountEnabled&$top=999"
+	for next != "" {
+		var page struct {
+			Value []struct {
+				ID                string   `json:"id"`
+				DisplayName       string   `json:"displayName"`
+				Mail              string   `json:"mail"`
+				UserPrincipalName string   `json:"userPrincipalName"`
+				OtherMails        []string `json:"otherMails"`
+				ProxyAddresses    []string `json:"proxyAddresses"`
+				AccountEnabled    *bool    `json:"accountEnabled"`
+			} `json:"value"`
+			NextLink string `json:"@odata.nextLink"`
+		}
+		if err := getJSON(httpClient, next, &page); err != nil {
+			return nil, fmt.Errorf("failed to list azure ad users: %w", err)
+		}
+
+		for _, u := range page.Value {
+			p := Person{
+				ID:       u.ID,
+				Name:     u.DisplayName,
+				Disabled: u.AccountEnabled != nil && !*u.AccountEnabled,
+			}
+			for _, e := range append([]string{u.Mail, u.UserPrincipalName}, u.OtherMails...) {
+				if strings.Contains(e, "@") {
+					p.Emails = append(p.Emails, e)
+				}
+			}
+			for _, addr := range u.ProxyAddresses {
+				if strings.HasPrefix(strings.ToLower(addr), "smtp:") {
+					p.Emails = append(p.Emails, addr[len("smtp:"):])
+				}
+			}
+			people = append(people, p)
+		}
+
+		next = page.NextLink
+	}
+
+	return people, nil
+}
+
+func getJSON(httpClient *http.Client, u string, result interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.Error.Message != "" {
+			return errors.New(resp.Status + ": " + body.Error.Message)
+		}
+		return errors.New(resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
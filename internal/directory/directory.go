@@ -0,0 +1,13 @@
+// Package directory lists the people in other systems, like a GitHub org, to
+// reconcile them with Buildkite members
+package directory
+
+// Person is someone in a directory, identified by any of their emails
+type Person struct {
+	// ID is the person's identifier in the directory, like a GitHub login
+	ID     string
+	Name   string
+	Emails []string
+	// Disabled is whether their account is disabled, like a blocked user
+	Disabled bool
+}
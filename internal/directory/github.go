@@ -0,0 +1,208 @@
+package directory
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultGitHubURL is the GraphQL endpoint of github.com
+const DefaultGitHubURL = "https://api.github.com/graphql"
+
+// GitHub lists the members of a GitHub organization
+type GitHub struct {
+	Org   string
+	Token string
+	// URL is the GraphQL endpoint, DefaultGitHubURL if empty
+	URL string
+
+	HTTPClient *http.Client
+}
+
+const githubMembersQuery = `query($org: String!, $after: String) {
+  organization(login: $org) {
+    membersWithRole(first: 100, after: $after) {
+      pageInfo { hasNextPage endCursor }
+      nodes { login name email organizationVerifiedDomainEmails(login: $org) }
+    }
+  }
+}`
+
+const githubIdentitiesQuery = `query($org: String!, $after: String) {
+  organization(login: $org) {
+    samlIdentityProvider {
+      externalIdentities(first: 100, after: $after) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          samlIdentity { nameId emails { value } }
+          user { login }
+        }
+      }
+    }
+  }
+}`
+
+type githubPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// Members returns the members of the org with their public email, emails on
+// the org's verified domains and the emails of their SAML identity, if the org
+// has SAML single sign-on
+func (g *GitHub) Members() ([]Person, error) {
+	var people []Person
+	index := map[string]int{}
+
+	var after *string
+	for {
+		var data struct {
+			Organization *struct {
+				MembersWithRole struct {
+					PageInfo githubPageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						Login                            string   `json:"login"`
+						Name                             string   `json:"name"`
+						Email                            string   `json:"email"`
+						OrganizationVerifiedDomainEmails []string `json:"organizationVerifiedDomainEmails"`
+					} `json:"nodes"`
+				} `json:"membersWithRole"`
+			} `json:"organization"`
+		}
+		if err := g.query(githubMembersQuery, after, &data); err != nil {
+			return nil, fmt.Errorf("failed to list github members: %w", err)
+		}
+		if data.Organization == nil {
+			return nil, fmt.Errorf("github organization %q not found", g.Org)
+		}
+
+		members := data.Organization.MembersWithRole
+		for _, n := range members.Nodes {
+			p := Person{ID: n.Login, Name: n.Name}
+			if n.Email != "" {
+				p.Emails = append(p.Emails, n.Email)
+			}
+			p.Emails = append(p.Emails, n.OrganizationVerifiedDomainEmails...)
+			index[strings.ToLower(n.Login)] = len(people)
+			people = append(people, p)
+		}
+
+		if !members.PageInfo.HasNextPage {
+			break
+		}
+		after = &members.PageInfo.EndCursor
+	}
+
+	after = nil
+	for {
+		var data struct {
+			Organization *struct {
+				SAMLIdentityProvider *struct {
+					ExternalIdentities struct {
+						PageInfo githubPageInfo `json:"pageInfo"`
+						Nodes    []struct {
+							SAMLIdentity *struct {
+								NameID string `json:"nameId"`
+								Emails []struct {
+									Value string `json:"value"`
+								} `json:"emails"`
+							} `json:"samlIdentity"`
+							User *struct {
+								Login string `json:"login"`
+							} `json:"user"`
+						} `json:"nodes"`
+					} `json:"externalIdentities"`
+				} `json:"samlIdentityProvider"`
+			} `json:"organization"`
+		}
+		if err := g.query(githubIdentitiesQuery, after, &data); err != nil {
+			return nil, fmt.Errorf("failed to list github saml identities: %w", err)
+		}
+		if data.Organization == nil || data.Organization.SAMLIdentityProvider == nil {
+			break
+		}
+
+		identities := data.Organization.SAMLIdentityProvider.ExternalIdentities
+		for _, n := range identities.Nodes {
+			if n.User == nil || n.SAMLIdentity == nil {
+				continue
+			}
+			i, ok := index[strings.ToLower(n.User.Login)]
+			if !ok {
+				continue
+			}
+			if strings.Contains(n.SAMLIdentity.NameID, "@") {
+				people[i].Emails = append(people[i].Emails, n.SAMLIdentity.NameID)
+			}
+			for _, e := range n.SAMLIdentity.Emails {
+				people[i].Emails = append(people[i].Emails, e.Value)
+			}
+		}
+
+		if !identities.PageInfo.HasNextPage {
+			break
+		}
+		after = &identities.PageInfo.EndCursor
+	}
+
+	return people, nil
+}
+
+func (g *GitHub) query(query string, after *string, result interface{}) error {
+	b, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": map[string]interface{}{"org": g.Org, "after": after},
+	})
+	if err != nil {
+		return err
+	}
+
+	url := g.URL
+	if url == "" {
+		url = DefaultGitHubURL
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+g.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := g.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return errors.New(resp.Status)
+	}
+
+	var body struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+	if len(body.Errors) > 0 {
+		messages := make([]string, 0, len(body.Errors))
+		for _, e := range body.Errors {
+			messages = append(messages, e.Message)
+		}
+		return errors.New(strings.Join(messages, ", "))
+	}
+
+	return json.Unmarshal(body.Data, result)
+}
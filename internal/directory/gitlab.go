@@ -0,0 +1,114 @@
+package directory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultGitLabURL is the URL of gitlab.com
+const DefaultGitLabURL = "https://gitlab.com"
+
+// GitLab lists the members of a GitLab group
+type GitLab struct {
+	// Group is the ID or full path of the group, like acme/engineering
+	Group string
+	Token string
+	// URL is the GitLab instance, DefaultGitLabURL if empty
+	URL string
+
+	HTTPClient *http.Client
+}
+
+// Members returns the active members of the group, including those inherited
+// from parent groups, with the emails GitLab shares with the token: their
+// email for administrators and enterprise users, their public email and the
+// extern UID of their group SAML identity, if it's an email
+func (g *GitLab) Members() ([]Person, error) {
+	base := g.URL
+	if base == "" {
+		base = DefaultGitLabURL
+	}
+
+	var people []Person
+	page := "1"
+	for page != "" {
+		u := fmt.Sprintf("%s/api/v4/groups/%s/members/all?per_page=100&page=%s",
+			strings.TrimSuffix(base, "/"), url.PathEscape(g.Group), page)
+
+		var members []struct {
+			Username          string `json:"username"`
+			Name              string `json:"name"`
+			State             string `json:"state"`
+			Email             string `json:"email"`
+			PublicEmail       string `json:"public_email"`
+			GroupSAMLIdentity *struct {
+				ExternUID string `json:"extern_uid"`
+			} `json:"group_saml_identity"`
+		}
+
+		next, err := g.get(u, &members)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list gitlab group members: %w", err)
+		}
+
+		for _, m := range members {
+			if m.State != "" && m.State != "active" {
+				continue
+			}
+			p := Person{ID: m.Username, Name: m.Name}
+			for _, e := range []string{m.Email, m.PublicEmail} {
+				if e != "" {
+					p.Emails = append(p.Emails, e)
+				}
+			}
+			if m.GroupSAMLIdentity != nil && strings.Contains(m.GroupSAMLIdentity.ExternUID, "@") {
+				p.Emails = append(p.Emails, m.GroupSAMLIdentity.ExternUID)
+			}
+			people = append(people, p)
+		}
+
+		page = next
+	}
+
+	return people, nil
+}
+
+// get decodes the response of a GET into result, returning the next page
+func (g *GitLab) get(u string, result interface{}) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("PRIVATE-TOKEN", g.Token)
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := g.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var body struct {
+			Message interface{} `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.Message != nil {
+			return "", fmt.Errorf("%s: %v", resp.Status, body.Message)
+		}
+		return "", errors.New(resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("X-Next-Page"), nil
+}
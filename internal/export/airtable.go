@@ -0,0 +1,109 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// airtableBatchSize is the most records Airtable accepts in a request
+const airtableBatchSize = 10
+
+// Airtable upserts records into a table of an Airtable base
+type Airtable struct {
+	Token string
+	// Base is the ID of the base, like appXXXXXXXXXXXXXX
+	Base string
+	// Table is the name or ID of the table
+	Table string
+
+	HTTPClient *http.Client
+	// BaseURL defaults to https://api.airtable.com
+	BaseURL string
+}
+
+// Upsert updates the records whose mergeOn fields match a record, and creates
+// the rest. Values are converted to the types of the table's fields
+func (a *Airtable) Upsert(records []map[string]interface{}, mergeOn []string) error {
+	for start := 0; start < len(records); start += airtableBatchSize {
+		end := start + airtableBatchSize
+		if end > len(records) {
+			end = len(records)
+		}
+
+		if start > 0 {
+			// stay under Airtable's limit of 5 requests a second
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		if err := a.upsertBatch(records[start:end], mergeOn); err != nil {
+			return fmt.Errorf("failed to upsert airtable records: %w", err)
+		}
+	}
+	return nil
+}
+
+func (a *Airtable) upsertBatch(records []map[string]interface{}, mergeOn []string) error {
+	type record struct {
+		Fields map[string]interface{} `json:"fields"`
+	}
+
+	payload := struct {
+		PerformUpsert struct {
+			FieldsToMergeOn []string `json:"fieldsToMergeOn"`
+		} `json:"performUpsert"`
+		Records  []record `json:"records"`
+		Typecast bool     `json:"typecast"`
+	}{Typecast: true}
+	payload.PerformUpsert.FieldsToMergeOn = mergeOn
+	for _, r := range records {
+		payload.Records = append(payload.Records, record{Fields: r})
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	baseURL := a.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.airtable.com"
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, baseURL+"/v0/"+url.PathEscape(a.Base)+"/"+url.PathEscape(a.Table), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+
+	httpClient := a.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var body struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.Error.Message != "" {
+			return errors.New(resp.Status + ": " + body.Error.Type + ": " + body.Error.Message)
+		}
+		return errors.New(resp.Status)
+	}
+
+	return nil
+}
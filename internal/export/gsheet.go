@@ -0,0 +1,181 @@
+// Package export writes tables of members to external services
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+// SheetsScope is the OAuth scope needed to write to Google Sheets
+const SheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+
+// GoogleSheet replaces the contents of a tab of a Google Sheet
+type GoogleSheet struct {
+	SpreadsheetID string
+	// Tab is the name of the tab, which is added if it doesn't exist
+	Tab string
+
+	// HTTPClient authorizes requests, see GoogleClient
+	HTTPClient *http.Client
+	// BaseURL defaults to https://sheets.googleapis.com
+	BaseURL string
+}
+
+// GoogleClient returns an http.Client authorized for Google Sheets with the
+// service account key in credentialsFile, or with application default
+// credentials like GOOGLE_APPLICATION_CREDENTIALS if it's empty
+func GoogleClient(ctx context.Context, credentialsFile string) (*http.Client, error) {
+	if credentialsFile == "" {
+		return google.DefaultClient(ctx, SheetsScope)
+	}
+
+	b, err := ioutil.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := google.JWTConfigFromJSON(b, SheetsScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account key %s: %w", credentialsFile, err)
+	}
+
+	return cfg.Client(ctx), nil
+}
+
+// Write replaces the contents of the tab with rows, the first being a header
+func (g *GoogleSheet) Write(rows [][]string) error {
+	exists, err := g.hasTab()
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		err := g.do(http.MethodPost, ":batchUpdate", nil, map[string]interface{}{
+			"requests": []interface{}{
+				map[string]interface{}{
+					"addSheet": map[string]interface{}{
+						"properties": map[string]interface{}{"title": g.Tab},
+					},
+				},
+			},
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to add tab %q: %w", g.Tab, err)
+		}
+	}
+
+	// quoted so tab names with spaces are a valid range
+	tabRange := "'" + strings.ReplaceAll(g.Tab, "'", "''") + "'"
+
+	if err := g.do(http.MethodPost, "/values/"+url.PathEscape(tabRange)+":clear", nil, struct{}{}, nil); err != nil {
+		return fmt.Errorf("failed to clear tab %q: %w", g.Tab, err)
+	}
+
+	err = g.do(http.MethodPut, "/values/"+url.PathEscape(tabRange), url.Values{"valueInputOption": {"RAW"}}, map[string]interface{}{
+		"range":          tabRange,
+		"majorDimension": "ROWS",
+		"values":         rows,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to write tab %q: %w", g.Tab, err)
+	}
+
+	return nil
+}
+
+// hasTab returns whether the spreadsheet has a tab named Tab
+func (g *GoogleSheet) hasTab() (bool, error) {
+	var spreadsheet struct {
+		Sheets []struct {
+			Properties struct {
+				Title string `json:"title"`
+			} `json:"properties"`
+		} `json:"sheets"`
+	}
+
+	err := g.do(http.MethodGet, "", url.Values{"fields": {"sheets.properties.title"}}, nil, &spreadsheet)
+	if err != nil {
+		return false, fmt.Errorf("failed to get spreadsheet %s: %w", g.SpreadsheetID, err)
+	}
+
+	for _, s := range spreadsheet.Sheets {
+		if s.Properties.Title == g.Tab {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// do makes a request to a path of the spreadsheet, decoding the response
+// into result if it's not nil
+func (g *GoogleSheet) do(method, path string, query url.Values, body, result interface{}) error {
+	baseURL := g.BaseURL
+	if baseURL == "" {
+		baseURL = "https://sheets.googleapis.com"
+	}
+
+	u := baseURL + "/v4/spreadsheets/" + url.PathEscape(g.SpreadsheetID) + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, u, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := g.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return errors.New(apiError(resp))
+	}
+
+	if result != nil {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+	return nil
+}
+
+// apiError describes an error response, including Google's error message if
+// there is one
+func apiError(resp *http.Response) string {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.Error.Message != "" {
+		return resp.Status + ": " + body.Error.Message
+	}
+	return resp.Status
+}
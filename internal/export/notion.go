@@ -0,0 +1,200 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// notionVersion is the version of the Notion API requests are made with
+const notionVersion = "2022-06-28"
+
+// Notion upserts pages into a Notion database
+type Notion struct {
+	Token string
+	// Database is the ID of the database
+	Database string
+	// KeyProperty is a text property that uniquely identifies each page
+	KeyProperty string
+
+	HTTPClient *http.Client
+	// BaseURL defaults to https://api.notion.com
+	BaseURL string
+}
+
+// NotionPage is a page of a database, identified by the value of its
+// KeyProperty. Properties are in the Notion API's format, see the Notion
+// property helpers like NotionText
+type NotionPage struct {
+	Key        string
+	Properties map[string]interface{}
+}
+
+// NotionTitle returns a title property value
+func NotionTitle(s string) interface{} {
+	return map[string]interface{}{"title": notionRichText(s)}
+}
+
+// NotionText returns a rich text property value
+func NotionText(s string) interface{} {
+	return map[string]interface{}{"rich_text": notionRichText(s)}
+}
+
+// NotionSelect returns a select property value, adding the option if needed
+func NotionSelect(s string) interface{} {
+	if s == "" {
+		return map[string]interface{}{"select": nil}
+	}
+	// commas aren't allowed in select options
+	return map[string]interface{}{"select": map[string]string{"name": strings.ReplaceAll(s, ",", " ")}}
+}
+
+// NotionDate returns a date property value, empty if t is nil
+func NotionDate(t *time.Time) interface{} {
+	if t == nil {
+		return map[string]interface{}{"date": nil}
+	}
+	return map[string]interface{}{"date": map[string]string{"start": t.UTC().Format(time.RFC3339)}}
+}
+
+// NotionEmail returns an email property value
+func NotionEmail(s string) interface{} {
+	return map[string]interface{}{"email": s}
+}
+
+func notionRichText(s string) []interface{} {
+	return []interface{}{map[string]interface{}{"text": map[string]string{"content": s}}}
+}
+
+// Upsert updates the pages of the database with the same key as a page, and
+// creates the rest
+func (n *Notion) Upsert(pages []NotionPage) error {
+	existing, err := n.pageIDs()
+	if err != nil {
+		return fmt.Errorf("failed to query notion database: %w", err)
+	}
+
+	for i, p := range pages {
+		props := map[string]interface{}{n.KeyProperty: NotionText(p.Key)}
+		for k, v := range p.Properties {
+			props[k] = v
+		}
+
+		if i > 0 {
+			// stay under Notion's average of 3 requests a second
+			time.Sleep(350 * time.Millisecond)
+		}
+
+		if id, ok := existing[p.Key]; ok {
+			err = n.do(http.MethodPatch, "/v1/pages/"+id, map[string]interface{}{"properties": props}, nil)
+		} else {
+			err = n.do(http.MethodPost, "/v1/pages", map[string]interface{}{
+				"parent":     map[string]string{"database_id": n.Database},
+				"properties": props,
+			}, nil)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to upsert notion page %s: %w", p.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// pageIDs returns the IDs of the pages in the database by their key
+func (n *Notion) pageIDs() (map[string]string, error) {
+	ids := map[string]string{}
+
+	var cursor string
+	for {
+		body := map[string]interface{}{"page_size": 100}
+		if cursor != "" {
+			body["start_cursor"] = cursor
+		}
+
+		var result struct {
+			Results []struct {
+				ID         string                     `json:"id"`
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"results"`
+			HasMore    bool   `json:"has_more"`
+			NextCursor string `json:"next_cursor"`
+		}
+
+		if err := n.do(http.MethodPost, "/v1/databases/"+n.Database+"/query", body, &result); err != nil {
+			return nil, err
+		}
+
+		for _, page := range result.Results {
+			var key struct {
+				RichText []struct {
+					PlainText string `json:"plain_text"`
+				} `json:"rich_text"`
+			}
+			if err := json.Unmarshal(page.Properties[n.KeyProperty], &key); err != nil || len(key.RichText) == 0 {
+				continue
+			}
+			var text string
+			for _, t := range key.RichText {
+				text += t.PlainText
+			}
+			ids[text] = page.ID
+		}
+
+		if !result.HasMore {
+			return ids, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+func (n *Notion) do(method, path string, body, result interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	baseURL := n.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.notion.com"
+	}
+
+	req, err := http.NewRequest(method, baseURL+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+n.Token)
+	req.Header.Set("Notion-Version", notionVersion)
+
+	httpClient := n.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var body struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.Message != "" {
+			return errors.New(resp.Status + ": " + body.Code + ": " + body.Message)
+		}
+		return errors.New(resp.Status)
+	}
+
+	if result != nil {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Package lock provides advisory file locks so that concurrent invocations
+// don't race on shared files
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrLocked is returned when a lock is held by another process
+var ErrLocked = errors.New("lock is held by another process")
+
+// Lock is a held advisory lock on a file
+type Lock struct {
+	f *os.File
+}
+
+// TryLock acquires an exclusive lock on path without blocking, returning an
+// error wrapping ErrLocked if another process holds it
+func TryLock(path string) (*Lock, error) {
+	return lock(path, false)
+}
+
+// Acquire acquires an exclusive lock on path, blocking until it's available
+func Acquire(path string) (*Lock, error) {
+	return lock(path, true)
+}
+
+func lock(path string, block bool) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(f, block); err != nil {
+		f.Close()
+		if errors.Is(err, ErrLocked) {
+			return nil, fmt.Errorf("%s: %w", path, ErrLocked)
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// record who holds the lock to help diagnose stuck runs
+	_ = f.Truncate(0)
+	_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+
+	return &Lock{f: f}, nil
+}
+
+// Release releases the lock
+func (l *Lock) Release() error {
+	if err := unlockFile(l.f); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// cloudWatchBatchSize is the number of metrics put per request
+const cloudWatchBatchSize = 500
+
+// CloudWatch publishes gauges to CloudWatch using the default AWS credential chain
+type CloudWatch struct {
+	Namespace string
+}
+
+// Publish implements Publisher
+func (c *CloudWatch) Publish(gauges []Gauge) error {
+	ctx := context.Background()
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %w", err)
+	}
+
+	client := cloudwatch.NewFromConfig(cfg)
+	now := time.Now()
+
+	var data []types.MetricDatum
+	for _, g := range gauges {
+		data = append(data, types.MetricDatum{
+			MetricName: aws.String(g.Name),
+			Value:      aws.Float64(g.Value),
+			Timestamp:  aws.Time(now),
+			Unit:       types.StandardUnitCount,
+			Dimensions: cloudWatchDimensions(g.Tags),
+		})
+	}
+
+	for len(data) > 0 {
+		n := cloudWatchBatchSize
+		if n > len(data) {
+			n = len(data)
+		}
+
+		_, err := client.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String(c.Namespace),
+			MetricData: data[:n],
+		})
+		if err != nil {
+			return fmt.Errorf("failed to put metrics to cloudwatch: %w", err)
+		}
+
+		data = data[n:]
+	}
+
+	return nil
+}
+
+func cloudWatchDimensions(tags map[string]string) []types.Dimension {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	dimensions := make([]types.Dimension, 0, len(keys))
+	for _, k := range keys {
+		dimensions = append(dimensions, types.Dimension{
+			Name:  aws.String(k),
+			Value: aws.String(tags[k]),
+		})
+	}
+	return dimensions
+}
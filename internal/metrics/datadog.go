@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Datadog publishes gauges to the Datadog metrics API
+type Datadog struct {
+	APIKey string
+	// Site is the Datadog site, e.g. datadoghq.com or datadoghq.eu
+	Site string
+	// Tags are added to every gauge
+	Tags []string
+
+	HTTPClient *http.Client
+}
+
+// Publish implements Publisher
+func (d *Datadog) Publish(gauges []Gauge) error {
+	type series struct {
+		Metric string       `json:"metric"`
+		Type   string       `json:"type"`
+		Points [][2]float64 `json:"points"`
+		Tags   []string     `json:"tags,omitempty"`
+	}
+
+	now := float64(time.Now().Unix())
+
+	var payload struct {
+		Series []series `json:"series"`
+	}
+
+	for _, g := range gauges {
+		payload.Series = append(payload.Series, series{
+			Metric: g.Name,
+			Type:   "gauge",
+			Points: [][2]float64{{now, g.Value}},
+			Tags:   append(append([]string{}, d.Tags...), g.SortedTags()...),
+		})
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	site := d.Site
+	if site == "" {
+		site = "datadoghq.com"
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "https://api."+site+"/api/v1/series", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", d.APIKey)
+
+	httpClient := d.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to submit metrics to datadog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to submit metrics to datadog: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+// Package metrics computes seat gauges from a report and publishes them to
+// metrics backends
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+// Gauge is a metric value with tags
+type Gauge struct {
+	Name  string
+	Value float64
+	Tags  map[string]string
+}
+
+// Publisher publishes gauges to a metrics backend
+type Publisher interface {
+	Publish(gauges []Gauge) error
+}
+
+// Gauges computes gauges for seats per org, role and domain, stale seats (no
+// SSO authorization within staleAfter) and duplicates
+func Gauges(r *report.Report, staleAfter time.Duration) []Gauge {
+	seats := counter{}
+	domains := counter{}
+	stale := counter{}
+	duplicates := counter{}
+
+	for _, m := range r.Members {
+		seats.add(map[string]string{"org": m.Org, "role": m.Role})
+		domains.add(map[string]string{"org": m.Org, "domain": m.Domain})
+
+		if m.IsStale(staleAfter) {
+			stale.add(map[string]string{"org": m.Org})
+		} else {
+			stale.zero(map[string]string{"org": m.Org})
+		}
+	}
+
+	for _, res := range r.Results {
+		tags := map[string]string{"org": res.Org}
+		if len(res.EmailDuplicates) > 0 || len(res.NameDuplicates) > 0 {
+			duplicates.add(tags)
+		} else {
+			duplicates.zero(tags)
+		}
+	}
+
+	var gauges []Gauge
+	gauges = append(gauges, seats.gauges("buildkite.seats")...)
+	gauges = append(gauges, domains.gauges("buildkite.seats.by_domain")...)
+	gauges = append(gauges, stale.gauges("buildkite.seats.stale")...)
+	gauges = append(gauges, duplicates.gauges("buildkite.duplicates")...)
+
+	return gauges
+}
+
+// counter counts occurrences of distinct tag sets
+type counter map[string]*Gauge
+
+func (c counter) add(tags map[string]string) {
+	c.gauge(tags).Value++
+}
+
+// zero ensures a gauge is reported for the tags even if nothing is counted
+func (c counter) zero(tags map[string]string) {
+	c.gauge(tags)
+}
+
+func (c counter) gauge(tags map[string]string) *Gauge {
+	key := tagKey(tags)
+	g, ok := c[key]
+	if !ok {
+		g = &Gauge{Tags: tags}
+		c[key] = g
+	}
+	return g
+}
+
+func (c counter) gauges(name string) []Gauge {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	gauges := make([]Gauge, 0, len(c))
+	for _, k := range keys {
+		g := *c[k]
+		g.Name = name
+		gauges = append(gauges, g)
+	}
+	return gauges
+}
+
+// SortedTags returns the gauge's tags as sorted key:value pairs
+func (g Gauge) SortedTags() []string {
+	tags := make([]string, 0, len(g.Tags))
+	for k, v := range g.Tags {
+		tags = append(tags, k+":"+v)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func tagKey(tags map[string]string) string {
+	var key string
+	for _, t := range (Gauge{Tags: tags}).SortedTags() {
+		key += t + ","
+	}
+	return key
+}
+
+// Run describes a run of the tool
+type Run struct {
+	Duration    time.Duration
+	APIRequests int
+	// Errors is the number of errors, a failed run or orgs that failed to load
+	Errors int
+}
+
+// Gauges returns gauges describing the run
+func (r Run) Gauges() []Gauge {
+	return []Gauge{
+		{Name: "buildkite.accounter.run.duration_seconds", Value: r.Duration.Seconds()},
+		{Name: "buildkite.accounter.run.api_requests", Value: float64(r.APIRequests)},
+		{Name: "buildkite.accounter.run.errors", Value: float64(r.Errors)},
+		{Name: "buildkite.accounter.run.timestamp_seconds", Value: float64(time.Now().Unix())},
+	}
+}
+
+// HTTPGauges returns gauges describing the API requests of a run
+func HTTPGauges(m buildkite.HTTPMetrics) []Gauge {
+	statuses := make([]string, 0, len(m.Requests))
+	for status := range m.Requests {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	var gauges []Gauge
+	for _, status := range statuses {
+		gauges = append(gauges, Gauge{
+			Name:  "buildkite.accounter.http.requests",
+			Value: float64(m.Requests[status]),
+			Tags:  map[string]string{"status": status},
+		})
+	}
+
+	for i, bound := range m.Latency.Buckets {
+		gauges = append(gauges, Gauge{
+			Name:  "buildkite.accounter.http.request_duration_seconds_bucket",
+			Value: float64(m.Latency.Counts[i]),
+			Tags:  map[string]string{"le": strconv.FormatFloat(bound.Seconds(), 'f', -1, 64)},
+		})
+	}
+
+	return append(gauges,
+		Gauge{Name: "buildkite.accounter.http.request_duration_seconds_bucket", Value: float64(m.Latency.Count), Tags: map[string]string{"le": "+Inf"}},
+		Gauge{Name: "buildkite.accounter.http.request_duration_seconds_sum", Value: m.Latency.Sum.Seconds()},
+		Gauge{Name: "buildkite.accounter.http.request_duration_seconds_count", Value: float64(m.Latency.Count)},
+		Gauge{Name: "buildkite.accounter.http.retries", Value: float64(m.Retries)},
+		Gauge{Name: "buildkite.accounter.http.bytes_sent", Value: float64(m.BytesSent)},
+		Gauge{Name: "buildkite.accounter.http.bytes_received", Value: float64(m.BytesReceived)},
+	)
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Pushgateway pushes gauges to a Prometheus Pushgateway, replacing any
+// previously pushed metrics for the job and instance
+type Pushgateway struct {
+	URL      string
+	Job      string
+	Instance string
+
+	HTTPClient *http.Client
+}
+
+// Publish implements Publisher
+func (p *Pushgateway) Publish(gauges []Gauge) error {
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, gauges); err != nil {
+		return err
+	}
+
+	job := p.Job
+	if job == "" {
+		job = "buildkite-accounter"
+	}
+
+	u := strings.TrimSuffix(p.URL, "/") + "/metrics/job/" + url.PathEscape(job)
+	if p.Instance != "" {
+		u += "/instance/" + url.PathEscape(p.Instance)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, u, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to push metrics: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// WritePrometheus writes gauges in the Prometheus text exposition format
+func WritePrometheus(w io.Writer, gauges []Gauge) error {
+	typed := map[string]bool{}
+
+	// the exposition format requires samples of a metric to be grouped
+	sorted := make([]Gauge, len(gauges))
+	copy(sorted, gauges)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	for _, g := range sorted {
+		name := prometheusName(g.Name)
+		if !typed[name] {
+			if _, err := fmt.Fprintf(w, "# TYPE %s gauge\n", name); err != nil {
+				return err
+			}
+			typed[name] = true
+		}
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", name, prometheusLabels(g.Tags),
+			strconv.FormatFloat(g.Value, 'f', -1, 64)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func prometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func prometheusLabels(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	labels := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(tags[k])
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, prometheusName(k), v))
+	}
+
+	return "{" + strings.Join(labels, ",") + "}"
+}
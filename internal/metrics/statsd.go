@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxStatsDPacket keeps packets under a typical MTU
+const maxStatsDPacket = 1432
+
+// StatsD publishes gauges over UDP to a StatsD or DogStatsD agent
+type StatsD struct {
+	Addr string
+	// DogStatsD sends tags in the DogStatsD format, otherwise tag values are
+	// appended to the metric name as plain StatsD has no tags
+	DogStatsD bool
+	// Prefix is prepended to every metric name
+	Prefix string
+}
+
+// Publish implements Publisher
+func (s *StatsD) Publish(gauges []Gauge) error {
+	conn, err := net.Dial("udp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to statsd: %w", err)
+	}
+	defer conn.Close()
+
+	var packet []byte
+
+	for _, g := range gauges {
+		line := s.format(g)
+
+		if len(packet) > 0 && len(packet)+len(line)+1 > maxStatsDPacket {
+			if _, err := conn.Write(packet); err != nil {
+				return fmt.Errorf("failed to send to statsd: %w", err)
+			}
+			packet = packet[:0]
+		}
+
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+
+	if len(packet) > 0 {
+		if _, err := conn.Write(packet); err != nil {
+			return fmt.Errorf("failed to send to statsd: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *StatsD) format(g Gauge) string {
+	name := s.Prefix + g.Name
+	value := strconv.FormatFloat(g.Value, 'f', -1, 64)
+
+	if s.DogStatsD {
+		line := name + ":" + value + "|g"
+		if tags := g.SortedTags(); len(tags) > 0 {
+			line += "|#" + strings.Join(tags, ",")
+		}
+		return line
+	}
+
+	keys := make([]string, 0, len(g.Tags))
+	for k := range g.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name += "." + statsdSanitize(g.Tags[k])
+	}
+
+	return name + ":" + value + "|g"
+}
+
+// statsdSanitize replaces characters with meaning in plain StatsD metric names
+func statsdSanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ':', '|', '@', ' ', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
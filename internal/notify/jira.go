@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Jira opens and updates issues in a Jira Cloud project
+type Jira struct {
+	// URL is the site, like https://acme.atlassian.net
+	URL string
+	// User and Token are the email and API token of the account
+	User  string
+	Token string
+
+	Project   string
+	IssueType string
+
+	HTTPClient *http.Client
+}
+
+// Ticket is an issue identified by a label, so it can be found again
+type Ticket struct {
+	Label       string
+	Summary     string
+	Description string
+}
+
+// Upsert updates the unresolved issue with the ticket's label, or opens a new
+// one if there isn't one, returning its key and whether it was created
+func (j *Jira) Upsert(t Ticket) (string, bool, error) {
+	jql := fmt.Sprintf("project = %q AND labels = %q AND statusCategory != Done ORDER BY created DESC", j.Project, t.Label)
+
+	var search struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	query := url.Values{"jql": {jql}, "fields": {"summary"}, "maxResults": {"1"}}
+	if err := j.do(http.MethodGet, "/rest/api/2/search/jql?"+query.Encode(), nil, &search); err != nil {
+		return "", false, fmt.Errorf("failed to search jira: %w", err)
+	}
+
+	fields := map[string]interface{}{
+		"summary":     t.Summary,
+		"description": t.Description,
+	}
+
+	if len(search.Issues) > 0 {
+		key := search.Issues[0].Key
+		if err := j.do(http.MethodPut, "/rest/api/2/issue/"+url.PathEscape(key), map[string]interface{}{"fields": fields}, nil); err != nil {
+			return "", false, fmt.Errorf("failed to update jira issue %s: %w", key, err)
+		}
+		return key, false, nil
+	}
+
+	fields["project"] = map[string]string{"key": j.Project}
+	fields["issuetype"] = map[string]string{"name": j.IssueType}
+	fields["labels"] = []string{"buildkite-accounter", t.Label}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := j.do(http.MethodPost, "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &created); err != nil {
+		return "", false, fmt.Errorf("failed to create jira issue: %w", err)
+	}
+	return created.Key, true, nil
+}
+
+func (j *Jira) do(method, path string, body, result interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(j.URL, "/")+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(j.User, j.Token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := j.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var body struct {
+			ErrorMessages []string          `json:"errorMessages"`
+			Errors        map[string]string `json:"errors"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err == nil {
+			messages := body.ErrorMessages
+			for field, message := range body.Errors {
+				messages = append(messages, field+": "+message)
+			}
+			if len(messages) > 0 {
+				return errors.New(resp.Status + ": " + strings.Join(messages, ", "))
+			}
+		}
+		return errors.New(resp.Status)
+	}
+
+	if result != nil && resp.StatusCode != http.StatusNoContent {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// PagerDutyEventsURL is the PagerDuty Events API v2 endpoint
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty triggers and resolves alerts with the PagerDuty Events API
+type PagerDuty struct {
+	// RoutingKey is the integration key of a service
+	RoutingKey string
+
+	HTTPClient *http.Client
+	// URL defaults to PagerDutyEventsURL
+	URL string
+}
+
+// Alert is a PagerDuty alert, deduplicated by DedupKey
+type Alert struct {
+	DedupKey string
+	Summary  string
+	// Severity is critical, error, warning or info
+	Severity string
+	Source   string
+	Details  map[string]interface{}
+}
+
+// Trigger triggers an alert, or updates it if it's already open
+func (p *PagerDuty) Trigger(a Alert) error {
+	return p.send(map[string]interface{}{
+		"routing_key":  p.RoutingKey,
+		"event_action": "trigger",
+		"dedup_key":    a.DedupKey,
+		"payload": map[string]interface{}{
+			"summary":        a.Summary,
+			"source":         a.Source,
+			"severity":       a.Severity,
+			"custom_details": a.Details,
+		},
+	})
+}
+
+// Resolve resolves the open alert with a dedup key, if there is one
+func (p *PagerDuty) Resolve(dedupKey string) error {
+	return p.send(map[string]interface{}{
+		"routing_key":  p.RoutingKey,
+		"event_action": "resolve",
+		"dedup_key":    dedupKey,
+	})
+}
+
+func (p *PagerDuty) send(event map[string]interface{}) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	u := p.URL
+	if u == "" {
+		u = PagerDutyEventsURL
+	}
+
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Post(u, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to send pagerduty event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var body struct {
+			Message string   `json:"message"`
+			Errors  []string `json:"errors"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && len(body.Errors) > 0 {
+			return fmt.Errorf("failed to send pagerduty event: %s: %s: %v", resp.Status, body.Message, body.Errors)
+		}
+		return fmt.Errorf("failed to send pagerduty event: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// teamsMaxMembers is the most members listed for each type of event, so
+// cards stay under the size limit of Teams webhooks
+const teamsMaxMembers = 20
+
+// Teams posts payloads to a Microsoft Teams incoming webhook as an adaptive
+// card summarizing the members added, removed, changed and stale
+type Teams struct {
+	URL string
+
+	HTTPClient *http.Client
+}
+
+// Send posts the payload to the webhook as an adaptive card
+func (t *Teams) Send(p Payload) error {
+	b, err := json.Marshal(map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content":     teamsCard(p),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	httpClient := t.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Post(t.URL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to notify teams: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to notify teams: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// teamsCard returns an adaptive card with a section per type of event
+func teamsCard(p Payload) map[string]interface{} {
+	sections := []struct {
+		title  string
+		events []string
+	}{
+		{title: "Added", events: []string{EventAdded}},
+		{title: "Removed", events: []string{EventRemoved}},
+		{title: "Changed", events: []string{EventRoleChanged, EventChanged}},
+		{title: "Stale", events: []string{EventStale}},
+	}
+
+	var facts, lists []interface{}
+	for _, s := range sections {
+		var lines []string
+		for _, e := range p.Events {
+			if containsString(s.events, e.Type) {
+				lines = append(lines, teamsLine(e))
+			}
+		}
+		facts = append(facts, map[string]string{"title": s.title, "value": fmt.Sprint(len(lines))})
+
+		if len(lines) == 0 {
+			continue
+		}
+		if len(lines) > teamsMaxMembers {
+			lines = append(lines[:teamsMaxMembers], fmt.Sprintf("- and %d more", len(lines)-teamsMaxMembers))
+		}
+		lists = append(lists,
+			map[string]interface{}{
+				"type":      "TextBlock",
+				"text":      s.title,
+				"weight":    "Bolder",
+				"separator": true,
+			},
+			map[string]interface{}{
+				"type": "TextBlock",
+				"text": strings.Join(lines, "\n"),
+				"wrap": true,
+			},
+		)
+	}
+
+	body := []interface{}{
+		map[string]interface{}{
+			"type":   "TextBlock",
+			"text":   "Buildkite members changed",
+			"size":   "Large",
+			"weight": "Bolder",
+		},
+		map[string]interface{}{
+			"type":     "TextBlock",
+			"text":     fmt.Sprintf("%s in %s", p.Generated.Format("2006-01-02 15:04 MST"), strings.Join(p.Orgs, ", ")),
+			"isSubtle": true,
+			"wrap":     true,
+		},
+		map[string]interface{}{
+			"type":  "FactSet",
+			"facts": facts,
+		},
+	}
+
+	return map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    append(body, lists...),
+	}
+}
+
+// teamsLine describes an event as a markdown list item
+func teamsLine(e Event) string {
+	m := e.Member
+	line := fmt.Sprintf("- %s (%s, %s)", m.Email, m.Org, m.Role)
+	if e.Previous != nil && len(e.Fields) > 0 {
+		line += ", changed " + strings.Join(e.Fields, ", ")
+	}
+	return line
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
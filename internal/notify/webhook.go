@@ -0,0 +1,141 @@
+// Package notify sends notifications about membership changes between runs
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+// Event types
+const (
+	EventAdded       = "added"
+	EventRemoved     = "removed"
+	EventRoleChanged = "role_changed"
+	EventChanged     = "changed"
+	EventStale       = "stale"
+)
+
+// Event is a change to a member since the last run
+type Event struct {
+	Type   string        `json:"type"`
+	Member report.Member `json:"member"`
+	// Previous is the member as of the last run, for changes
+	Previous *report.Member `json:"previous,omitempty"`
+	// Fields are the names of the fields that changed, for changes
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Payload is the body posted to a webhook
+type Payload struct {
+	Generated time.Time `json:"generated"`
+	Orgs      []string  `json:"orgs"`
+	Events    []Event   `json:"events"`
+}
+
+// Events returns an event for each change, and for each member that became
+// stale since the last run. A change that includes the role is a
+// role_changed event.
+func Events(changes *report.Changes, stale []report.Member) []Event {
+	events := []Event{}
+	if changes != nil {
+		for _, m := range changes.Added {
+			events = append(events, Event{Type: EventAdded, Member: m})
+		}
+		for _, m := range changes.Removed {
+			events = append(events, Event{Type: EventRemoved, Member: m})
+		}
+		for _, c := range changes.Changed {
+			before := c.Before
+			e := Event{Type: EventChanged, Member: c.After, Previous: &before, Fields: c.Fields}
+			for _, f := range c.Fields {
+				if f == "role" {
+					e.Type = EventRoleChanged
+				}
+			}
+			events = append(events, e)
+		}
+	}
+	for _, m := range stale {
+		events = append(events, Event{Type: EventStale, Member: m})
+	}
+	return events
+}
+
+// FilterEvents returns the events of the given types
+func FilterEvents(events []Event, types []string) []Event {
+	filtered := []Event{}
+	for _, e := range events {
+		if containsString(types, e.Type) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// SignatureHeader carries the HMAC-SHA256 signature of a payload, like
+// timestamp=1642389123,signature=<hex>. The signature is of the timestamp, a
+// period and the body, so receivers can reject replayed requests.
+const SignatureHeader = "X-Buildkite-Accounter-Signature"
+
+// Webhook posts payloads as JSON to a URL, signed if Secret is set
+type Webhook struct {
+	URL    string
+	Secret string
+
+	HTTPClient *http.Client
+}
+
+// Send posts the payload to the webhook
+func (w *Webhook) Send(p Payload) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if w.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.Secret, time.Now(), b))
+	}
+
+	httpClient := w.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to notify webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to notify webhook: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Sign returns the signature header value for a body sent at t
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+
+	return "timestamp=" + ts + ",signature=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,191 @@
+// Package proxy provides a caching proxy in front of the Buildkite GraphQL API
+// for the queries this tool issues, so that many runs share one cache and one
+// rate limit budget
+package proxy
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+)
+
+// maxRequestSize limits the size of proxied request bodies
+const maxRequestSize = 1 << 20
+
+// Proxy is an http.Handler that forwards allowed GraphQL queries with its own
+// client and caches successful responses
+type Proxy struct {
+	Client *buildkite.Client
+	TTL    time.Duration
+	// AuthToken is the bearer token required of callers, if set
+	AuthToken string
+	// Queries are the queries the proxy will forward, along with batches of
+	// members
+	Queries []string
+	Logf    func(format string, v ...interface{})
+
+	mu       sync.Mutex
+	entries  map[[sha256.Size]byte]*entry
+	inflight map[[sha256.Size]byte]*sync.WaitGroup
+}
+
+type entry struct {
+	body    []byte
+	expires time.Time
+}
+
+// New returns a Proxy that forwards the queries used to list orgs, check they
+// exist and fetch their members
+func New(client *buildkite.Client, ttl time.Duration) *Proxy {
+	return &Proxy{
+		Client:  client,
+		TTL:     ttl,
+		Queries: buildkite.MembersQueries(),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if p.AuthToken != "" {
+		expected := []byte("Bearer " + p.AuthToken)
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request")
+		return
+	}
+
+	var req struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	if !p.allowed(req.Query) {
+		writeError(w, http.StatusForbidden, "query not supported by proxy")
+		return
+	}
+
+	// the key is the query and variables, re-marshaled so formatting doesn't matter
+	normalized, _ := json.Marshal(req)
+	key := sha256.Sum256(normalized)
+
+	respBody, status, cached := p.fetch(key, req.Query, req.Variables)
+	if p.Logf != nil {
+		p.Logf("Proxied %v (cached=%v, status=%d)", req.Variables, cached, status)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if cached {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(respBody)
+}
+
+func (p *Proxy) allowed(query string) bool {
+	if buildkite.IsOrgMembersBatchQuery(query) {
+		return true
+	}
+	for _, q := range p.Queries {
+		if strings.TrimSpace(q) == strings.TrimSpace(query) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch returns a cached response or forwards the query upstream, coalescing
+// concurrent requests for the same key into a single upstream request
+func (p *Proxy) fetch(key [sha256.Size]byte, query string, vars map[string]interface{}) ([]byte, int, bool) {
+	for {
+		p.mu.Lock()
+		if p.entries == nil {
+			p.entries = make(map[[sha256.Size]byte]*entry)
+			p.inflight = make(map[[sha256.Size]byte]*sync.WaitGroup)
+		}
+		if e, ok := p.entries[key]; ok && time.Now().Before(e.expires) {
+			p.mu.Unlock()
+			return e.body, http.StatusOK, true
+		}
+		if wg, ok := p.inflight[key]; ok {
+			p.mu.Unlock()
+			wg.Wait()
+			continue
+		}
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		p.inflight[key] = wg
+		p.mu.Unlock()
+
+		body, status, err := p.forward(query, vars)
+
+		p.mu.Lock()
+		if err == nil {
+			p.entries[key] = &entry{body: body, expires: time.Now().Add(p.TTL)}
+		}
+		for k, e := range p.entries {
+			if time.Now().After(e.expires) {
+				delete(p.entries, k)
+			}
+		}
+		delete(p.inflight, key)
+		wg.Done()
+		p.mu.Unlock()
+
+		return body, status, false
+	}
+}
+
+func (p *Proxy) forward(query string, vars map[string]interface{}) ([]byte, int, error) {
+	resp, err := p.Client.Do(query, vars)
+	if resp == nil {
+		b, _ := json.Marshal(errorBody(err.Error()))
+		return b, http.StatusBadGateway, err
+	}
+
+	body, rerr := ioutil.ReadAll(resp.Body)
+	if rerr != nil {
+		b, _ := json.Marshal(errorBody(rerr.Error()))
+		return b, http.StatusBadGateway, rerr
+	}
+
+	// graphql errors are passed through but not cached
+	return body, resp.StatusCode, err
+}
+
+func errorBody(message string) interface{} {
+	return map[string]interface{}{
+		"errors": []interface{}{
+			map[string]interface{}{"message": message},
+		},
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorBody(message))
+}
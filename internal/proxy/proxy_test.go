@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/buildkite/buildkitetest"
+)
+
+// proxiedClient returns a client whose requests go through a proxy in front of
+// a fake server with two orgs
+func proxiedClient(t *testing.T) (*buildkite.Client, *buildkitetest.Server) {
+	s := buildkitetest.NewServer()
+	t.Cleanup(s.Close)
+	s.PageSize = 2
+	s.AddOrg("acme",
+		buildkite.OrgMember{ID: "u1", Email: "alice@acme.com", Name: "Alice", Role: "ADMIN"},
+		buildkite.OrgMember{ID: "u2", Email: "bob@acme.com", Name: "Bob", Role: "MEMBER"},
+		buildkite.OrgMember{ID: "u3", Email: "carol@acme.com", Name: "Carol", Role: "MEMBER"},
+	)
+	s.AddOrg("llama",
+		buildkite.OrgMember{ID: "u1", Email: "alice@acme.com", Name: "Alice", Role: "MEMBER"},
+	)
+
+	upstream, err := s.Client("test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(New(upstream, time.Hour))
+	t.Cleanup(srv.Close)
+
+	client, err := buildkite.NewClient("test-token", buildkite.WithEndpoint(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, s
+}
+
+func TestProxyForwardsClientQueries(t *testing.T) {
+	client, s := proxiedClient(t)
+
+	orgs, err := client.GetOrganizations()
+	if err != nil {
+		t.Fatalf("GetOrganizations() error = %v", err)
+	}
+	if len(orgs) != 2 {
+		t.Errorf("got %d orgs, want 2", len(orgs))
+	}
+
+	if _, err := client.GetOrganization("acme"); err != nil {
+		t.Fatalf("GetOrganization() error = %v", err)
+	}
+
+	// acme spans two pages, so it's fetched by a batch and then a page
+	client.BatchOrgMembers([]string{"acme", "llama"}, 2)
+	before := s.Requests()
+	for org, want := range map[string]int{"acme": 3, "llama": 1} {
+		members, err := client.GetOrgMembers(org)
+		if err != nil {
+			t.Fatalf("GetOrgMembers(%q) error = %v", org, err)
+		}
+		if len(members) != want {
+			t.Errorf("got %d %s members, want %d", len(members), org, want)
+		}
+	}
+	if n := s.Requests() - before; n != 2 {
+		t.Errorf("got %d upstream requests for members, want a batch and a page", n)
+	}
+
+	// responses are cached, once the first page has been fetched on its own
+	if _, err := client.GetOrgMembers("acme"); err != nil {
+		t.Fatalf("GetOrgMembers() error = %v", err)
+	}
+	before = s.Requests()
+	if _, err := client.GetOrgMembers("acme"); err != nil {
+		t.Fatalf("GetOrgMembers() error = %v", err)
+	}
+	if n := s.Requests() - before; n != 0 {
+		t.Errorf("got %d upstream requests for cached members, want none", n)
+	}
+}
+
+func TestProxyRefusesOtherQueries(t *testing.T) {
+	client, s := proxiedClient(t)
+
+	before := s.Requests()
+	if _, err := client.RemoveOrgMember("T3JnYW5pemF0aW9uTWVtYmVyLS0tdTI="); !errors.Is(err, buildkite.ErrUnauthorized) {
+		t.Fatalf("RemoveOrgMember() error = %v, want %v", err, buildkite.ErrUnauthorized)
+	}
+	if _, err := client.GetTeams("acme"); !errors.Is(err, buildkite.ErrUnauthorized) {
+		t.Fatalf("GetTeams() error = %v, want %v", err, buildkite.ErrUnauthorized)
+	}
+	if n := s.Requests() - before; n != 0 {
+		t.Errorf("got %d upstream requests, want none", n)
+	}
+}
+
+func TestIsOrgMembersBatchQuery(t *testing.T) {
+	if buildkite.IsOrgMembersBatchQuery("query OrgMembersBatch ($org0: ID!) {\n\torg0: organization(slug: $org0) {\n\t\tid\n\t}\n}") {
+		t.Error("a query named OrgMembersBatch with other fields is allowed")
+	}
+	if buildkite.IsOrgMembersBatchQuery("mutation OrgMembersBatch { organizationMemberDelete(input: {id: \"x\"}) { clientMutationId } }") {
+		t.Error("a mutation named OrgMembersBatch is allowed")
+	}
+}
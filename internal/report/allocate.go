@@ -0,0 +1,106 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Ways of allocating the seat of a member with several values, like teams
+const (
+	// AllocateSplit splits the seat evenly between the values
+	AllocateSplit = "split"
+	// AllocateFirst allocates the seat to the first value
+	AllocateFirst = "first"
+	// AllocateEach allocates the whole seat to each value, so the allocations
+	// add up to more than the total
+	AllocateEach = "each"
+)
+
+// Unallocated is the group of seats whose members have no value to allocate by
+const Unallocated = "(unallocated)"
+
+// AllocateOptions controls how Allocate splits seats between groups
+type AllocateOptions struct {
+	// By is the member field to group by, by its JSON name like domain or
+	// enrichment.team
+	By string
+	// Separator splits a field into several values, none if empty
+	Separator string
+	// Multi is how seats with several values are allocated, AllocateSplit if empty
+	Multi string
+	// SeatPrice is the price of a seat for the period being allocated
+	SeatPrice float64
+}
+
+// Allocation is the share of seats and their cost charged to a group
+type Allocation struct {
+	Group string  `json:"group"`
+	Seats float64 `json:"seats"`
+	Cost  float64 `json:"cost"`
+}
+
+// Allocate splits the cost of the members' seats between the values of a
+// field. Each member is a seat in their org, and complimentary seats cost
+// nothing so are skipped. Allocations are sorted by cost, highest first
+func Allocate(members []Member, opts AllocateOptions) ([]Allocation, error) {
+	seats := map[string]float64{}
+
+	for _, m := range members {
+		if m.Complimentary {
+			continue
+		}
+
+		v, err := memberField(opts.By, m)
+		if err != nil {
+			return nil, err
+		}
+
+		values := []string{v}
+		if opts.Separator != "" {
+			values = strings.Split(v, opts.Separator)
+		}
+
+		var groups []string
+		seen := map[string]bool{}
+		for _, v := range values {
+			v = strings.TrimSpace(v)
+			if v != "" && !seen[v] {
+				seen[v] = true
+				groups = append(groups, v)
+			}
+		}
+		if len(groups) == 0 {
+			groups = []string{Unallocated}
+		}
+
+		switch opts.Multi {
+		case AllocateSplit, "":
+			for _, g := range groups {
+				seats[g] += 1 / float64(len(groups))
+			}
+		case AllocateFirst:
+			seats[groups[0]]++
+		case AllocateEach:
+			for _, g := range groups {
+				seats[g]++
+			}
+		default:
+			return nil, fmt.Errorf("unknown way of allocating seats %q", opts.Multi)
+		}
+	}
+
+	allocations := make([]Allocation, 0, len(seats))
+	for g, n := range seats {
+		allocations = append(allocations, Allocation{Group: g, Seats: n, Cost: n * opts.SeatPrice})
+	}
+
+	sort.Slice(allocations, func(i, j int) bool {
+		if allocations[i].Seats != allocations[j].Seats {
+			return allocations[i].Seats > allocations[j].Seats
+		}
+		return allocations[i].Group < allocations[j].Group
+	})
+
+	return allocations, nil
+}
@@ -0,0 +1,163 @@
+package report
+
+import (
+	"sort"
+	"time"
+)
+
+// Changes are the differences in members between two runs
+type Changes struct {
+	Added   []Member       `json:"added"`
+	Removed []Member       `json:"removed"`
+	Changed []MemberChange `json:"changed"`
+}
+
+// MemberChange is a member whose details changed between runs
+type MemberChange struct {
+	Before Member `json:"before"`
+	After  Member `json:"after"`
+	// Fields are the names of the fields that changed, like role
+	Fields []string `json:"fields"`
+}
+
+// Empty returns whether there were no changes
+func (c *Changes) Empty() bool {
+	return c == nil || len(c.Added)+len(c.Removed)+len(c.Changed) == 0
+}
+
+// Diff compares members by org and ID, ignoring fields like last_auth that
+// change on every run
+func Diff(before, after []Member) *Changes {
+	changes := &Changes{
+		Added:   []Member{},
+		Removed: []Member{},
+		Changed: []MemberChange{},
+	}
+
+	previous := make(map[string]Member, len(before))
+	for _, m := range before {
+		previous[m.Org+"/"+m.ID] = m
+	}
+
+	current := make(map[string]bool, len(after))
+	for _, m := range after {
+		key := m.Org + "/" + m.ID
+		current[key] = true
+
+		p, ok := previous[key]
+		if !ok {
+			changes.Added = append(changes.Added, m)
+			continue
+		}
+
+		if fields := changedFields(p, m); len(fields) > 0 {
+			changes.Changed = append(changes.Changed, MemberChange{Before: p, After: m, Fields: fields})
+		}
+	}
+
+	for _, m := range before {
+		if !current[m.Org+"/"+m.ID] {
+			changes.Removed = append(changes.Removed, m)
+		}
+	}
+
+	sortMembers(changes.Added)
+	sortMembers(changes.Removed)
+	sort.SliceStable(changes.Changed, func(i, j int) bool {
+		return memberLess(changes.Changed[i].After, changes.Changed[j].After)
+	})
+
+	return changes
+}
+
+func changedFields(a, b Member) []string {
+	var fields []string
+	if a.Email != b.Email {
+		fields = append(fields, "email")
+	}
+	if a.Name != b.Name {
+		fields = append(fields, "name")
+	}
+	if a.Role != b.Role {
+		fields = append(fields, "role")
+	}
+	if a.Complimentary != b.Complimentary {
+		fields = append(fields, "complimentary")
+	}
+	return fields
+}
+
+func sortMembers(members []Member) {
+	sort.SliceStable(members, func(i, j int) bool {
+		return memberLess(members[i], members[j])
+	})
+}
+
+func memberLess(a, b Member) bool {
+	if a.Org != b.Org {
+		return a.Org < b.Org
+	}
+	return a.Email < b.Email
+}
+
+// BecameStale returns the members of after that are stale now, but weren't
+// stale in before as of the time it was loaded. Members that are new in after
+// are left to Diff.
+func BecameStale(before []Member, loaded time.Time, after []Member, staleAfter time.Duration) []Member {
+	previous := make(map[string]Member, len(before))
+	for _, m := range before {
+		previous[m.Org+"/"+m.ID] = m
+	}
+
+	stale := []Member{}
+	for _, m := range after {
+		p, ok := previous[m.Org+"/"+m.ID]
+		if ok && m.IsStale(staleAfter) && !p.staleAt(loaded, staleAfter) {
+			stale = append(stale, m)
+		}
+	}
+
+	sortMembers(stale)
+	return stale
+}
+
+// RoleChange is a member whose role changed between runs
+type RoleChange struct {
+	Org   string `json:"org"`
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	// Promotion is whether the member became an admin
+	Promotion bool `json:"promotion"`
+}
+
+// RoleChanges returns the changes in members' roles, promotions to admin first
+func RoleChanges(changes *Changes) []RoleChange {
+	result := []RoleChange{}
+	if changes == nil {
+		return result
+	}
+
+	for _, c := range changes.Changed {
+		if c.Before.Role == c.After.Role {
+			continue
+		}
+		result = append(result, RoleChange{
+			Org:       c.After.Org,
+			ID:        c.After.ID,
+			Email:     c.After.Email,
+			Name:      c.After.Name,
+			From:      c.Before.Role,
+			To:        c.After.Role,
+			Promotion: c.After.Role == "admin",
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Promotion && !result[j].Promotion
+	})
+
+	return result
+}
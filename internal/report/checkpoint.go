@@ -0,0 +1,72 @@
+package report
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+)
+
+// PagesFunc fetches the members of an org a page at a time starting after a
+// cursor, like buildkite.Client.GetOrgMembersPages
+type PagesFunc func(orgSlug string, after string, f func(members []buildkite.OrgMember, next string) error) error
+
+// checkpoint is the progress of fetching an org, saved after each page
+type checkpoint struct {
+	After   string                `json:"after"`
+	Members []buildkite.OrgMember `json:"members"`
+}
+
+// CheckpointedFetch returns a FetchFunc that saves the members fetched so far
+// and the cursor of the next page to dir after each page, removing it once the
+// org is complete. If resume is true, fetching continues from any saved checkpoint.
+func CheckpointedFetch(dir string, pages PagesFunc, resume bool, logf Logf) (FetchFunc, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return func(orgSlug string) ([]buildkite.OrgMember, error) {
+		path := CacheFile(dir, orgSlug)
+
+		var cp checkpoint
+
+		if resume {
+			b, err := ioutil.ReadFile(path)
+			if err == nil {
+				if err := json.Unmarshal(b, &cp); err != nil {
+					return nil, err
+				}
+				if logf != nil {
+					logf("Resuming %s from checkpoint with %d members", orgSlug, len(cp.Members))
+				}
+			} else if !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+
+		err := pages(orgSlug, cp.After, func(members []buildkite.OrgMember, next string) error {
+			cp.Members = append(cp.Members, members...)
+			cp.After = next
+
+			if next == "" {
+				return nil
+			}
+
+			b, err := json.Marshal(cp)
+			if err != nil {
+				return err
+			}
+			return writeFileAtomic(path, b)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		return cp.Members, nil
+	}, nil
+}
@@ -0,0 +1,100 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DedupeOptions controls which duplicates are removed by Dedupe
+type DedupeOptions struct {
+	Email bool
+	Name  bool
+	// Explain annotates each remaining result with the members it absorbed
+	Explain bool
+}
+
+// Rules that link an absorbed member to the result that absorbed it
+const (
+	RuleUserID     = "user-id"
+	RuleEmailExact = "email-exact"
+	RuleNameExact  = "name-exact"
+	RuleNameFuzzy  = "name-fuzzy"
+)
+
+// Absorbed is a member that was deduped into a result
+type Absorbed struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Org   string `json:"org"`
+	// Rule is how the member matched, e.g. email-exact
+	Rule string `json:"rule"`
+}
+
+// Dedupe removes results that are duplicates of an earlier result
+func Dedupe(results []MemberWithDuplicates, opts DedupeOptions) []MemberWithDuplicates {
+	if !opts.Email && !opts.Name {
+		return results
+	}
+
+	dupeResults := []MemberWithDuplicates{}
+	seenMembers := make(map[string]bool)
+	// absorbed members by id and org, so each is only explained once
+	absorbed := make(map[string]bool)
+
+	for _, r := range results {
+		if _, ok := seenMembers[r.ID]; ok {
+			continue
+		}
+		seenMembers[r.ID] = true
+
+		absorb := func(m Member, rule string) {
+			seenMembers[m.ID] = true
+			key := m.ID + "/" + m.Org
+			if !opts.Explain || absorbed[key] || (m.ID == r.ID && m.Org == r.Org) {
+				return
+			}
+			absorbed[key] = true
+			r.Absorbed = append(r.Absorbed, Absorbed{ID: m.ID, Email: m.Email, Org: m.Org, Rule: rule})
+		}
+
+		if opts.Email {
+			for _, rr := range r.EmailDuplicates {
+				rule := RuleEmailExact
+				if rr.ID == r.ID {
+					rule = RuleUserID
+				}
+				absorb(rr, rule)
+			}
+		}
+		if opts.Name {
+			for _, rr := range r.NameDuplicates {
+				rule := RuleNameExact
+				if rr.Similarity < 1 {
+					rule = RuleNameFuzzy
+				}
+				absorb(rr.Member, rule)
+			}
+		}
+
+		dupeResults = append(dupeResults, r)
+	}
+
+	return dupeResults
+}
+
+// ExplainDedupe describes what each result absorbed, one line per result
+// like "kept a@x.com (org), absorbing b@x.com (org, email-exact)"
+func ExplainDedupe(results []MemberWithDuplicates) []string {
+	var lines []string
+	for _, r := range results {
+		if len(r.Absorbed) == 0 {
+			continue
+		}
+		absorbed := make([]string, 0, len(r.Absorbed))
+		for _, a := range r.Absorbed {
+			absorbed = append(absorbed, fmt.Sprintf("%s (%s, %s)", a.Email, a.Org, a.Rule))
+		}
+		lines = append(lines, fmt.Sprintf("kept %s (%s), absorbing %s", r.Email, r.Org, strings.Join(absorbed, ", ")))
+	}
+	return lines
+}
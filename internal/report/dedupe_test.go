@@ -0,0 +1,92 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestDedupe(t *testing.T) {
+	members := []Member{
+		testMember("acme", "alice@acme.com", "Alice Smith"),
+		testMember("llama", "alice@acme.com", "Alice Smith"),
+		testMember("acme", "asmith@acme.com", "ALICE SMITH"),
+		testMember("acme", "jon@acme.com", "Jon Jones"),
+		testMember("acme", "john@acme.com", "John Jones"),
+		testMember("acme", "ilgin@acme.com", "Ilgın Yılmaz"),
+		testMember("acme", "ilgin.yilmaz@acme.com", "ILGIN YILMAZ"),
+	}
+
+	tests := []struct {
+		name  string
+		group GroupOptions
+		opts  DedupeOptions
+		want  []string
+	}{
+		{
+			name: "none",
+			want: []string{"alice@acme.com", "alice@acme.com", "asmith@acme.com", "ilgin.yilmaz@acme.com", "ilgin@acme.com", "john@acme.com", "jon@acme.com"},
+		},
+		{
+			name: "email",
+			opts: DedupeOptions{Email: true},
+			want: []string{"alice@acme.com", "asmith@acme.com", "ilgin.yilmaz@acme.com", "ilgin@acme.com", "john@acme.com", "jon@acme.com"},
+		},
+		{
+			name: "name",
+			opts: DedupeOptions{Name: true},
+			want: []string{"alice@acme.com", "ilgin.yilmaz@acme.com", "ilgin@acme.com", "john@acme.com", "jon@acme.com"},
+		},
+		{
+			name:  "name similarity",
+			group: GroupOptions{NameSimilarity: 0.9},
+			opts:  DedupeOptions{Name: true},
+			want:  []string{"alice@acme.com", "ilgin.yilmaz@acme.com", "ilgin@acme.com", "john@acme.com"},
+		},
+		{
+			name:  "name similarity below the threshold",
+			group: GroupOptions{NameSimilarity: 0.95},
+			opts:  DedupeOptions{Name: true},
+			want:  []string{"alice@acme.com", "ilgin.yilmaz@acme.com", "ilgin@acme.com", "john@acme.com", "jon@acme.com"},
+		},
+		{
+			// in Turkish, I lowercases to a dotless ı, so ILGIN is Ilgın but
+			// ALICE isn't Alice
+			name:  "name locale",
+			group: GroupOptions{Locale: language.Turkish},
+			opts:  DedupeOptions{Name: true},
+			want:  []string{"alice@acme.com", "asmith@acme.com", "ilgin.yilmaz@acme.com", "john@acme.com", "jon@acme.com"},
+		},
+		{
+			name:  "email and name",
+			group: GroupOptions{NameSimilarity: 0.9},
+			opts:  DedupeOptions{Email: true, Name: true},
+			want:  []string{"alice@acme.com", "ilgin.yilmaz@acme.com", "ilgin@acme.com", "john@acme.com"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results := Dedupe(Group(members, tc.group), tc.opts)
+			if got := emails(results); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Dedupe() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDedupeExplain(t *testing.T) {
+	members := []Member{
+		testMember("acme", "alice@acme.com", "Alice Smith"),
+		testMember("llama", "alice@acme.com", "Alice Smith"),
+		testMember("acme", "asmith@acme.com", "Alice Smyth"),
+	}
+
+	results := Dedupe(Group(members, GroupOptions{NameSimilarity: 0.8}), DedupeOptions{Email: true, Name: true, Explain: true})
+
+	want := []string{"kept alice@acme.com (acme), absorbing alice@acme.com (llama, user-id), asmith@acme.com (acme, name-fuzzy)"}
+	if got := ExplainDedupe(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExplainDedupe() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package report
+
+import (
+	"sort"
+	"time"
+)
+
+// DomainSeats are the seats held by members with an email domain
+type DomainSeats struct {
+	Domain string `json:"domain"`
+	Seats  int    `json:"seats"`
+	// Share is the domain's share of all seats, from 0 to 1
+	Share float64 `json:"share"`
+	// Stale are the seats with no SSO authorization within staleAfter
+	Stale int `json:"stale"`
+}
+
+// TopDomains ranks email domains by their seats, most first
+func TopDomains(members []Member, staleAfter time.Duration) []DomainSeats {
+	domains := map[string]*DomainSeats{}
+	for _, m := range members {
+		d, ok := domains[m.Domain]
+		if !ok {
+			d = &DomainSeats{Domain: m.Domain}
+			domains[m.Domain] = d
+		}
+		d.Seats++
+		if m.IsStale(staleAfter) {
+			d.Stale++
+		}
+	}
+
+	result := make([]DomainSeats, 0, len(domains))
+	for _, d := range domains {
+		d.Share = float64(d.Seats) / float64(len(members))
+		result = append(result, *d)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Seats != result[j].Seats {
+			return result[i].Seats > result[j].Seats
+		}
+		return result[i].Domain < result[j].Domain
+	})
+
+	return result
+}
@@ -0,0 +1,116 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Enrichment is extra columns for members from a CSV file, joined on a key
+type Enrichment struct {
+	// Key is both the CSV column and the member field, by its JSON name, that
+	// rows are joined on. Values are compared ignoring case and surrounding space
+	Key     string
+	Columns []string
+	rows    map[string]map[string]string
+}
+
+// LoadEnrichment reads a CSV file with a header row, keeping the columns of
+// each row by the value of its key column. Columns are found ignoring case,
+// and default to every column but the key. If several rows have the same key,
+// the first is used
+func LoadEnrichment(path, key string, columns []string) (*Enrichment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header of %s: %w", path, err)
+	}
+
+	index := map[string]int{}
+	for i, h := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		index[strings.ToLower(header[i])] = i
+	}
+
+	keyIndex, ok := index[strings.ToLower(key)]
+	if !ok {
+		return nil, fmt.Errorf("%s has no %q column to join on", path, key)
+	}
+
+	if len(columns) == 0 {
+		for i, h := range header {
+			if i != keyIndex && h != "" {
+				columns = append(columns, h)
+			}
+		}
+		sort.Strings(columns)
+	}
+	for _, c := range columns {
+		if _, ok := index[strings.ToLower(c)]; !ok {
+			return nil, fmt.Errorf("%s has no %q column", path, c)
+		}
+	}
+
+	e := &Enrichment{Key: strings.ToLower(key), Columns: columns, rows: map[string]map[string]string{}}
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if keyIndex >= len(record) {
+			continue
+		}
+
+		k := enrichmentKey(record[keyIndex])
+		if _, ok := e.rows[k]; ok || k == "" {
+			continue
+		}
+
+		row := make(map[string]string, len(columns))
+		for _, c := range columns {
+			if i := index[strings.ToLower(c)]; i < len(record) {
+				row[c] = record[i]
+			}
+		}
+		e.rows[k] = row
+	}
+
+	return e, nil
+}
+
+// Enrich left-joins the columns onto each member, setting every column so
+// that members without a matching row have them empty
+func Enrich(members []Member, e *Enrichment) ([]Member, error) {
+	for i, m := range members {
+		v, err := memberField(e.Key, m)
+		if err != nil {
+			return nil, err
+		}
+
+		row := e.rows[enrichmentKey(v)]
+		enrichment := make(map[string]string, len(e.Columns))
+		for _, c := range e.Columns {
+			enrichment[c] = row[c]
+		}
+		members[i].Enrichment = enrichment
+	}
+	return members, nil
+}
+
+func enrichmentKey(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OrgError is an error loading the members of an org
+type OrgError struct {
+	Org string
+	Err error
+}
+
+func (e *OrgError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Org, e.Err)
+}
+
+func (e *OrgError) Unwrap() error {
+	return e.Err
+}
+
+// PartialError is returned when some orgs failed to load but others succeeded
+type PartialError struct {
+	Failures []*OrgError
+}
+
+func (e *PartialError) Error() string {
+	var orgs []string
+	for _, f := range e.Failures {
+		orgs = append(orgs, f.Org)
+	}
+	return fmt.Sprintf("failed to load %d orgs: %s", len(e.Failures), strings.Join(orgs, ", "))
+}
+
+// InterruptedError is returned when loading was interrupted before every org
+// loaded, either by a signal or by the error that stopped it
+type InterruptedError struct {
+	Loaded    []string
+	Remaining []string
+	Err       error
+}
+
+func (e *InterruptedError) Error() string {
+	msg := fmt.Sprintf("interrupted after loading %d of %d orgs",
+		len(e.Loaded), len(e.Loaded)+len(e.Remaining))
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"sort"
+	"time"
+)
+
+// ExpiringSSO is a member whose SSO authorization has expired or expires soon
+type ExpiringSSO struct {
+	Org       string    `json:"org"`
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	State     string    `json:"state"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Expired   bool      `json:"expired"`
+}
+
+// ExpiringSSOAuthorizations returns the members whose SSO authorization
+// expires within the duration of now, and, if includeExpired, those whose
+// authorization has already expired, soonest expiry first. Revoked
+// authorizations are left out, they're ended rather than expiring
+func ExpiringSSOAuthorizations(members []Member, now time.Time, within time.Duration, includeExpired bool) []ExpiringSSO {
+	result := []ExpiringSSO{}
+	for _, m := range members {
+		if m.SSO == nil || m.SSO.ExpiredAt == nil || m.SSO.RevokedAt != nil {
+			continue
+		}
+
+		expiresAt := *m.SSO.ExpiredAt
+		expired := !expiresAt.After(now)
+		if (expired && !includeExpired) || expiresAt.After(now.Add(within)) {
+			continue
+		}
+
+		result = append(result, ExpiringSSO{
+			Org:       m.Org,
+			ID:        m.ID,
+			Email:     m.Email,
+			Name:      m.Name,
+			Role:      m.Role,
+			State:     m.SSO.State,
+			ExpiresAt: expiresAt,
+			Expired:   expired,
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ExpiresAt.Before(result[j].ExpiresAt)
+	})
+
+	return result
+}
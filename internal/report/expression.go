@@ -0,0 +1,62 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Expression is a compiled boolean expression evaluated against a member,
+// which is available as `member` with the same field names as the JSON output
+type Expression struct {
+	source  string
+	program *vm.Program
+}
+
+// CompileExpression compiles an expression like `member.role == "admin"`
+func CompileExpression(source string) (*Expression, error) {
+	program, err := expr.Compile(source, expr.AsBool())
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression: %w", err)
+	}
+	return &Expression{source: source, program: program}, nil
+}
+
+// Match returns whether the expression is true for the member
+func (e *Expression) Match(m Member) (bool, error) {
+	env, err := expressionEnv(m)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := expr.Run(e.program, env)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate filter %q for %s: %w", e.source, m.Email, err)
+	}
+
+	return result.(bool), nil
+}
+
+// expressionEnv exposes the member as a map keyed by its JSON field names, with
+// times kept as time values so they can be compared with now() and duration()
+func expressionEnv(m Member) (map[string]interface{}, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var member map[string]interface{}
+	if err := json.Unmarshal(b, &member); err != nil {
+		return nil, err
+	}
+
+	if m.LastAuth != nil {
+		member["last_auth"] = *m.LastAuth
+	}
+
+	return map[string]interface{}{
+		"member": member,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package report
+
+// FilterOptions controls which results are kept by Filter
+type FilterOptions struct {
+	Email      string
+	Expression *Expression
+}
+
+// Filter returns the results that match the provided options
+func Filter(results []MemberWithDuplicates, opts FilterOptions) ([]MemberWithDuplicates, error) {
+	filtered := []MemberWithDuplicates{}
+
+	for _, r := range results {
+		ok, err := opts.Matches(r.Member)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			filtered = append(filtered, r)
+		}
+	}
+
+	return filtered, nil
+}
+
+// FilterMembers returns the members that match the provided options, for
+// work on members rather than results, like recommending removals
+func FilterMembers(members []Member, opts FilterOptions) ([]Member, error) {
+	filtered := []Member{}
+
+	for _, m := range members {
+		ok, err := opts.Matches(m)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			filtered = append(filtered, m)
+		}
+	}
+
+	return filtered, nil
+}
+
+// Matches returns whether a member matches the options
+func (o FilterOptions) Matches(m Member) (bool, error) {
+	if o.Email != "" && o.Email != m.Email {
+		return false, nil
+	}
+	if o.Expression != nil {
+		return o.Expression.Match(m)
+	}
+	return true, nil
+}
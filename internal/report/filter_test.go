@@ -0,0 +1,100 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	members := []Member{
+		testMember("acme", "alice@acme.com", "Alice"),
+		testMember("acme", "bob@contractor.com", "Bob"),
+		testMember("llama", "bob@contractor.com", "Bob"),
+		testMember("llama", "carol@contractor.com", "Carol"),
+	}
+	members[0].Role = "admin"
+
+	tests := []struct {
+		name       string
+		email      string
+		expression string
+		want       []string
+	}{
+		{
+			name: "no filter",
+			want: []string{"alice@acme.com", "bob@contractor.com", "bob@contractor.com", "carol@contractor.com"},
+		},
+		{
+			name:  "email",
+			email: "bob@contractor.com",
+			want:  []string{"bob@contractor.com", "bob@contractor.com"},
+		},
+		{
+			name:  "email matches exactly",
+			email: "BOB@contractor.com",
+			want:  []string{},
+		},
+		{
+			name:       "expression",
+			expression: `member.domain == "contractor.com" && member.org == "llama"`,
+			want:       []string{"bob@contractor.com", "carol@contractor.com"},
+		},
+		{
+			name:       "expression on role",
+			expression: `member.role == "admin"`,
+			want:       []string{"alice@acme.com"},
+		},
+		{
+			name:       "email and expression",
+			email:      "bob@contractor.com",
+			expression: `member.org == "acme"`,
+			want:       []string{"bob@contractor.com"},
+		},
+		{
+			name:       "email and expression matching nothing",
+			email:      "alice@acme.com",
+			expression: `member.domain == "contractor.com"`,
+			want:       []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := FilterOptions{Email: tc.email}
+			if tc.expression != "" {
+				expression, err := CompileExpression(tc.expression)
+				if err != nil {
+					t.Fatal(err)
+				}
+				opts.Expression = expression
+			}
+
+			results := make([]MemberWithDuplicates, 0, len(members))
+			for _, m := range members {
+				results = append(results, MemberWithDuplicates{Member: m})
+			}
+
+			filtered, err := Filter(results, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := emails(filtered); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Filter() = %v, want %v", got, tc.want)
+			}
+
+			filteredMembers, err := FilterMembers(members, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(filteredMembers) != len(filtered) {
+				t.Errorf("FilterMembers() returned %d members, Filter() %d results", len(filteredMembers), len(filtered))
+			}
+		})
+	}
+}
+
+func TestFilterInvalidExpression(t *testing.T) {
+	if _, err := CompileExpression(`member.role ==`); err == nil {
+		t.Fatal("expected an error compiling an invalid expression")
+	}
+}
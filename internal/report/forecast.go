@@ -0,0 +1,121 @@
+package report
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// daysPerMonth is the average length of a month
+const daysPerMonth = 365.25 / 12
+
+// SeatSample is the number of paid seats in an org at a time
+type SeatSample struct {
+	Time  time.Time `json:"time"`
+	Seats int       `json:"seats"`
+}
+
+// Forecast is the projected seats of an org, fitted to its recent history
+type Forecast struct {
+	Org string `json:"org"`
+	// Seats is the latest number of seats, at Time
+	Seats int       `json:"seats"`
+	Time  time.Time `json:"time"`
+	// Samples is how many samples the trend was fitted to, too few if less
+	// than two
+	Samples int `json:"samples"`
+	// PerMonth is the trend in seats per month
+	PerMonth    float64      `json:"per_month"`
+	Projections []Projection `json:"projections"`
+}
+
+// Projection is the seats projected some months after the latest sample
+type Projection struct {
+	Months int     `json:"months"`
+	Seats  float64 `json:"seats"`
+	// MonthlyCost is the price of the seats per month, if a price was provided
+	MonthlyCost float64 `json:"monthly_cost,omitempty"`
+}
+
+// ForecastSeats fits a linear trend to the samples of each org within window
+// of its latest sample by least squares, and projects it months ahead. Orgs
+// with fewer than two samples, or samples all at the same time, are projected
+// to stay the same
+func ForecastSeats(history map[string][]SeatSample, window time.Duration, months []int, pricePerSeat float64) []Forecast {
+	orgs := make([]string, 0, len(history))
+	for org := range history {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+
+	var forecasts []Forecast
+	for _, org := range orgs {
+		samples := append([]SeatSample{}, history[org]...)
+		if len(samples) == 0 {
+			continue
+		}
+		sort.Slice(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+
+		latest := samples[len(samples)-1]
+		var recent []SeatSample
+		for _, s := range samples {
+			if latest.Time.Sub(s.Time) <= window {
+				recent = append(recent, s)
+			}
+		}
+
+		f := Forecast{Org: org, Seats: latest.Seats, Time: latest.Time, Samples: len(recent)}
+		perDay := seatTrend(recent)
+		f.PerMonth = perDay * daysPerMonth
+
+		for _, m := range months {
+			// project from the fitted line's value now, rather than the latest
+			// sample, so a noisy last run doesn't skew every projection
+			seats := math.Max(0, fittedSeats(recent, perDay, latest.Time)+perDay*float64(m)*daysPerMonth)
+			f.Projections = append(f.Projections, Projection{Months: m, Seats: seats, MonthlyCost: seats * pricePerSeat})
+		}
+
+		forecasts = append(forecasts, f)
+	}
+
+	return forecasts
+}
+
+// seatTrend returns the least squares slope of seats in seats per day
+func seatTrend(samples []SeatSample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+
+	origin := samples[0].Time
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x := s.Time.Sub(origin).Hours() / 24
+		y := float64(s.Seats)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// fittedSeats returns the value of the line with slope perDay through the
+// mean of the samples at t
+func fittedSeats(samples []SeatSample, perDay float64, t time.Time) float64 {
+	origin := samples[0].Time
+	var sumX, sumY float64
+	for _, s := range samples {
+		sumX += s.Time.Sub(origin).Hours() / 24
+		sumY += float64(s.Seats)
+	}
+	n := float64(len(samples))
+	x := t.Sub(origin).Hours() / 24
+	return sumY/n + perDay*(x-sumX/n)
+}
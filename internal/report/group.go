@@ -0,0 +1,202 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// GroupOptions controls how members are grouped as duplicates
+type GroupOptions struct {
+	// NameSimilarity is the similarity from 0 to 1 at which names are
+	// duplicates, 1 or unset matches identical names only
+	NameSimilarity float64
+	// Locale is used to case fold names, if set
+	Locale language.Tag
+}
+
+// Group returns a result for each member ordered by email, along with the
+// other members that share their email or a similar name
+func Group(members []Member, opts GroupOptions) []MemberWithDuplicates {
+	emails := make([]string, 0, len(members))
+	for _, member := range members {
+		emails = append(emails, member.Email)
+	}
+
+	sort.Strings(emails)
+
+	keys := map[string]string{}
+	for _, member := range members {
+		if _, ok := keys[member.Name]; !ok {
+			keys[member.Name] = NameKey(member.Name, opts.Locale)
+		}
+	}
+
+	result := []MemberWithDuplicates{}
+
+	// iterate by sorted email
+	for _, email := range emails {
+		byEmail := filterMembersByEmail(members, email)
+		member := byEmail[0]
+		var byName []NameDuplicate
+		for _, m := range members {
+			if m.Email == member.Email {
+				continue
+			}
+			if score := opts.nameScore(keys[member.Name], keys[m.Name]); score > 0 {
+				byName = append(byName, NameDuplicate{Member: m, Similarity: score})
+			}
+		}
+		result = append(result, MemberWithDuplicates{
+			Member:          member,
+			EmailDuplicates: byEmail[1:],
+			NameDuplicates:  byName,
+		})
+	}
+
+	return result
+}
+
+// nameScore returns the similarity of two name keys if they are duplicates,
+// or 0. Only identical keys are duplicates unless NameSimilarity is between 0
+// and 1, so edit distances are only computed for fuzzy matching
+func (o GroupOptions) nameScore(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if o.NameSimilarity <= 0 || o.NameSimilarity >= 1 {
+		return 0
+	}
+	if score := nameSimilarity(a, b); score >= o.NameSimilarity {
+		return score
+	}
+	return 0
+}
+
+// nameSimilarity returns how similar two name keys are from 0 to 1, based on
+// the edit distance between them, rounded to two decimal places
+func nameSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ra := []rune(a)
+	rb := []rune(b)
+
+	longest := max(len(ra), len(rb))
+
+	// only identical names are a perfect match
+	score := 1 - float64(levenshtein(ra, rb))/float64(longest)
+	return math.Min(math.Round(score*100)/100, 0.99)
+}
+
+// levenshtein returns the number of single rune edits to turn a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// AssignDuplicateGroups sets a DuplicateGroup on the members and results
+// that have duplicates, shared by every member linked to them by email or
+// name. The group is derived from the lowest email in it, so it is stable
+// between runs while the members are
+func AssignDuplicateGroups(members []Member, results []MemberWithDuplicates) {
+	parent := map[string]string{}
+	var find func(k string) string
+	find = func(k string) string {
+		if p, ok := parent[k]; ok && p != k {
+			parent[k] = find(p)
+			return parent[k]
+		}
+		parent[k] = k
+		return k
+	}
+	union := func(a, b Member) {
+		parent[find(memberKey(a))] = find(memberKey(b))
+	}
+
+	for _, r := range results {
+		for _, d := range r.EmailDuplicates {
+			union(r.Member, d)
+		}
+		for _, d := range r.NameDuplicates {
+			union(r.Member, d.Member)
+		}
+	}
+
+	lowest := map[string]string{}
+	sizes := map[string]int{}
+	for _, m := range members {
+		root := find(memberKey(m))
+		sizes[root]++
+		email := strings.ToLower(m.Email)
+		if l, ok := lowest[root]; !ok || email < l {
+			lowest[root] = email
+		}
+	}
+
+	groupOf := func(m Member) string {
+		root := find(memberKey(m))
+		if sizes[root] < 2 {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(lowest[root]))
+		return "dup-" + hex.EncodeToString(sum[:4])
+	}
+
+	for i := range members {
+		members[i].DuplicateGroup = groupOf(members[i])
+	}
+	for i := range results {
+		r := &results[i]
+		r.DuplicateGroup = groupOf(r.Member)
+		for j := range r.EmailDuplicates {
+			r.EmailDuplicates[j].DuplicateGroup = r.DuplicateGroup
+		}
+		for j := range r.NameDuplicates {
+			r.NameDuplicates[j].DuplicateGroup = r.DuplicateGroup
+		}
+	}
+}
+
+// memberKey identifies a member of an org, as the same user can be a member
+// of several
+func memberKey(m Member) string {
+	return m.ID + "/" + m.Org
+}
+
+func filterMembers(members []Member, f func(m Member) bool) (matching []Member) {
+	for _, m := range members {
+		if f(m) {
+			matching = append(matching, m)
+		}
+	}
+	return
+}
+
+func filterMembersByEmail(members []Member, email string) (matching []Member) {
+	return filterMembers(members, func(m Member) bool {
+		return m.Email == email
+	})
+}
@@ -0,0 +1,128 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	members := []Member{
+		testMember("llama", "carol@acme.com", "Carol"),
+		testMember("acme", "alice@acme.com", "Alice Smith"),
+		testMember("llama", "alice@acme.com", "Alice Smith"),
+		testMember("acme", "asmith@acme.com", "alice  SMITH"),
+		testMember("acme", "jon@acme.com", "Jon Jones"),
+		testMember("acme", "john@acme.com", "John Jones"),
+	}
+
+	type group struct {
+		Email           string
+		EmailDuplicates []string
+		NameDuplicates  map[string]float64
+	}
+
+	tests := []struct {
+		name string
+		opts GroupOptions
+		want []group
+	}{
+		{
+			name: "identical names",
+			want: []group{
+				{Email: "alice@acme.com", EmailDuplicates: []string{"llama"}, NameDuplicates: map[string]float64{"asmith@acme.com": 1}},
+				{Email: "alice@acme.com", EmailDuplicates: []string{"llama"}, NameDuplicates: map[string]float64{"asmith@acme.com": 1}},
+				{Email: "asmith@acme.com", NameDuplicates: map[string]float64{"alice@acme.com": 2}},
+				{Email: "carol@acme.com"},
+				{Email: "john@acme.com"},
+				{Email: "jon@acme.com"},
+			},
+		},
+		{
+			name: "similar names",
+			opts: GroupOptions{NameSimilarity: 0.9},
+			want: []group{
+				{Email: "alice@acme.com", EmailDuplicates: []string{"llama"}, NameDuplicates: map[string]float64{"asmith@acme.com": 1}},
+				{Email: "alice@acme.com", EmailDuplicates: []string{"llama"}, NameDuplicates: map[string]float64{"asmith@acme.com": 1}},
+				{Email: "asmith@acme.com", NameDuplicates: map[string]float64{"alice@acme.com": 2}},
+				{Email: "carol@acme.com"},
+				{Email: "john@acme.com", NameDuplicates: map[string]float64{"jon@acme.com": 0.9}},
+				{Email: "jon@acme.com", NameDuplicates: map[string]float64{"john@acme.com": 0.9}},
+			},
+		},
+		{
+			name: "similarity threshold above the names",
+			opts: GroupOptions{NameSimilarity: 0.95},
+			want: []group{
+				{Email: "alice@acme.com", EmailDuplicates: []string{"llama"}, NameDuplicates: map[string]float64{"asmith@acme.com": 1}},
+				{Email: "alice@acme.com", EmailDuplicates: []string{"llama"}, NameDuplicates: map[string]float64{"asmith@acme.com": 1}},
+				{Email: "asmith@acme.com", NameDuplicates: map[string]float64{"alice@acme.com": 2}},
+				{Email: "carol@acme.com"},
+				{Email: "john@acme.com"},
+				{Email: "jon@acme.com"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []group
+			for _, r := range Group(members, tc.opts) {
+				g := group{Email: r.Email}
+				for _, d := range r.EmailDuplicates {
+					g.EmailDuplicates = append(g.EmailDuplicates, d.Org)
+				}
+				for _, d := range r.NameDuplicates {
+					if g.NameDuplicates == nil {
+						g.NameDuplicates = map[string]float64{}
+					}
+					// members sharing an email are each a duplicate
+					g.NameDuplicates[d.Email] += d.Similarity
+				}
+				got = append(got, g)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Group() =\n%+v\nwant\n%+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"alice smith", "alice smith", 1},
+		{"jon jones", "john jones", 0.9},
+		{"alice", "bob", 0},
+		{"", "bob", 0},
+	}
+
+	for _, tc := range tests {
+		if got := nameSimilarity(tc.a, tc.b); got != tc.want {
+			t.Errorf("nameSimilarity(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestNameScore(t *testing.T) {
+	tests := []struct {
+		threshold float64
+		a, b      string
+		want      float64
+	}{
+		{0, "alice smith", "alice smith", 1},
+		{0, "jon jones", "john jones", 0},
+		{1, "alice smith", "alice smith", 1},
+		{1, "jon jones", "john jones", 0},
+		{0.9, "jon jones", "john jones", 0.9},
+		{0.95, "jon jones", "john jones", 0},
+	}
+
+	for _, tc := range tests {
+		if got := (GroupOptions{NameSimilarity: tc.threshold}).nameScore(tc.a, tc.b); got != tc.want {
+			t.Errorf("nameScore(%q, %q) at %v = %v, want %v", tc.a, tc.b, tc.threshold, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package report
+
+import (
+	"strings"
+	"time"
+)
+
+// Types of member history events
+const (
+	// HistoryPresent is a member in the first snapshot of their org
+	HistoryPresent = "present"
+	HistoryAdded   = "added"
+	HistoryRemoved = "removed"
+	HistoryChanged = "changed"
+)
+
+// HistoryEvent is a change in the members of an org, recorded by a run
+type HistoryEvent struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Member Member    `json:"member"`
+	// Before is the member before a change
+	Before *Member `json:"before,omitempty"`
+	// Fields are the names of the fields that changed, like role
+	Fields []string `json:"fields,omitempty"`
+}
+
+// RecordHistory appends events to the history of an org for the differences
+// between the members it records and the org's current members. If there's
+// no history yet, every member is recorded as present
+func RecordHistory(history []HistoryEvent, current []Member, t time.Time) []HistoryEvent {
+	snapshot := make([]Member, 0, len(current))
+	for _, m := range current {
+		snapshot = append(snapshot, historyMember(m))
+	}
+
+	if len(history) == 0 {
+		sortMembers(snapshot)
+		for _, m := range snapshot {
+			history = append(history, HistoryEvent{Time: t, Type: HistoryPresent, Member: m})
+		}
+		return history
+	}
+
+	changes := Diff(HistoryMembers(history), snapshot)
+	for _, m := range changes.Added {
+		history = append(history, HistoryEvent{Time: t, Type: HistoryAdded, Member: m})
+	}
+	for _, m := range changes.Removed {
+		history = append(history, HistoryEvent{Time: t, Type: HistoryRemoved, Member: m})
+	}
+	for _, c := range changes.Changed {
+		before := c.Before
+		history = append(history, HistoryEvent{Time: t, Type: HistoryChanged, Member: c.After, Before: &before, Fields: c.Fields})
+	}
+
+	return history
+}
+
+// HistoryMembers returns the members of an org after the events of its history
+func HistoryMembers(history []HistoryEvent) []Member {
+	members := map[string]Member{}
+	var order []string
+
+	for _, e := range history {
+		key := e.Member.ID
+		if e.Type == HistoryRemoved {
+			delete(members, key)
+			continue
+		}
+		if _, ok := members[key]; !ok {
+			order = append(order, key)
+		}
+		members[key] = e.Member
+	}
+
+	var result []Member
+	seen := map[string]bool{}
+	for _, key := range order {
+		if m, ok := members[key]; ok && !seen[key] {
+			seen[key] = true
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// MemberHistory returns the events of a member with an email, before or
+// after a change, ignoring case
+func MemberHistory(history []HistoryEvent, email string) []HistoryEvent {
+	var events []HistoryEvent
+	for _, e := range history {
+		if strings.EqualFold(e.Member.Email, email) || (e.Before != nil && strings.EqualFold(e.Before.Email, email)) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// historyMember returns the fields of a member that are compared by Diff,
+// leaving out those like last_auth that change on every run
+func historyMember(m Member) Member {
+	return Member{
+		ID:            m.ID,
+		Email:         m.Email,
+		Domain:        m.Domain,
+		Name:          m.Name,
+		Org:           m.Org,
+		Role:          m.Role,
+		Complimentary: m.Complimentary,
+		Bot:           m.Bot,
+	}
+}
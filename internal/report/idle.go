@@ -0,0 +1,90 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+)
+
+// Activity classes of members, by whether they have a recent SSO
+// authorization and recently created builds
+const (
+	ActivityActive     = "active"
+	ActivityAuthOnly   = "auth-only"
+	ActivityBuildsOnly = "builds-only"
+	ActivityIdle       = "idle"
+)
+
+// MemberActivity is a member classified by their SSO and build activity
+type MemberActivity struct {
+	Member
+	LastBuild *time.Time `json:"last_build"`
+	Activity  string     `json:"activity"`
+}
+
+// Reclaimable returns whether the member has neither signal of activity, so
+// their seat is safe to reclaim
+func (m MemberActivity) Reclaimable() bool {
+	return m.Activity == ActivityIdle
+}
+
+// ClassifyActivity classifies members as active if they have both an SSO
+// authorization within authAfter and created a build within buildAfter,
+// idle if they have neither, or auth-only or builds-only otherwise. Builds
+// are matched to members of the same org by user ID or email.
+func ClassifyActivity(members []Member, builds []buildkite.Build, authAfter, buildAfter time.Duration) []MemberActivity {
+	lastBuild := map[string]time.Time{}
+	for _, b := range builds {
+		if b.Creator == nil {
+			continue
+		}
+		for _, key := range []string{b.Org + "/" + b.Creator.ID, b.Org + "/" + strings.ToLower(b.Creator.Email)} {
+			if b.CreatedAt.After(lastBuild[key]) {
+				lastBuild[key] = b.CreatedAt
+			}
+		}
+	}
+
+	result := make([]MemberActivity, 0, len(members))
+	for _, m := range members {
+		a := MemberActivity{Member: m}
+
+		for _, key := range []string{m.Org + "/" + m.ID, m.Org + "/" + strings.ToLower(m.Email)} {
+			if t, ok := lastBuild[key]; ok && (a.LastBuild == nil || t.After(*a.LastBuild)) {
+				t := t
+				a.LastBuild = &t
+			}
+		}
+
+		authed := !m.IsStale(authAfter)
+		built := a.LastBuild != nil && time.Since(*a.LastBuild) <= buildAfter
+
+		switch {
+		case authed && built:
+			a.Activity = ActivityActive
+		case authed:
+			a.Activity = ActivityAuthOnly
+		case built:
+			a.Activity = ActivityBuildsOnly
+		default:
+			a.Activity = ActivityIdle
+		}
+
+		result = append(result, a)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return activityRank[result[i].Activity] < activityRank[result[j].Activity]
+	})
+
+	return result
+}
+
+var activityRank = map[string]int{
+	ActivityIdle:       0,
+	ActivityBuildsOnly: 1,
+	ActivityAuthOnly:   2,
+	ActivityActive:     3,
+}
@@ -0,0 +1,21 @@
+package report
+
+// Label merges labels for each org into the members of that org
+func Label(members []Member, orgLabels map[string]map[string]string) []Member {
+	for i, m := range members {
+		labels := orgLabels[m.Org]
+		if len(labels) == 0 {
+			continue
+		}
+
+		merged := make(map[string]string, len(m.Labels)+len(labels))
+		for k, v := range m.Labels {
+			merged[k] = v
+		}
+		for k, v := range labels {
+			merged[k] = v
+		}
+		members[i].Labels = merged
+	}
+	return members
+}
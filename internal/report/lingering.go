@@ -0,0 +1,95 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+)
+
+// LingeringCredential is a member whose SSO authorization was revoked or
+// expired but who created builds afterwards, e.g. with an API token
+type LingeringCredential struct {
+	Org        string    `json:"org"`
+	ID         string    `json:"id"`
+	Email      string    `json:"email"`
+	Name       string    `json:"name"`
+	SSOState   string    `json:"sso_state"`
+	SSOEndedAt time.Time `json:"sso_ended_at"`
+	// Builds is the number of builds created after SSO ended
+	Builds    int       `json:"builds"`
+	LastBuild time.Time `json:"last_build"`
+}
+
+// LingeringCredentials returns the members of an org whose SSO authorization
+// was revoked or expired before they created one of the provided builds
+func LingeringCredentials(orgSlug string, members []buildkite.OrgMember, builds []buildkite.Build) []LingeringCredential {
+	ended := map[string]*LingeringCredential{}
+	byEmail := map[string]*LingeringCredential{}
+
+	for _, m := range members {
+		state, endedAt, ok := ssoEnded(m.Authorization)
+		if !ok {
+			continue
+		}
+
+		l := &LingeringCredential{
+			Org:        orgSlug,
+			ID:         m.ID,
+			Email:      m.Email,
+			Name:       m.Name,
+			SSOState:   state,
+			SSOEndedAt: endedAt,
+		}
+		ended[m.ID] = l
+		byEmail[strings.ToLower(m.Email)] = l
+	}
+
+	for _, b := range builds {
+		if b.Creator == nil {
+			continue
+		}
+
+		l := ended[b.Creator.ID]
+		if l == nil {
+			l = byEmail[strings.ToLower(b.Creator.Email)]
+		}
+		if l == nil || !b.CreatedAt.After(l.SSOEndedAt) {
+			continue
+		}
+
+		l.Builds++
+		if b.CreatedAt.After(l.LastBuild) {
+			l.LastBuild = b.CreatedAt
+		}
+	}
+
+	var result []LingeringCredential
+	for _, l := range ended {
+		if l.Builds > 0 {
+			result = append(result, *l)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastBuild.After(result[j].LastBuild)
+	})
+
+	return result
+}
+
+// ssoEnded returns whether an SSO authorization was revoked or has expired,
+// and when
+func ssoEnded(a *buildkite.Authorization) (string, time.Time, bool) {
+	if a == nil {
+		return "", time.Time{}, false
+	}
+	if a.RevokedAt != nil && !a.RevokedAt.IsZero() {
+		return "revoked", *a.RevokedAt, true
+	}
+	if a.ExpireAt != nil && !a.ExpireAt.IsZero() && a.ExpireAt.Before(time.Now()) {
+		return "expired", *a.ExpireAt, true
+	}
+	return "", time.Time{}, false
+}
@@ -0,0 +1,417 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/lock"
+)
+
+// FetchFunc returns the members of an org
+type FetchFunc func(orgSlug string) ([]buildkite.OrgMember, error)
+
+// CacheFile returns the path in dir that an org's members are cached in,
+// which includes the shape of the members query so that members saved by an
+// incompatible version aren't used
+func CacheFile(dir, orgSlug string) string {
+	return filepath.Join(dir, orgSlug+"-"+buildkite.OrgMembersShape()+".json")
+}
+
+// CachedFetch returns a FetchFunc that serves org members from a disk cache
+// in dir, falling back to fetch and saving the results
+func CachedFetch(dir string, fetch FetchFunc) (FetchFunc, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return func(orgSlug string) ([]buildkite.OrgMember, error) {
+		cacheFile := CacheFile(dir, orgSlug)
+
+		// serve from cache if it exists
+		if _, err := os.Stat(cacheFile); err == nil {
+			return readCache(cacheFile)
+		}
+
+		// otherwise look up the org members form the API (slow)
+		return fetchAndCache(cacheFile, orgSlug, fetch)
+	}, nil
+}
+
+// fetchAndCache fetches an org's members and saves them to cacheFile, holding
+// a lock so that concurrent runs fetch an org once, the others waiting and
+// then reading what it saved
+func fetchAndCache(cacheFile, orgSlug string, fetch FetchFunc) ([]buildkite.OrgMember, error) {
+	l, err := lock.Acquire(cacheFile + ".lock")
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	if _, err := os.Stat(cacheFile); err == nil {
+		return readCache(cacheFile)
+	}
+
+	members, err := fetch(orgSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeCache(cacheFile, members); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// RevalidatingFetch returns a FetchFunc that serves org members from the disk
+// cache in dir as soon as they are there, however old, and refreshes them
+// from fetch in the background for next time, adding each refresh to wg.
+// Orgs that aren't cached are fetched and saved like CachedFetch
+func RevalidatingFetch(dir string, fetch FetchFunc, logf Logf, wg *sync.WaitGroup) (FetchFunc, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	refreshing := map[string]bool{}
+
+	refresh := func(orgSlug, cacheFile string) {
+		defer wg.Done()
+		defer func() {
+			mu.Lock()
+			delete(refreshing, orgSlug)
+			mu.Unlock()
+		}()
+
+		// leave it to another run that's already refreshing the org
+		l, err := lock.TryLock(cacheFile + ".lock")
+		if errors.Is(err, lock.ErrLocked) {
+			return
+		} else if err != nil {
+			if logf != nil {
+				logf("Failed to refresh cached members of %s: %v", orgSlug, err)
+			}
+			return
+		}
+		defer l.Release()
+
+		members, err := fetch(orgSlug)
+		if err == nil {
+			err = writeCache(cacheFile, members)
+		}
+		if err != nil && logf != nil {
+			logf("Failed to refresh cached members of %s: %v", orgSlug, err)
+		}
+	}
+
+	return func(orgSlug string) ([]buildkite.OrgMember, error) {
+		cacheFile := CacheFile(dir, orgSlug)
+
+		if _, err := os.Stat(cacheFile); err != nil {
+			return fetchAndCache(cacheFile, orgSlug, fetch)
+		}
+
+		members, err := readCache(cacheFile)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		if !refreshing[orgSlug] {
+			refreshing[orgSlug] = true
+			wg.Add(1)
+			go refresh(orgSlug, cacheFile)
+		}
+		mu.Unlock()
+
+		return members, nil
+	}, nil
+}
+
+// ErrNotCached is returned by OfflineFetch for orgs missing from the cache
+var ErrNotCached = errors.New("not cached")
+
+// OfflineFetch returns a FetchFunc that only serves org members from the disk
+// cache in dir, failing with ErrNotCached for orgs that aren't in it
+func OfflineFetch(dir string) FetchFunc {
+	return func(orgSlug string) ([]buildkite.OrgMember, error) {
+		cacheFile := CacheFile(dir, orgSlug)
+		if _, err := os.Stat(cacheFile); os.IsNotExist(err) {
+			return nil, fmt.Errorf("members of org %q are %w in %s", orgSlug, ErrNotCached, dir)
+		}
+		return readCache(cacheFile)
+	}
+}
+
+// FallbackFetch returns a FetchFunc that saves the members fetched to the disk
+// cache in dir, and serves them from it with a warning if the API is
+// unavailable
+func FallbackFetch(dir string, fetch FetchFunc, logf Logf) (FetchFunc, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return func(orgSlug string) ([]buildkite.OrgMember, error) {
+		cacheFile := CacheFile(dir, orgSlug)
+
+		members, err := fetch(orgSlug)
+		if err == nil {
+			return members, writeCache(cacheFile, members)
+		}
+
+		info, serr := os.Stat(cacheFile)
+		if !errors.Is(err, buildkite.ErrUnavailable) || serr != nil {
+			return nil, err
+		}
+
+		if logf != nil {
+			logf("Warning: using cached members of %s from %s ago, the API is unavailable: %v",
+				orgSlug, time.Since(info.ModTime()).Round(time.Minute), err)
+		}
+
+		return readCache(cacheFile)
+	}, nil
+}
+
+// MemoizedFetch returns a FetchFunc that fetches each org's members once,
+// serving later calls for the org from memory, so that a run that needs them
+// more than once only fetches them once. Concurrent calls for an org wait for
+// the first, and errors aren't remembered, so a failed org is fetched again
+func MemoizedFetch(fetch FetchFunc) FetchFunc {
+	var mu sync.Mutex
+	orgs := map[string]*memoizedOrg{}
+
+	return func(orgSlug string) ([]buildkite.OrgMember, error) {
+		mu.Lock()
+		org, ok := orgs[orgSlug]
+		if !ok {
+			org = &memoizedOrg{}
+			orgs[orgSlug] = org
+		}
+		mu.Unlock()
+
+		org.Lock()
+		defer org.Unlock()
+
+		if !org.fetched {
+			members, err := fetch(orgSlug)
+			if err != nil {
+				return members, err
+			}
+			org.members, org.fetched = members, true
+		}
+
+		// each caller gets its own copy to sort or filter
+		return append([]buildkite.OrgMember(nil), org.members...), nil
+	}
+}
+
+type memoizedOrg struct {
+	sync.Mutex
+	members []buildkite.OrgMember
+	fetched bool
+}
+
+func readCache(cacheFile string) ([]buildkite.OrgMember, error) {
+	b, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []buildkite.OrgMember
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func writeCache(cacheFile string, members []buildkite.OrgMember) error {
+	b, err := json.Marshal(members)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(cacheFile, b)
+}
+
+// writeFileAtomic writes to a temporary file that's renamed over path, so a
+// concurrent reader sees either the old or the new contents, never a mix
+func writeFileAtomic(path string, b []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// LoadOptions controls how Load fetches members
+type LoadOptions struct {
+	Logf Logf
+	// ContinueOnError records orgs that fail to load and continues with the
+	// rest, returning a *PartialError along with the members that loaded
+	ContinueOnError bool
+	// Interrupt stops loading when closed, returning an *InterruptedError along
+	// with the members of the orgs that finished loading
+	Interrupt <-chan struct{}
+}
+
+// Load fetches the members of each org and converts them into Members
+func Load(fetch FetchFunc, orgSlugs []string, opts LoadOptions) ([]Member, error) {
+	result := []Member{}
+	var failures []*OrgError
+	var loaded []string
+
+	for i, orgSlug := range orgSlugs {
+		if opts.Logf != nil {
+			opts.Logf("Finding members in %s", orgSlug)
+		}
+		t := time.Now()
+
+		members, interrupted, err := loadOrgInterruptibly(fetch, orgSlug, opts.Interrupt)
+		if interrupted {
+			return result, &InterruptedError{Loaded: loaded, Remaining: orgSlugs[i:]}
+		}
+		if errors.Is(err, buildkite.ErrRequestBudgetExceeded) {
+			return result, &InterruptedError{Loaded: loaded, Remaining: orgSlugs[i:], Err: err}
+		}
+		if err != nil {
+			orgErr := &OrgError{Org: orgSlug, Err: err}
+			if !opts.ContinueOnError {
+				return nil, orgErr
+			}
+			if opts.Logf != nil {
+				opts.Logf("Failed to load %s: %v", orgSlug, err)
+			}
+			failures = append(failures, orgErr)
+			continue
+		}
+
+		result = append(result, members...)
+		loaded = append(loaded, orgSlug)
+
+		if opts.Logf != nil {
+			opts.Logf("Found %d responses in %v", len(members), time.Since(t))
+		}
+	}
+
+	if len(failures) > 0 {
+		return result, &PartialError{Failures: failures}
+	}
+
+	return result, nil
+}
+
+// loadOrgInterruptibly loads an org, abandoning it if interrupt is closed first
+func loadOrgInterruptibly(fetch FetchFunc, orgSlug string, interrupt <-chan struct{}) ([]Member, bool, error) {
+	if interrupt == nil {
+		members, err := loadOrg(fetch, orgSlug)
+		return members, false, err
+	}
+
+	type result struct {
+		members []Member
+		err     error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		members, err := loadOrg(fetch, orgSlug)
+		done <- result{members, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.members, false, r.err
+	case <-interrupt:
+		return nil, true, nil
+	}
+}
+
+func loadOrg(fetch FetchFunc, orgSlug string) ([]Member, error) {
+	orgMembers, err := fetch(orgSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]Member, 0, len(orgMembers))
+	for _, orgMember := range orgMembers {
+		m, err := NewMember(orgSlug, orgMember)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, nil
+}
+
+// NewMember converts an org member into a Member of the org
+func NewMember(orgSlug string, orgMember buildkite.OrgMember) (Member, error) {
+	m := Member{
+		ID:            orgMember.ID,
+		Email:         orgMember.Email,
+		Name:          orgMember.Name,
+		Org:           orgSlug,
+		Role:          strings.ToLower(orgMember.Role),
+		Complimentary: orgMember.Complimentary,
+		Bot:           orgMember.Bot,
+		MembershipID:  orgMember.MembershipID,
+	}
+
+	if a := orgMember.Authorization; a != nil {
+		m.Email = a.Email
+		m.LastAuth = &a.CreatedAt
+		m.SSO = &SSODetails{
+			State:              strings.ToLower(a.State),
+			CreatedAt:          a.CreatedAt,
+			ExpiredAt:          nonZero(a.ExpireAt),
+			RevokedAt:          nonZero(a.RevokedAt),
+			SessionDestroyedAt: nonZero(a.UserSessionDestroyedAt),
+		}
+	}
+
+	domain, err := getEmailDomain(m.Email)
+	if err != nil {
+		return Member{}, err
+	}
+	m.Domain = domain
+
+	return m, nil
+}
+
+// nonZero returns nil for zero times, which members cached before timestamps
+// were decoded as nullable can have
+func nonZero(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+func getEmailDomain(email string) (string, error) {
+	at := strings.LastIndex(email, "@")
+	if at >= 0 {
+		_, domain := email[:at], email[at+1:]
+		return domain, nil
+	}
+	return "", fmt.Errorf("%s is an invalid email address", email)
+}
@@ -0,0 +1,98 @@
+package report
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+)
+
+func TestLoad(t *testing.T) {
+	errAPI := errors.New("api failed")
+
+	orgs := map[string][]buildkite.OrgMember{
+		"acme": {
+			{ID: "alice", Email: "alice@acme.com", Name: "Alice", Role: "ADMIN"},
+			{ID: "bob", Email: "bob@contractor.com", Name: "Bob", Role: "MEMBER"},
+		},
+		"llama": {
+			{ID: "alice", Email: "alice@acme.com", Name: "Alice", Role: "MEMBER"},
+		},
+		"sso": {
+			{ID: "alice", Email: "alice@acme.com", Name: "Alice", Role: "MEMBER", Authorization: &buildkite.Authorization{
+				Email: "alice@idp.acme.com",
+				State: "VERIFIED",
+			}},
+		},
+	}
+
+	fetch := func(orgSlug string) ([]buildkite.OrgMember, error) {
+		if orgSlug == "broken" {
+			return nil, errAPI
+		}
+		return orgs[orgSlug], nil
+	}
+
+	tests := []struct {
+		name     string
+		orgSlugs []string
+		opts     LoadOptions
+		want     []string
+		failures []string
+		err      error
+	}{
+		{
+			name:     "every org",
+			orgSlugs: []string{"acme", "llama"},
+			want:     []string{"acme/alice@acme.com/admin/acme.com", "acme/bob@contractor.com/member/contractor.com", "llama/alice@acme.com/member/acme.com"},
+		},
+		{
+			name:     "failed org",
+			orgSlugs: []string{"acme", "broken", "llama"},
+			err:      errAPI,
+		},
+		{
+			name:     "failed org continuing on error",
+			orgSlugs: []string{"acme", "broken", "llama"},
+			opts:     LoadOptions{ContinueOnError: true},
+			want:     []string{"acme/alice@acme.com/admin/acme.com", "acme/bob@contractor.com/member/contractor.com", "llama/alice@acme.com/member/acme.com"},
+			failures: []string{"broken"},
+		},
+		{
+			name:     "sso email",
+			orgSlugs: []string{"sso"},
+			want:     []string{"sso/alice@idp.acme.com/member/idp.acme.com"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			members, err := Load(fetch, tc.orgSlugs, tc.opts)
+
+			var failures []string
+			var partial *PartialError
+			if errors.As(err, &partial) {
+				for _, f := range partial.Failures {
+					failures = append(failures, f.Org)
+				}
+			} else if !errors.Is(err, tc.err) {
+				t.Fatalf("Load() error = %v, want %v", err, tc.err)
+			}
+			if !reflect.DeepEqual(failures, tc.failures) {
+				t.Errorf("failed orgs = %v, want %v", failures, tc.failures)
+			}
+
+			var got []string
+			for _, m := range members {
+				got = append(got, m.Org+"/"+m.Email+"/"+m.Role+"/"+m.Domain)
+				if (m.LastAuth != nil) != (m.SSO != nil) {
+					t.Errorf("%s has a last auth of %v with sso details %v", m.Email, m.LastAuth, m.SSO)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Load() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
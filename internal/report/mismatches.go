@@ -0,0 +1,70 @@
+package report
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"golang.org/x/text/language"
+)
+
+// Mismatch is a member whose SSO identity differs from their Buildkite
+// account, which usually means a personal account linked to corporate SSO
+type Mismatch struct {
+	Org          string `json:"org"`
+	ID           string `json:"id"`
+	Role         string `json:"role"`
+	AccountEmail string `json:"account_email"`
+	AccountName  string `json:"account_name"`
+	SSOEmail     string `json:"sso_email"`
+	SSOName      string `json:"sso_name"`
+	// Fields are the fields that differ, email and/or name
+	Fields []string `json:"fields"`
+}
+
+// Mismatches returns the members of an org whose SSO identity email or name
+// differs from their account's. Emails are compared case-insensitively and
+// names by NameKey; fields missing from the identity are
+// not compared
+func Mismatches(orgSlug string, members []buildkite.OrgMember) []Mismatch {
+	var result []Mismatch
+
+	for _, m := range members {
+		a := m.Authorization
+		if a == nil {
+			continue
+		}
+
+		var fields []string
+		if a.Email != "" && !strings.EqualFold(a.Email, m.Email) {
+			fields = append(fields, "email")
+		}
+		if a.Name != "" && NameKey(a.Name, language.Und) != NameKey(m.Name, language.Und) {
+			fields = append(fields, "name")
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		result = append(result, Mismatch{
+			Org:          orgSlug,
+			ID:           m.ID,
+			Role:         strings.ToLower(m.Role),
+			AccountEmail: m.Email,
+			AccountName:  m.Name,
+			SSOEmail:     a.Email,
+			SSOName:      a.Name,
+			Fields:       fields,
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return strings.ToLower(result[i].AccountEmail) < strings.ToLower(result[j].AccountEmail)
+	})
+
+	return result
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
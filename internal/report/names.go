@@ -0,0 +1,31 @@
+package report
+
+import (
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldDiacritics decomposes a string, drops combining marks like accents and
+// recomposes what's left, so é becomes e
+var foldDiacritics = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// NameKey returns a name in a form for comparison, so "José  García",
+// "JOSE GARCIA" and composed or decomposed forms of them are the same. It
+// applies NFKC normalization, folds diacritics, lowercases using the rules of
+// the locale if there is one (like the dotless i in Turkish), case folds and
+// collapses whitespace
+func NameKey(name string, locale language.Tag) string {
+	s := norm.NFKC.String(name)
+	if folded, _, err := transform.String(foldDiacritics, s); err == nil {
+		s = folded
+	}
+	if locale != language.Und {
+		s = cases.Lower(locale).String(s)
+	}
+	return normalizeSpace(cases.Fold().String(s))
+}
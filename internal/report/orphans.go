@@ -0,0 +1,109 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+)
+
+// OrphanedPipeline is a pipeline that no active member owns
+type OrphanedPipeline struct {
+	Org       string     `json:"org"`
+	Pipeline  string     `json:"pipeline"`
+	Name      string     `json:"name"`
+	LastBuild *time.Time `json:"last_build"`
+	// Creators are the emails of the creators of recent builds
+	Creators []string `json:"creators"`
+	// Maintainers are the emails of the maintainers of the pipeline's teams
+	Maintainers []string `json:"maintainers"`
+}
+
+// OrphanedPipelines returns the pipelines of an org whose recent build
+// creators and team maintainers are all stale or no longer members. Builds
+// should be the org's recent builds and members the org's members.
+func OrphanedPipelines(orgSlug string, pipelines []buildkite.Pipeline, builds []buildkite.Build, members []Member, staleAfter time.Duration) []OrphanedPipeline {
+	active := map[string]bool{}
+	for _, m := range members {
+		if m.Org == orgSlug && !m.IsStale(staleAfter) {
+			active[m.ID] = true
+			active[strings.ToLower(m.Email)] = true
+		}
+	}
+
+	type pipelineBuilds struct {
+		last     *time.Time
+		creators []string
+		owned    bool
+	}
+
+	byPipeline := map[string]*pipelineBuilds{}
+	for _, b := range builds {
+		pb := byPipeline[b.Pipeline]
+		if pb == nil {
+			pb = &pipelineBuilds{}
+			byPipeline[b.Pipeline] = pb
+		}
+
+		if pb.last == nil || b.CreatedAt.After(*pb.last) {
+			createdAt := b.CreatedAt
+			pb.last = &createdAt
+		}
+
+		if b.Creator == nil {
+			continue
+		}
+		if active[b.Creator.ID] || active[strings.ToLower(b.Creator.Email)] {
+			pb.owned = true
+		}
+		pb.creators = appendUnique(pb.creators, b.Creator.Email)
+	}
+
+	var result []OrphanedPipeline
+
+	for _, p := range pipelines {
+		pb := byPipeline[p.Slug]
+		if pb == nil {
+			pb = &pipelineBuilds{}
+		}
+
+		owned := pb.owned
+		var maintainers []string
+		for _, t := range p.Teams {
+			for _, email := range t.Maintainers {
+				if active[strings.ToLower(email)] {
+					owned = true
+				}
+				maintainers = appendUnique(maintainers, email)
+			}
+		}
+
+		if owned {
+			continue
+		}
+
+		sort.Strings(pb.creators)
+		sort.Strings(maintainers)
+
+		result = append(result, OrphanedPipeline{
+			Org:         orgSlug,
+			Pipeline:    p.Slug,
+			Name:        p.Name,
+			LastBuild:   pb.last,
+			Creators:    pb.creators,
+			Maintainers: maintainers,
+		})
+	}
+
+	return result
+}
+
+func appendUnique(s []string, v string) []string {
+	for _, existing := range s {
+		if existing == v {
+			return s
+		}
+	}
+	return append(s, v)
+}
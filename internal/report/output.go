@@ -0,0 +1,83 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/template"
+	"time"
+)
+
+// Report is the result of running the report pipeline
+type Report struct {
+	// Orgs are the slugs of the orgs that were loaded
+	Orgs []string
+	// Members are all the members that were loaded
+	Members []Member
+	// Results are the grouped, filtered and deduped members
+	Results []MemberWithDuplicates
+	// Failures are the orgs that failed to load, if loading continued on error
+	Failures []*OrgError
+	// Partial is true if loading was interrupted before every org loaded
+	Partial bool
+	// Changes are the changes in members since the last run, if there was one
+	Changes *Changes
+}
+
+// OutputWriter writes a Report in a particular format
+type OutputWriter interface {
+	Write(r *Report) error
+	Flush() error
+}
+
+// OutputOptions are options shared by OutputWriters, not every writer supports every option
+type OutputOptions struct {
+	// Query is applied to the result document before it's written
+	Query *Query
+	// Color enables ANSI colored output
+	Color bool
+	// Compact disables indentation
+	Compact bool
+	// Template renders the complete report
+	Template *template.Template
+	// ChangesOnly writes only the changes since the last run
+	ChangesOnly bool
+}
+
+// NewOutputWriterFunc returns an OutputWriter that writes to w
+type NewOutputWriterFunc func(w io.Writer, opts OutputOptions) OutputWriter
+
+var outputWriters = map[string]NewOutputWriterFunc{}
+
+// now is when a report is generated, fixed by tests for golden output
+var now = time.Now
+
+// RegisterOutputWriter registers an OutputWriter for a format name
+func RegisterOutputWriter(format string, f NewOutputWriterFunc) {
+	outputWriters[format] = f
+}
+
+// NewOutputWriter returns the OutputWriter registered for a format name
+func NewOutputWriter(format string, w io.Writer, opts OutputOptions) (OutputWriter, error) {
+	f, ok := outputWriters[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+	return f(w, opts), nil
+}
+
+// IsOutputFormat returns whether an OutputWriter is registered for a format name
+func IsOutputFormat(format string) bool {
+	_, ok := outputWriters[format]
+	return ok
+}
+
+// OutputFormats returns the registered format names
+func OutputFormats() []string {
+	formats := make([]string, 0, len(outputWriters))
+	for format := range outputWriters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
@@ -0,0 +1,85 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+func init() {
+	RegisterOutputWriter(`clusters`, func(w io.Writer, opts OutputOptions) OutputWriter {
+		return &clustersWriter{w: w}
+	})
+}
+
+// clustersWriter writes a block per duplicate group, listing every member
+// linked by email or name
+type clustersWriter struct {
+	w       io.Writer
+	members []Member
+	groups  map[string]bool
+}
+
+func (c *clustersWriter) Write(r *Report) error {
+	if c.groups == nil {
+		c.groups = map[string]bool{}
+	}
+	// only clusters with a result remain after filtering
+	for _, res := range r.Results {
+		if res.DuplicateGroup != "" {
+			c.groups[res.DuplicateGroup] = true
+		}
+	}
+	c.members = append(c.members, r.Members...)
+	return nil
+}
+
+func (c *clustersWriter) Flush() error {
+	clusters := map[string][]Member{}
+	for _, m := range c.members {
+		if c.groups[m.DuplicateGroup] {
+			clusters[m.DuplicateGroup] = append(clusters[m.DuplicateGroup], m)
+		}
+	}
+
+	groups := make([]string, 0, len(clusters))
+	for g := range clusters {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	if len(groups) == 0 {
+		_, err := fmt.Fprintln(c.w, "No duplicates found.")
+		return err
+	}
+
+	for i, g := range groups {
+		members := clusters[g]
+		sort.SliceStable(members, func(i, j int) bool {
+			if members[i].Email != members[j].Email {
+				return members[i].Email < members[j].Email
+			}
+			return members[i].Org < members[j].Org
+		})
+
+		if i > 0 {
+			fmt.Fprintln(c.w)
+		}
+		fmt.Fprintf(c.w, "%s (%d members)\n", g, len(members))
+
+		tw := tabwriter.NewWriter(c.w, 0, 0, 2, ' ', 0)
+		for _, m := range members {
+			lastAuth := "never"
+			if m.LastAuth != nil {
+				lastAuth = m.LastAuth.Format("2006-01-02")
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", m.Email, m.Name, m.Org, m.Role, lastAuth)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
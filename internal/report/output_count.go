@@ -0,0 +1,46 @@
+package report
+
+import (
+	"fmt"
+	"io"
+)
+
+func init() {
+	RegisterOutputWriter(`count`, func(w io.Writer, opts OutputOptions) OutputWriter {
+		return &countWriter{w: w, changes: opts.ChangesOnly}
+	})
+}
+
+// partialComment leads the count of a run that stopped before every org
+// loaded, so it can't be mistaken for a complete one
+const partialComment = "# partial: the run stopped before every org loaded"
+
+// countWriter writes the number of results, or of changes since the last run
+type countWriter struct {
+	w       io.Writer
+	changes bool
+	count   int
+	partial bool
+}
+
+func (c *countWriter) Write(r *Report) error {
+	c.partial = c.partial || r.Partial
+	if c.changes {
+		if r.Changes != nil {
+			c.count += len(r.Changes.Added) + len(r.Changes.Removed) + len(r.Changes.Changed)
+		}
+		return nil
+	}
+	c.count += len(r.Results)
+	return nil
+}
+
+func (c *countWriter) Flush() error {
+	if c.partial {
+		if _, err := fmt.Fprintln(c.w, partialComment); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(c.w, c.count)
+	return err
+}
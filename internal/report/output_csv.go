@@ -0,0 +1,195 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterOutputWriter(`csv`, func(w io.Writer, opts OutputOptions) OutputWriter {
+		if opts.ChangesOnly {
+			return &csvChangesWriter{w: csv.NewWriter(w)}
+		}
+		return newCSVWriter(w)
+	})
+}
+
+// csvWriter writes every loaded member as a row of CSV
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+	labels      []string
+	enrichment  []string
+	sso         bool
+	partial     bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(r *Report) error {
+	if !c.wroteHeader {
+		c.labels = labelKeys(r.Members)
+		header := []string{"email", "name", "org", "role", "last_sso_auth", "duplicate_group"}
+		for _, l := range c.labels {
+			header = append(header, "label_"+l)
+		}
+		c.enrichment = enrichmentKeys(r.Members)
+		header = append(header, c.enrichment...)
+		for _, m := range r.Members {
+			c.sso = c.sso || m.SSO != nil
+		}
+		if c.sso {
+			header = append(header, "sso_state", "sso_created_at", "sso_expired_at", "sso_revoked_at", "sso_session_destroyed_at")
+		}
+		c.partial = r.Partial
+		if c.partial {
+			header = append(header, "partial")
+		}
+		if err := c.w.Write(header); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+
+	for _, member := range r.Members {
+		lastAuth := ""
+
+		if member.LastAuth != nil {
+			lastAuth = member.LastAuth.Format(`2006-01-02 15:04:05`)
+		}
+
+		row := []string{
+			member.Email,
+			member.Name,
+			member.Org,
+			member.Role,
+			lastAuth,
+			member.DuplicateGroup,
+		}
+		for _, l := range c.labels {
+			row = append(row, member.Labels[l])
+		}
+		for _, k := range c.enrichment {
+			row = append(row, member.Enrichment[k])
+		}
+		if c.sso {
+			row = append(row, ssoColumns(member.SSO)...)
+		}
+		if c.partial {
+			row = append(row, "true")
+		}
+
+		if err := c.w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ssoColumns returns the state and timestamps of an SSO authorization
+func ssoColumns(sso *SSODetails) []string {
+	if sso == nil {
+		return []string{"", "", "", "", ""}
+	}
+
+	format := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(`2006-01-02 15:04:05`)
+	}
+
+	return []string{sso.State, format(&sso.CreatedAt), format(sso.ExpiredAt), format(sso.RevokedAt), format(sso.SessionDestroyedAt)}
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// csvChangesWriter writes the changes since the last run as rows of CSV
+type csvChangesWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+	partial     bool
+}
+
+func (c *csvChangesWriter) Write(r *Report) error {
+	if !c.wroteHeader {
+		header := []string{"change", "email", "name", "org", "role", "fields"}
+		c.partial = r.Partial
+		if c.partial {
+			header = append(header, "partial")
+		}
+		if err := c.w.Write(header); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+
+	if r.Changes == nil {
+		return nil
+	}
+
+	for _, m := range r.Changes.Added {
+		if err := c.writeRow("added", m, ""); err != nil {
+			return err
+		}
+	}
+	for _, m := range r.Changes.Removed {
+		if err := c.writeRow("removed", m, ""); err != nil {
+			return err
+		}
+	}
+	for _, ch := range r.Changes.Changed {
+		if err := c.writeRow("changed", ch.After, strings.Join(ch.Fields, " ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *csvChangesWriter) writeRow(change string, m Member, fields string) error {
+	row := []string{change, m.Email, m.Name, m.Org, m.Role, fields}
+	if c.partial {
+		row = append(row, "true")
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvChangesWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// labelKeys returns the sorted keys of every label on the members
+func labelKeys(members []Member) []string {
+	return mapKeys(members, func(m Member) map[string]string { return m.Labels })
+}
+
+// enrichmentKeys returns the sorted columns joined onto the members
+func enrichmentKeys(members []Member) []string {
+	return mapKeys(members, func(m Member) map[string]string { return m.Enrichment })
+}
+
+func mapKeys(members []Member, values func(Member) map[string]string) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range members {
+		for k := range values(m) {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,62 @@
+package report
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "update the golden output in testdata")
+
+// TestOutputGolden compares the output of each format with testdata/golden,
+// run with -update to rewrite it after an intended change
+func TestOutputGolden(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time {
+		return time.Date(2026, 4, 1, 9, 30, 0, 0, time.UTC)
+	}
+
+	for _, format := range OutputFormats() {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w, err := NewOutputWriter(format, &buf, testOutputOptions(t, format))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Write(testReport()); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", "golden", format+".golden")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v, run with -update to write it", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				got := buf.String()
+				if !utf8.Valid(buf.Bytes()) {
+					got = fmt.Sprintf("%d bytes of binary output", buf.Len())
+				}
+				t.Errorf("%s output differs from %s, run with -update if it's intended:\n%s", format, path, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package report
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+//go:embed templates/report.html
+var htmlTemplate string
+
+func init() {
+	RegisterOutputWriter(`html`, func(w io.Writer, opts OutputOptions) OutputWriter {
+		return &htmlWriter{w: w}
+	})
+}
+
+// htmlWriter writes a self-contained HTML report with a member table, per-org
+// seat charts and the duplicates found
+type htmlWriter struct {
+	w       io.Writer
+	members []Member
+	results []MemberWithDuplicates
+	partial bool
+	changes *Changes
+}
+
+type htmlData struct {
+	Generated  time.Time
+	Partial    bool
+	Changes    *Changes
+	Members    []Member
+	Duplicates []MemberWithDuplicates
+	Orgs       []htmlOrg
+	// Enrichment are the columns joined onto members from --enrich-csv
+	Enrichment []string
+}
+
+type htmlOrg struct {
+	Name  string
+	Count int
+	Width float64
+	Roles []htmlRole
+}
+
+type htmlRole struct {
+	Name  string
+	Class string
+	Count int
+	Width float64
+}
+
+func (h *htmlWriter) Write(r *Report) error {
+	h.members = append(h.members, r.Members...)
+	h.results = append(h.results, r.Results...)
+	h.partial = h.partial || r.Partial
+	h.changes = r.Changes
+	return nil
+}
+
+func (h *htmlWriter) Flush() error {
+	t, err := template.New("report").Parse(htmlTemplate)
+	if err != nil {
+		return err
+	}
+
+	data := htmlData{
+		Generated:  now(),
+		Partial:    h.partial,
+		Changes:    h.changes,
+		Members:    h.members,
+		Orgs:       htmlOrgs(h.members),
+		Enrichment: enrichmentKeys(h.members),
+	}
+
+	for _, r := range h.results {
+		if len(r.EmailDuplicates) > 0 || len(r.NameDuplicates) > 0 {
+			data.Duplicates = append(data.Duplicates, r)
+		}
+	}
+
+	return t.Execute(h.w, data)
+}
+
+func htmlOrgs(members []Member) []htmlOrg {
+	roleCounts := make(map[string]map[string]int)
+	for _, m := range members {
+		if roleCounts[m.Org] == nil {
+			roleCounts[m.Org] = make(map[string]int)
+		}
+		roleCounts[m.Org][m.Role]++
+	}
+
+	var orgs []htmlOrg
+	var max int
+
+	for name, roles := range roleCounts {
+		org := htmlOrg{Name: name}
+		for role, count := range roles {
+			org.Count += count
+			class := role
+			if class != `admin` && class != `member` {
+				class = `other`
+			}
+			org.Roles = append(org.Roles, htmlRole{Name: role, Class: class, Count: count})
+		}
+		if org.Count > max {
+			max = org.Count
+		}
+		sort.Slice(org.Roles, func(i, j int) bool {
+			return org.Roles[i].Name < org.Roles[j].Name
+		})
+		orgs = append(orgs, org)
+	}
+
+	for i := range orgs {
+		orgs[i].Width = float64(orgs[i].Count) / float64(max) * 80
+		for j := range orgs[i].Roles {
+			orgs[i].Roles[j].Width = float64(orgs[i].Roles[j].Count) / float64(orgs[i].Count) * 100
+		}
+	}
+
+	sort.Slice(orgs, func(i, j int) bool {
+		return orgs[i].Name < orgs[j].Name
+	})
+
+	return orgs
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hokaccha/go-prettyjson"
+)
+
+func init() {
+	RegisterOutputWriter(`json`, func(w io.Writer, opts OutputOptions) OutputWriter {
+		return &jsonWriter{
+			w:       w,
+			query:   opts.Query,
+			color:   opts.Color,
+			compact: opts.Compact,
+			changes: opts.ChangesOnly,
+			results: []MemberWithDuplicates{},
+		}
+	})
+}
+
+// jsonWriter writes the results as a JSON array
+type jsonWriter struct {
+	w       io.Writer
+	query   *Query
+	color   bool
+	compact bool
+	changes bool
+	results []MemberWithDuplicates
+	diff    *Changes
+	partial bool
+}
+
+func (j *jsonWriter) Write(r *Report) error {
+	j.results = append(j.results, r.Results...)
+	j.diff = r.Changes
+	j.partial = j.partial || r.Partial
+	return nil
+}
+
+func (j *jsonWriter) Flush() error {
+	var doc interface{} = j.results
+	if j.changes {
+		doc = j.diff
+		if j.diff == nil {
+			doc = Diff(nil, nil)
+		}
+	}
+
+	// partial results are wrapped, so they can't be mistaken for a complete run
+	if j.partial {
+		key := "results"
+		if j.changes {
+			key = "changes"
+		}
+		doc = map[string]interface{}{"partial": true, key: doc}
+	}
+
+	if j.query == nil {
+		return j.writeValue(doc)
+	}
+
+	values, err := j.query.Run(doc)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		if err := j.writeValue(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (j *jsonWriter) writeValue(v interface{}) error {
+	var b []byte
+	var err error
+
+	if j.compact {
+		b, err = json.Marshal(v)
+	} else {
+		f := prettyjson.NewFormatter()
+		f.DisabledColor = !j.color
+		b, err = f.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(j.w, string(b))
+	return err
+}
@@ -0,0 +1,218 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+func init() {
+	RegisterOutputWriter(`pdf`, func(w io.Writer, opts OutputOptions) OutputWriter {
+		return &pdfWriter{w: w}
+	})
+}
+
+// pdfWriter writes a paginated PDF with a summary, the duplicates found and
+// a table of members
+type pdfWriter struct {
+	w       io.Writer
+	members []Member
+	results []MemberWithDuplicates
+	partial bool
+	changes *Changes
+}
+
+func (p *pdfWriter) Write(r *Report) error {
+	p.members = append(p.members, r.Members...)
+	p.results = append(p.results, r.Results...)
+	p.partial = p.partial || r.Partial
+	p.changes = r.Changes
+	return nil
+}
+
+func (p *pdfWriter) Flush() error {
+	generated := now()
+
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetTitle("Buildkite Accounts", true)
+	pdf.SetCreator("buildkite-accounter", true)
+	pdf.SetCreationDate(generated)
+	pdf.SetModificationDate(generated)
+	// sorted objects make the same report produce the same bytes
+	pdf.SetCatalogSort(true)
+	pdf.AliasNbPages("")
+
+	// the core fonts only support cp1252, so translate names and emails
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 8, fmt.Sprintf("Generated %s - Page %d of {nb}",
+			generated.Format("2006-01-02 15:04 MST"), pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 12, "Buildkite Accounts", "", 1, "L", false, 0, "")
+
+	if p.partial {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetTextColor(170, 51, 51)
+		pdf.CellFormat(0, 8, "Partial results: the run stopped before every org loaded.", "", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	}
+
+	// summary of seats per org and role
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 10, "Summary", "", 1, "L", false, 0, "")
+
+	summary := [][]string{}
+	counts := map[string]map[string]int{}
+	for _, m := range p.members {
+		if counts[m.Org] == nil {
+			counts[m.Org] = map[string]int{}
+		}
+		counts[m.Org][m.Role]++
+	}
+	orgs := make([]string, 0, len(counts))
+	for org := range counts {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+	for _, org := range orgs {
+		total := 0
+		for _, n := range counts[org] {
+			total += n
+		}
+		summary = append(summary, []string{
+			org,
+			fmt.Sprint(counts[org][`admin`]),
+			fmt.Sprint(counts[org][`member`]),
+			fmt.Sprint(total),
+		})
+	}
+	summary = append(summary, []string{"Total", "", "", fmt.Sprint(len(p.members))})
+	pdfTable(pdf, tr, []string{"Org", "Admins", "Members", "Seats"}, []float64{90, 40, 40, 40}, summary)
+
+	// changes since the last run
+	if p.changes != nil {
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "B", 13)
+		pdf.CellFormat(0, 10, "Changes since last run", "", 1, "L", false, 0, "")
+
+		changes := [][]string{}
+		for _, m := range p.changes.Added {
+			changes = append(changes, []string{"added", m.Email, m.Org, m.Role, ""})
+		}
+		for _, m := range p.changes.Removed {
+			changes = append(changes, []string{"removed", m.Email, m.Org, m.Role, ""})
+		}
+		for _, c := range p.changes.Changed {
+			changes = append(changes, []string{"changed", c.After.Email, c.After.Org, c.After.Role, strings.Join(c.Fields, ", ")})
+		}
+		if len(changes) == 0 {
+			pdf.SetFont("Helvetica", "", 10)
+			pdf.CellFormat(0, 8, "No changes since the last run.", "", 1, "L", false, 0, "")
+		} else {
+			pdfTable(pdf, tr, []string{"Change", "Email", "Org", "Role", "Fields"},
+				[]float64{30, 85, 50, 30, 75}, changes)
+		}
+	}
+
+	// duplicates
+	pdf.Ln(4)
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 10, "Duplicates", "", 1, "L", false, 0, "")
+
+	duplicates := [][]string{}
+	for _, r := range p.results {
+		for _, d := range r.EmailDuplicates {
+			duplicates = append(duplicates, []string{r.DuplicateGroup, r.Email, r.Org, d.Email, d.Org, "email"})
+		}
+		for _, d := range r.NameDuplicates {
+			duplicates = append(duplicates, []string{r.DuplicateGroup, r.Email, r.Org, d.Email, d.Org, fmt.Sprintf("name (%.2f)", d.Similarity)})
+		}
+	}
+	if len(duplicates) == 0 {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 8, "No duplicates found.", "", 1, "L", false, 0, "")
+	} else {
+		pdfTable(pdf, tr, []string{"Group", "Email", "Org", "Duplicate", "Duplicate Org", "Match"},
+			[]float64{30, 65, 40, 65, 40, 30}, duplicates)
+	}
+
+	// members
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 10, "Members", "", 1, "L", false, 0, "")
+
+	header := []string{"Email", "Name", "Org", "Role", "Last SSO Auth", "Duplicate Group"}
+	widths := []float64{75, 60, 45, 25, 30, 35}
+
+	// enrichment columns take 30mm each from the others
+	enrichment := enrichmentKeys(p.members)
+	if len(enrichment) > 0 {
+		scale := (270 - 30*float64(len(enrichment))) / 270
+		if scale < 0.4 {
+			scale = 0.4
+		}
+		for i := range widths {
+			widths[i] *= scale
+		}
+		for _, k := range enrichment {
+			header = append(header, k)
+			widths = append(widths, 30)
+		}
+	}
+
+	rows := [][]string{}
+	for _, m := range p.members {
+		lastAuth := ""
+		if m.LastAuth != nil {
+			lastAuth = m.LastAuth.Format("2006-01-02")
+		}
+		row := []string{m.Email, m.Name, m.Org, m.Role, lastAuth, m.DuplicateGroup}
+		for _, k := range enrichment {
+			row = append(row, m.Enrichment[k])
+		}
+		rows = append(rows, row)
+	}
+	pdfTable(pdf, tr, header, widths, rows)
+
+	return pdf.Output(p.w)
+}
+
+// pdfTable writes a table, repeating the header row on each new page
+func pdfTable(pdf *fpdf.Fpdf, tr func(string) string, header []string, widths []float64, rows [][]string) {
+	const lineHeight = 7
+
+	writeHeader := func() {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetFillColor(235, 235, 235)
+		for i, h := range header {
+			pdf.CellFormat(widths[i], lineHeight, h, "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+
+	writeHeader()
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+
+	for _, row := range rows {
+		if pdf.GetY()+lineHeight > pageHeight-bottom-12 {
+			pdf.AddPage()
+			writeHeader()
+		}
+		for i, cell := range row {
+			pdf.CellFormat(widths[i], lineHeight, tr(cell), "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+}
@@ -0,0 +1,36 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+)
+
+func init() {
+	RegisterOutputWriter(`template`, func(w io.Writer, opts OutputOptions) OutputWriter {
+		return &templateWriter{w: w, template: opts.Template}
+	})
+}
+
+// templateWriter renders the complete report through a template
+type templateWriter struct {
+	w        io.Writer
+	template *template.Template
+	data     TemplateData
+}
+
+func (t *templateWriter) Write(r *Report) error {
+	t.data.Members = append(t.data.Members, r.Members...)
+	t.data.Results = append(t.data.Results, r.Results...)
+	t.data.Partial = t.data.Partial || r.Partial
+	t.data.Changes = r.Changes
+	return nil
+}
+
+func (t *templateWriter) Flush() error {
+	if t.template == nil {
+		return fmt.Errorf("template output requires a report template")
+	}
+	t.data.Generated = now()
+	return t.template.Execute(t.w, t.data)
+}
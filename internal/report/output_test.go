@@ -0,0 +1,142 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+// testReport returns a report of two orgs with a duplicate and a stale member
+func testReport() *Report {
+	lastAuth := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	members := []Member{
+		authorizedAt(testMember("acme", "alice@acme.com", "Alice Smith"), lastAuth),
+		testMember("llama", "alice@acme.com", "Alice Smith"),
+		testMember("acme", "bob@contractor.com", "Bob Jones"),
+	}
+	members[0].Role = "admin"
+
+	results := Group(members, GroupOptions{})
+	AssignDuplicateGroups(members, results)
+
+	return &Report{
+		Orgs:    []string{"acme", "llama"},
+		Members: members,
+		Results: Dedupe(results, DedupeOptions{Email: true}),
+	}
+}
+
+// testOutputOptions are the options for writing a format in tests
+func testOutputOptions(t *testing.T, format string) OutputOptions {
+	opts := OutputOptions{Compact: true}
+	if format == `template` {
+		tmpl, err := template.New("test").Parse("{{range .Results}}{{.Email}} in {{.Org}}\n{{end}}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts.Template = tmpl
+	}
+	return opts
+}
+
+func TestOutputWriters(t *testing.T) {
+	// a format added without a case here fails, so every writer is tested
+	want := map[string][]string{
+		`clusters`: {"alice@acme.com"},
+		`count`:    {"2\n"},
+		`csv`:      {"email,name,org,role", "alice@acme.com,Alice Smith,acme,admin,2026-03-01 12:00:00", "bob@contractor.com,Bob Jones,acme,member"},
+		`html`:     {"<html", "alice@acme.com", "bob@contractor.com"},
+		`json`:     {`"email":"alice@acme.com"`, `"email":"bob@contractor.com"`},
+		`pdf`:      {"%PDF-"},
+		`template`: {"alice@acme.com in acme\nbob@contractor.com in acme\n"},
+	}
+
+	formats := make([]string, 0, len(want))
+	for format := range want {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	if got := OutputFormats(); !reflect.DeepEqual(got, formats) {
+		t.Fatalf("OutputFormats() = %v, want tests for each of them", got)
+	}
+
+	for _, format := range OutputFormats() {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w, err := NewOutputWriter(format, &buf, testOutputOptions(t, format))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Write(testReport()); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, s := range want[format] {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("%s output doesn't contain %q:\n%s", format, s, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestNewOutputWriterUnknownFormat(t *testing.T) {
+	if _, err := NewOutputWriter("yaml", &bytes.Buffer{}, OutputOptions{}); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestOutputPartial(t *testing.T) {
+	tests := []struct {
+		format  string
+		changes bool
+		want    string
+	}{
+		{format: `json`, want: `"partial":true,"results":[`},
+		{format: `json`, changes: true, want: `{"changes":{"added":[`},
+		{format: `csv`, want: "email,name,org,role,last_sso_auth,duplicate_group,partial\nalice@acme.com,Alice Smith,acme,admin,2026-03-01 12:00:00,dup-"},
+		{format: `csv`, changes: true, want: "change,email,name,org,role,fields,partial\nadded,carol@acme.com,Carol,acme,member,,true\n"},
+		{format: `count`, want: "# partial: the run stopped before every org loaded\n2\n"},
+		{format: `count`, changes: true, want: "# partial: the run stopped before every org loaded\n1\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%s changes %v", tc.format, tc.changes), func(t *testing.T) {
+			rep := testReport()
+			rep.Changes = &Changes{Added: []Member{testMember("acme", "carol@acme.com", "Carol")}}
+
+			for _, partial := range []bool{false, true} {
+				rep.Partial = partial
+
+				var buf bytes.Buffer
+				w, err := NewOutputWriter(tc.format, &buf, OutputOptions{Compact: true, ChangesOnly: tc.changes})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := w.Write(rep); err != nil {
+					t.Fatal(err)
+				}
+				if err := w.Flush(); err != nil {
+					t.Fatal(err)
+				}
+
+				if got := strings.Contains(buf.String(), tc.want); got != partial {
+					t.Errorf("partial %v output contains %q = %v:\n%s", partial, tc.want, got, buf.String())
+				}
+				if got := strings.Contains(buf.String(), "partial"); got != partial {
+					t.Errorf("partial %v output mentions partial = %v:\n%s", partial, got, buf.String())
+				}
+			}
+		})
+	}
+}
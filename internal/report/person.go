@@ -0,0 +1,120 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+)
+
+// Person is everything known about one person across orgs
+type Person struct {
+	Email       string       `json:"email"`
+	Memberships []Membership `json:"memberships"`
+	// EmailDuplicates are other members sharing an email with one of the
+	// memberships, like an account whose SSO email is the person's email
+	EmailDuplicates []Member `json:"email_duplicates"`
+	// NameDuplicates are other members with a similar name
+	NameDuplicates []NameDuplicate `json:"name_duplicates"`
+}
+
+// Membership is a person's membership of an org
+type Membership struct {
+	Member
+	// AccountEmail is the email of the Buildkite account, which can differ
+	// from the SSO email in Email
+	AccountEmail string           `json:"account_email"`
+	CreatedAt    time.Time        `json:"created_at"`
+	Teams        []MembershipTeam `json:"teams"`
+}
+
+// MembershipTeam is a team a member belongs to
+type MembershipTeam struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// FindPerson finds the memberships with an account or SSO email matching
+// email, along with their teams and duplicates. Memberships are in the
+// order of orgSlugs
+func FindPerson(email string, orgSlugs []string, members map[string][]buildkite.OrgMember, teams map[string][]buildkite.Team, opts GroupOptions) (Person, error) {
+	person := Person{Email: email, Memberships: []Membership{}, EmailDuplicates: []Member{}, NameDuplicates: []NameDuplicate{}}
+
+	var all []Member
+	matched := map[string]bool{}
+
+	for _, orgSlug := range orgSlugs {
+		for _, om := range members[orgSlug] {
+			m, err := NewMember(orgSlug, om)
+			if err != nil {
+				return Person{}, err
+			}
+			all = append(all, m)
+
+			if !strings.EqualFold(om.Email, email) && (om.Authorization == nil || !strings.EqualFold(om.Authorization.Email, email)) {
+				continue
+			}
+
+			matched[orgSlug+"/"+om.ID] = true
+			person.Memberships = append(person.Memberships, Membership{
+				Member:       m,
+				AccountEmail: om.Email,
+				CreatedAt:    om.CreatedAt,
+				Teams:        memberTeams(teams[orgSlug], om.Email),
+			})
+		}
+	}
+
+	if len(person.Memberships) == 0 {
+		return person, nil
+	}
+
+	emails := map[string]bool{}
+	for _, m := range person.Memberships {
+		emails[m.Email] = true
+	}
+
+	for _, m := range all {
+		key := m.Org + "/" + m.ID
+		if emails[m.Email] && !matched[key] {
+			matched[key] = true
+			person.EmailDuplicates = append(person.EmailDuplicates, m)
+		}
+	}
+
+	for _, res := range Group(all, opts) {
+		if !emails[res.Email] {
+			continue
+		}
+		for _, d := range res.NameDuplicates {
+			key := d.Org + "/" + d.ID
+			if matched[key] {
+				continue
+			}
+			matched[key] = true
+			person.NameDuplicates = append(person.NameDuplicates, d)
+		}
+	}
+
+	sort.SliceStable(person.NameDuplicates, func(i, j int) bool {
+		return person.NameDuplicates[i].Similarity > person.NameDuplicates[j].Similarity
+	})
+
+	return person, nil
+}
+
+// memberTeams returns the teams with a member with the account email
+func memberTeams(teams []buildkite.Team, email string) []MembershipTeam {
+	result := []MembershipTeam{}
+	for _, t := range teams {
+		for _, tm := range t.Members {
+			if strings.EqualFold(tm.Email, email) {
+				result = append(result, MembershipTeam{Slug: t.Slug, Name: t.Name, Role: strings.ToLower(tm.Role)})
+				break
+			}
+		}
+	}
+	return result
+}
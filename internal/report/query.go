@@ -0,0 +1,58 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// Query is a compiled jq query applied to the result document
+type Query struct {
+	code *gojq.Code
+}
+
+// CompileQuery compiles a jq query like `map(select(.role == "admin")) | length`
+func CompileQuery(source string) (*Query, error) {
+	parsed, err := gojq.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	code, err := gojq.Compile(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	return &Query{code: code}, nil
+}
+
+// Run applies the query to v, returning each value it produces
+func (q *Query) Run(v interface{}) ([]interface{}, error) {
+	// gojq only operates on plain JSON values, so round trip through json
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var input interface{}
+	if err := json.Unmarshal(b, &input); err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+
+	iter := q.code.Run(input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := v.(error); ok {
+			return nil, fmt.Errorf("query failed: %w", err)
+		}
+		values = append(values, v)
+	}
+
+	return values, nil
+}
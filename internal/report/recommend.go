@@ -0,0 +1,130 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// Recommendation actions
+const (
+	ActionRemove = "remove"
+	ActionReview = "review"
+)
+
+// Recommendation is a concrete action to reclaim a seat, with the evidence for it
+type Recommendation struct {
+	Action  string   `json:"action"`
+	Member  Member   `json:"member"`
+	Reasons []string `json:"reasons"`
+	// MonthlySavings is the price of the seat, if a price was provided
+	MonthlySavings float64 `json:"monthly_savings,omitempty"`
+
+	score int
+}
+
+// String describes the recommendation like "remove X from org Y — no auth in 180d"
+func (r Recommendation) String() string {
+	return fmt.Sprintf("%s %s from org %s — %s", r.Action, r.Member.Email, r.Member.Org, strings.Join(r.Reasons, ", "))
+}
+
+// Recommend combines staleness, duplicate accounts within an org and bots into
+// recommendations, most certain first. Complimentary seats cost nothing so are
+// never recommended. pricePerSeat may be zero if unknown.
+func Recommend(members []Member, staleAfter time.Duration, pricePerSeat float64) []Recommendation {
+	byName := map[string][]Member{}
+	byEmail := map[string][]Member{}
+	for _, m := range members {
+		if m.Name != "" {
+			key := m.Org + "/" + NameKey(m.Name, language.Und)
+			byName[key] = append(byName[key], m)
+		}
+		key := m.Org + "/" + strings.ToLower(m.Email)
+		byEmail[key] = append(byEmail[key], m)
+	}
+
+	var result []Recommendation
+
+	for _, m := range members {
+		if m.Complimentary {
+			continue
+		}
+
+		r := Recommendation{Member: m}
+
+		if m.IsStale(staleAfter) {
+			if m.LastAuth == nil {
+				r.Reasons = append(r.Reasons, "never authorized with SSO")
+			} else {
+				r.Reasons = append(r.Reasons, fmt.Sprintf("no auth in %dd", int(time.Since(*m.LastAuth).Hours()/24)))
+			}
+			r.score += 2
+		}
+
+		// only the less recently authorized of duplicate accounts is redundant
+		var dupes []Member
+		dupes = append(dupes, byEmail[m.Org+"/"+strings.ToLower(m.Email)]...)
+		dupes = append(dupes, byName[m.Org+"/"+NameKey(m.Name, language.Und)]...)
+		for _, d := range dupes {
+			if d.ID == m.ID || !authorizedBefore(m, d) {
+				continue
+			}
+			r.Reasons = append(r.Reasons, "duplicate of "+d.Email)
+			r.score += 2
+			break
+		}
+
+		if m.Bot {
+			r.Reasons = append(r.Reasons, "bot account")
+			r.score++
+		}
+
+		if len(r.Reasons) == 0 {
+			continue
+		}
+
+		// a bot on its own needs a human to confirm it's unused
+		r.Action = ActionRemove
+		if r.score < 2 {
+			r.Action = ActionReview
+		}
+
+		r.MonthlySavings = pricePerSeat
+		result = append(result, r)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].score != result[j].score {
+			return result[i].score > result[j].score
+		}
+		return lastAuthBefore(result[i].Member, result[j].Member)
+	})
+
+	return result
+}
+
+// authorizedBefore returns whether a last authorized strictly before b, or
+// never authorized while b did, ordering by ID to break ties
+func authorizedBefore(a, b Member) bool {
+	switch {
+	case a.LastAuth == nil && b.LastAuth == nil:
+		return a.ID > b.ID
+	case a.LastAuth == nil:
+		return true
+	case b.LastAuth == nil:
+		return false
+	case a.LastAuth.Equal(*b.LastAuth):
+		return a.ID > b.ID
+	}
+	return a.LastAuth.Before(*b.LastAuth)
+}
+
+func lastAuthBefore(a, b Member) bool {
+	if a.LastAuth == nil || b.LastAuth == nil {
+		return a.LastAuth == nil && b.LastAuth != nil
+	}
+	return a.LastAuth.Before(*b.LastAuth)
+}
@@ -0,0 +1,176 @@
+package report
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/directory"
+)
+
+// Unreconciled is someone who is a Buildkite member but not in a directory,
+// or the other way around
+type Unreconciled struct {
+	// OnlyIn is where they were found, buildkite or the directory's name
+	OnlyIn string `json:"only_in"`
+	// Org is the Buildkite org of a member, empty for people in the directory
+	Org   string `json:"org,omitempty"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Role  string `json:"role,omitempty"`
+}
+
+// Reconcile matches the members of Buildkite orgs with the people of a
+// directory named source by their account and SSO identity emails, ignoring
+// case, and returns those who are only in one or the other. Members who are
+// bots are skipped
+func Reconcile(source string, orgSlugs []string, members map[string][]buildkite.OrgMember, people []directory.Person) []Unreconciled {
+	inDirectory := map[string]bool{}
+	for _, p := range people {
+		for _, e := range p.Emails {
+			inDirectory[strings.ToLower(e)] = true
+		}
+	}
+
+	var result []Unreconciled
+	inBuildkite := map[string]bool{}
+
+	for _, orgSlug := range orgSlugs {
+		for _, m := range members[orgSlug] {
+			if m.Bot {
+				continue
+			}
+
+			emails := []string{strings.ToLower(m.Email)}
+			if m.Authorization != nil && m.Authorization.Email != "" {
+				emails = append(emails, strings.ToLower(m.Authorization.Email))
+			}
+
+			found := false
+			for _, e := range emails {
+				inBuildkite[e] = true
+				found = found || inDirectory[e]
+			}
+			if found {
+				continue
+			}
+
+			result = append(result, Unreconciled{
+				OnlyIn: "buildkite",
+				Org:    orgSlug,
+				ID:     m.ID,
+				Name:   m.Name,
+				Email:  m.Email,
+				Role:   strings.ToLower(m.Role),
+			})
+		}
+	}
+
+	for _, p := range people {
+		found := false
+		for _, e := range p.Emails {
+			found = found || inBuildkite[strings.ToLower(e)]
+		}
+		if found {
+			continue
+		}
+
+		var email string
+		if len(p.Emails) > 0 {
+			email = p.Emails[0]
+		}
+		result = append(result, Unreconciled{
+			OnlyIn: source,
+			ID:     p.ID,
+			Name:   p.Name,
+			Email:  email,
+		})
+	}
+
+	// buildkite members first, as they're the seats to reclaim
+	sort.SliceStable(result, func(i, j int) bool {
+		if (result[i].OnlyIn == "buildkite") != (result[j].OnlyIn == "buildkite") {
+			return result[i].OnlyIn == "buildkite"
+		}
+		return strings.ToLower(result[i].Email) < strings.ToLower(result[j].Email)
+	})
+
+	return result
+}
+
+// Deprovisioned is a member whose account in a directory is disabled or
+// missing, which usually means they've been offboarded
+type Deprovisioned struct {
+	Org   string `json:"org"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+	// Status is disabled or missing
+	Status string `json:"status"`
+}
+
+// DeprovisionedMembers returns the members of Buildkite orgs whose account
+// and SSO identity emails don't match an enabled account among people,
+// ignoring case. Members who are bots are skipped
+func DeprovisionedMembers(orgSlugs []string, members map[string][]buildkite.OrgMember, people []directory.Person) []Deprovisioned {
+	enabled := map[string]bool{}
+	disabled := map[string]bool{}
+	for _, p := range people {
+		for _, e := range p.Emails {
+			if p.Disabled {
+				disabled[strings.ToLower(e)] = true
+			} else {
+				enabled[strings.ToLower(e)] = true
+			}
+		}
+	}
+
+	var result []Deprovisioned
+	for _, orgSlug := range orgSlugs {
+		for _, m := range members[orgSlug] {
+			if m.Bot {
+				continue
+			}
+
+			emails := []string{strings.ToLower(m.Email)}
+			if m.Authorization != nil && m.Authorization.Email != "" {
+				emails = append(emails, strings.ToLower(m.Authorization.Email))
+			}
+
+			status := "missing"
+			for _, e := range emails {
+				if enabled[e] {
+					status = ""
+					break
+				}
+				if disabled[e] {
+					status = "disabled"
+				}
+			}
+			if status == "" {
+				continue
+			}
+
+			result = append(result, Deprovisioned{
+				Org:    orgSlug,
+				ID:     m.ID,
+				Name:   m.Name,
+				Email:  m.Email,
+				Role:   strings.ToLower(m.Role),
+				Status: status,
+			})
+		}
+	}
+
+	// disabled accounts first, as they're the clearest offboardings
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Status != result[j].Status {
+			return result[i].Status == "disabled"
+		}
+		return strings.ToLower(result[i].Email) < strings.ToLower(result[j].Email)
+	})
+
+	return result
+}
@@ -0,0 +1,68 @@
+// Package report turns Buildkite org members into reports, in distinct
+// Load, Group, Filter, Dedupe and Render stages
+package report
+
+import (
+	"time"
+)
+
+// Logf is a printf style function for debug logging
+type Logf func(format string, v ...interface{})
+
+// Member is an org member
+type Member struct {
+	ID            string     `json:"id"`
+	Email         string     `json:"email"`
+	Domain        string     `json:"domain"`
+	Name          string     `json:"name"`
+	Org           string     `json:"org"`
+	Role          string     `json:"role"`
+	LastAuth      *time.Time `json:"last_auth"`
+	Complimentary bool       `json:"complimentary,omitempty"`
+	Bot           bool       `json:"bot,omitempty"`
+	// Labels are attached to the member's org in the config file
+	Labels map[string]string `json:"labels,omitempty"`
+	// Enrichment are the columns joined onto the member from --enrich-csv
+	Enrichment map[string]string `json:"enrichment,omitempty"`
+	// DuplicateGroup identifies the members linked by email or name
+	// duplicates, empty if the member has none
+	DuplicateGroup string `json:"duplicate_group,omitempty"`
+	// SSO is the member's most recent SSO authorization, if requested
+	SSO *SSODetails `json:"sso,omitempty"`
+	// MembershipID identifies the membership of the org for mutations
+	MembershipID string `json:"-"`
+}
+
+// SSODetails describe an SSO authorization
+type SSODetails struct {
+	State              string     `json:"state"`
+	CreatedAt          time.Time  `json:"created_at"`
+	ExpiredAt          *time.Time `json:"expired_at"`
+	RevokedAt          *time.Time `json:"revoked_at"`
+	SessionDestroyedAt *time.Time `json:"session_destroyed_at"`
+}
+
+// IsStale returns whether the member has no SSO authorization within staleAfter
+func (m Member) IsStale(staleAfter time.Duration) bool {
+	return m.staleAt(time.Now(), staleAfter)
+}
+
+func (m Member) staleAt(t time.Time, staleAfter time.Duration) bool {
+	return m.LastAuth == nil || t.Sub(*m.LastAuth) > staleAfter
+}
+
+// MemberWithDuplicates is a member along with other members that share their email or name
+type MemberWithDuplicates struct {
+	Member
+	NameDuplicates  []NameDuplicate `json:"name_duplicates,omitempty"`
+	EmailDuplicates []Member        `json:"email_duplicates,omitempty"`
+	// Absorbed are the members deduped into this one, if explained
+	Absorbed []Absorbed `json:"absorbed,omitempty"`
+}
+
+// NameDuplicate is a member with the same or a similar name
+type NameDuplicate struct {
+	Member
+	// Similarity of the names from 0 to 1, where 1 is identical
+	Similarity float64 `json:"similarity"`
+}
@@ -0,0 +1,33 @@
+package report
+
+import (
+	"strings"
+	"time"
+)
+
+// testMember returns a member of an org, with its ID derived from the email
+func testMember(org, email, name string) Member {
+	return Member{
+		ID:     "user-" + email,
+		Email:  email,
+		Domain: email[strings.Index(email, "@")+1:],
+		Name:   name,
+		Org:    org,
+		Role:   "member",
+	}
+}
+
+// authorizedAt returns the member with a last SSO authorization at t
+func authorizedAt(m Member, t time.Time) Member {
+	m.LastAuth = &t
+	return m
+}
+
+// emails returns the emails of the results, in order
+func emails(results []MemberWithDuplicates) []string {
+	out := []string{}
+	for _, r := range results {
+		out = append(out, r.Email)
+	}
+	return out
+}
@@ -0,0 +1,200 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ParseTemplate parses a text/template file used to render a complete report,
+// with helper functions for counting, grouping and date math
+func ParseTemplate(path string) (*template.Template, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := template.New(filepath.Base(path)).Funcs(templateFuncs()).Parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("invalid report template: %w", err)
+	}
+
+	return t, nil
+}
+
+// TemplateData is the data available to report templates
+type TemplateData struct {
+	Generated time.Time
+	Members   []Member
+	Results   []MemberWithDuplicates
+	// Partial is true if the run was interrupted before every org loaded
+	Partial bool
+	// Changes are the changes in members since the last run, if there was one
+	Changes *Changes
+}
+
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"now":       time.Now,
+		"since":     time.Since,
+		"daysSince": daysSince,
+		"olderThan": olderThan,
+		"date":      formatDate,
+		"field":     memberField,
+		"where":     whereField,
+		"groupBy":   groupBy,
+		"countBy":   countBy,
+		"sortBy":    sortBy,
+		"percent":   percent,
+		"join":      strings.Join,
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+	}
+}
+
+// memberField returns a member field by its JSON name, e.g. "org" or "labels.region"
+func memberField(name string, m Member) (string, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return "", err
+	}
+
+	var v interface{} = fields
+	for _, key := range strings.Split(name, ".") {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return "", nil
+		}
+		v = obj[key]
+	}
+
+	if v == nil {
+		return "", nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+func whereField(name, value string, members []Member) ([]Member, error) {
+	var matching []Member
+	for _, m := range members {
+		v, err := memberField(name, m)
+		if err != nil {
+			return nil, err
+		}
+		if v == value {
+			matching = append(matching, m)
+		}
+	}
+	return matching, nil
+}
+
+func groupBy(name string, members []Member) (map[string][]Member, error) {
+	groups := make(map[string][]Member)
+	for _, m := range members {
+		v, err := memberField(name, m)
+		if err != nil {
+			return nil, err
+		}
+		groups[v] = append(groups[v], m)
+	}
+	return groups, nil
+}
+
+func countBy(name string, members []Member) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, m := range members {
+		v, err := memberField(name, m)
+		if err != nil {
+			return nil, err
+		}
+		counts[v]++
+	}
+	return counts, nil
+}
+
+func sortBy(name string, members []Member) ([]Member, error) {
+	keys := make([]string, len(members))
+	for i, m := range members {
+		v, err := memberField(name, m)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = v
+	}
+
+	sorted := make([]Member, len(members))
+	copy(sorted, members)
+
+	idx := make([]int, len(members))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return keys[idx[i]] < keys[idx[j]]
+	})
+	for i, j := range idx {
+		sorted[i] = members[j]
+	}
+
+	return sorted, nil
+}
+
+func daysSince(t *time.Time) int {
+	if t == nil {
+		return -1
+	}
+	return int(time.Since(*t).Hours() / 24)
+}
+
+// olderThan returns whether t is older than a duration like 90d or 36h, nil
+// times are always older
+func olderThan(d string, t *time.Time) (bool, error) {
+	dur, err := ParseDuration(d)
+	if err != nil {
+		return false, err
+	}
+	return t == nil || time.Since(*t) > dur, nil
+}
+
+func formatDate(layout string, t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+func percent(n, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)/float64(total)*100)
+}
+
+// ParseDuration parses a duration like time.ParseDuration, with additional
+// support for days (d) and weeks (w)
+func ParseDuration(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,99 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Thresholds are limits on the members of each org, zero values are unlimited
+type Thresholds struct {
+	MaxSeats  int
+	MaxAdmins int
+	// AllowedDomains are the email domains members may have, any if empty
+	AllowedDomains []string
+}
+
+// Kinds of threshold breach
+const (
+	BreachSeats   = "seats"
+	BreachAdmins  = "admins"
+	BreachDomains = "domains"
+)
+
+// Breach is a threshold breached by an org
+type Breach struct {
+	Org     string
+	Kind    string
+	Summary string
+	// Details are specifics like counts and the members responsible
+	Details map[string]interface{}
+}
+
+// CheckThresholds returns a breach for each org and kind of threshold it
+// breaches, and the kinds of threshold that are set, ordered by org
+func CheckThresholds(orgSlugs []string, members []Member, t Thresholds) (breaches []Breach, kinds []string) {
+	if t.MaxSeats > 0 {
+		kinds = append(kinds, BreachSeats)
+	}
+	if t.MaxAdmins > 0 {
+		kinds = append(kinds, BreachAdmins)
+	}
+	if len(t.AllowedDomains) > 0 {
+		kinds = append(kinds, BreachDomains)
+	}
+
+	allowed := map[string]bool{}
+	for _, d := range t.AllowedDomains {
+		allowed[strings.ToLower(d)] = true
+	}
+
+	byOrg := map[string][]Member{}
+	for _, m := range members {
+		byOrg[m.Org] = append(byOrg[m.Org], m)
+	}
+
+	for _, org := range orgSlugs {
+		var seats, admins int
+		var unauthorized []string
+		for _, m := range byOrg[org] {
+			if !m.Complimentary {
+				seats++
+			}
+			if m.Role == "admin" {
+				admins++
+			}
+			if len(allowed) > 0 && !allowed[strings.ToLower(m.Domain)] {
+				unauthorized = append(unauthorized, m.Email)
+			}
+		}
+
+		if t.MaxSeats > 0 && seats > t.MaxSeats {
+			breaches = append(breaches, Breach{
+				Org:     org,
+				Kind:    BreachSeats,
+				Summary: fmt.Sprintf("%s has %d seats, over the limit of %d", org, seats, t.MaxSeats),
+				Details: map[string]interface{}{"seats": seats, "limit": t.MaxSeats},
+			})
+		}
+		if t.MaxAdmins > 0 && admins > t.MaxAdmins {
+			breaches = append(breaches, Breach{
+				Org:     org,
+				Kind:    BreachAdmins,
+				Summary: fmt.Sprintf("%s has %d admins, over the limit of %d", org, admins, t.MaxAdmins),
+				Details: map[string]interface{}{"admins": admins, "limit": t.MaxAdmins},
+			})
+		}
+		if len(unauthorized) > 0 {
+			sort.Strings(unauthorized)
+			breaches = append(breaches, Breach{
+				Org:     org,
+				Kind:    BreachDomains,
+				Summary: fmt.Sprintf("%s has %d members with unauthorized email domains", org, len(unauthorized)),
+				Details: map[string]interface{}{"members": unauthorized, "allowed_domains": t.AllowedDomains},
+			})
+		}
+	}
+
+	return breaches, kinds
+}
@@ -0,0 +1,66 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+)
+
+// UsageRow is the compute used by the builds of a pipeline or creator
+type UsageRow struct {
+	Org      string  `json:"org"`
+	Pipeline string  `json:"pipeline,omitempty"`
+	Creator  string  `json:"creator,omitempty"`
+	Builds   int     `json:"builds"`
+	Jobs     int     `json:"jobs"`
+	Minutes  float64 `json:"minutes"`
+}
+
+// Usage sums the jobs and job minutes of builds per org and pipeline, or per
+// org and build creator if byCreator is true, most minutes first. Builds
+// without a creator, like scheduled builds, are attributed to "unknown".
+func Usage(builds []buildkite.Build, byCreator bool) []UsageRow {
+	index := map[[2]string]*UsageRow{}
+	var rows []*UsageRow
+
+	for _, b := range builds {
+		row := UsageRow{Org: b.Org}
+		if byCreator {
+			row.Creator = "unknown"
+			if b.Creator != nil {
+				row.Creator = b.Creator.Email
+			}
+		} else {
+			row.Pipeline = b.Pipeline
+		}
+
+		key := [2]string{row.Org, row.Pipeline + row.Creator}
+		r, ok := index[key]
+		if !ok {
+			r = &row
+			index[key] = r
+			rows = append(rows, r)
+		}
+
+		var d time.Duration
+		for _, j := range b.Jobs {
+			d += j.Duration()
+		}
+
+		r.Builds++
+		r.Jobs += len(b.Jobs)
+		r.Minutes += d.Minutes()
+	}
+
+	result := make([]UsageRow, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, *r)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Minutes > result[j].Minutes
+	})
+
+	return result
+}
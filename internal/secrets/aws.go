@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// ReadAWSSecret reads a secret from AWS Secrets Manager using the default AWS
+// credential chain. The id is a secret ARN or name, optionally followed by
+// #key to read a key from a secret stored as a JSON object.
+func ReadAWSSecret(id string) (string, error) {
+	ctx := context.Background()
+
+	secretID, key := id, ""
+	if i := strings.LastIndex(id, "#"); i >= 0 {
+		secretID, key = id[:i], id[i+1:]
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if a, err := arn.Parse(secretID); err == nil && a.Region != "" {
+		opts = append(opts, config.WithRegion(a.Region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to load aws config: %w", err)
+	}
+
+	out, err := secretsmanager.NewFromConfig(cfg).GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret %s: %w", secretID, err)
+	}
+
+	value := aws.ToString(out.SecretString)
+	if out.SecretString == nil {
+		value = string(out.SecretBinary)
+	}
+
+	if key != "" {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &fields); err != nil {
+			return "", fmt.Errorf("secret %s isn't a JSON object: %w", secretID, err)
+		}
+		v, ok := fields[key].(string)
+		if !ok {
+			return "", fmt.Errorf("secret %s has no string key %q", secretID, key)
+		}
+		value = v
+	}
+
+	return strings.TrimSpace(value), nil
+}
@@ -0,0 +1,193 @@
+// Package secrets resolves API tokens from external secret stores
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// OnePasswordRef is a parsed 1Password secret reference like op://vault/item/field
+// or op://vault/item/section/field
+type OnePasswordRef struct {
+	Vault   string
+	Item    string
+	Section string
+	Field   string
+}
+
+// ParseOnePasswordRef parses an op:// secret reference
+func ParseOnePasswordRef(ref string) (OnePasswordRef, error) {
+	if !strings.HasPrefix(ref, "op://") {
+		return OnePasswordRef{}, fmt.Errorf("1password reference %q must start with op://", ref)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(ref, "op://"), "/")
+	for _, p := range parts {
+		if p == "" {
+			return OnePasswordRef{}, fmt.Errorf("1password reference %q has an empty component", ref)
+		}
+	}
+
+	switch len(parts) {
+	case 3:
+		return OnePasswordRef{Vault: parts[0], Item: parts[1], Field: parts[2]}, nil
+	case 4:
+		return OnePasswordRef{Vault: parts[0], Item: parts[1], Section: parts[2], Field: parts[3]}, nil
+	}
+
+	return OnePasswordRef{}, fmt.Errorf("1password reference %q must be op://vault/item/[section/]field", ref)
+}
+
+// OnePassword resolves op:// references with 1Password Connect if ConnectHost
+// is set, otherwise with the op CLI
+type OnePassword struct {
+	ConnectHost  string
+	ConnectToken string
+	HTTPClient   *http.Client
+}
+
+// NewOnePassword returns a OnePassword configured from the standard
+// OP_CONNECT_HOST and OP_CONNECT_TOKEN environment variables
+func NewOnePassword() *OnePassword {
+	return &OnePassword{
+		ConnectHost:  os.Getenv("OP_CONNECT_HOST"),
+		ConnectToken: os.Getenv("OP_CONNECT_TOKEN"),
+	}
+}
+
+// Read returns the value of the field a reference points at
+func (o *OnePassword) Read(ref string) (string, error) {
+	r, err := ParseOnePasswordRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	var value string
+	if o.ConnectHost != "" {
+		value, err = o.readConnect(r)
+	} else {
+		value, err = o.readCLI(ref)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from 1password: %w", ref, err)
+	}
+
+	return strings.TrimSpace(value), nil
+}
+
+func (o *OnePassword) readCLI(ref string) (string, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("op", "read", "--no-newline", ref)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+func (o *OnePassword) readConnect(r OnePasswordRef) (string, error) {
+	var vaults []struct {
+		ID string `json:"id"`
+	}
+	if err := o.get("/v1/vaults", fmt.Sprintf("name eq %q", r.Vault), &vaults); err != nil {
+		return "", err
+	}
+	if len(vaults) == 0 {
+		return "", fmt.Errorf("vault %q not found", r.Vault)
+	}
+
+	var items []struct {
+		ID string `json:"id"`
+	}
+	if err := o.get("/v1/vaults/"+vaults[0].ID+"/items", fmt.Sprintf("title eq %q", r.Item), &items); err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", fmt.Errorf("item %q not found in vault %q", r.Item, r.Vault)
+	}
+
+	var item struct {
+		Sections []struct {
+			ID    string `json:"id"`
+			Label string `json:"label"`
+		} `json:"sections"`
+		Fields []struct {
+			ID      string `json:"id"`
+			Label   string `json:"label"`
+			Value   string `json:"value"`
+			Section *struct {
+				ID string `json:"id"`
+			} `json:"section"`
+		} `json:"fields"`
+	}
+	if err := o.get("/v1/vaults/"+vaults[0].ID+"/items/"+items[0].ID, "", &item); err != nil {
+		return "", err
+	}
+
+	sectionID := ""
+	if r.Section != "" {
+		for _, s := range item.Sections {
+			if s.Label == r.Section || s.ID == r.Section {
+				sectionID = s.ID
+			}
+		}
+		if sectionID == "" {
+			return "", fmt.Errorf("section %q not found in item %q", r.Section, r.Item)
+		}
+	}
+
+	for _, f := range item.Fields {
+		if f.Label != r.Field && f.ID != r.Field {
+			continue
+		}
+		if sectionID != "" && (f.Section == nil || f.Section.ID != sectionID) {
+			continue
+		}
+		return f.Value, nil
+	}
+
+	return "", fmt.Errorf("field %q not found in item %q", r.Field, r.Item)
+}
+
+func (o *OnePassword) get(path string, filter string, v interface{}) error {
+	u := strings.TrimSuffix(o.ConnectHost, "/") + path
+	if filter != "" {
+		u += "?filter=" + url.QueryEscape(filter)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+o.ConnectToken)
+
+	httpClient := o.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("1password connect returned status %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,122 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Vault reads secrets from HashiCorp Vault's KV secrets engine, either version
+type Vault struct {
+	Addr       string
+	Token      string
+	Namespace  string
+	HTTPClient *http.Client
+}
+
+// NewVault returns a Vault configured from the standard VAULT_ADDR,
+// VAULT_TOKEN and VAULT_NAMESPACE environment variables, falling back to the
+// token helper file ~/.vault-token
+func NewVault() (*Vault, error) {
+	v := &Vault{
+		Addr:      os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+	}
+
+	if v.Addr == "" {
+		return nil, fmt.Errorf("VAULT_ADDR is required to read secrets from vault")
+	}
+
+	if v.Token == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+		if err != nil {
+			return nil, fmt.Errorf("VAULT_TOKEN or ~/.vault-token is required to read secrets from vault")
+		}
+		v.Token = strings.TrimSpace(string(b))
+	}
+
+	return v, nil
+}
+
+// Read returns a key of a secret from a reference like secret/buildkite#token
+func (v *Vault) Read(ref string) (string, error) {
+	i := strings.LastIndex(ref, "#")
+	if i <= 0 || i == len(ref)-1 {
+		return "", fmt.Errorf("vault reference %q must be path#key", ref)
+	}
+	path, key := strings.Trim(ref[:i], "/"), ref[i+1:]
+
+	var mount struct {
+		Data struct {
+			Path    string `json:"path"`
+			Options struct {
+				Version string `json:"version"`
+			} `json:"options"`
+		} `json:"data"`
+	}
+	if err := v.get("sys/internal/ui/mounts/"+path, &mount); err != nil {
+		return "", fmt.Errorf("failed to read mount of %s from vault: %w", path, err)
+	}
+
+	kv2 := mount.Data.Options.Version == "2"
+	if kv2 {
+		mountPath := strings.Trim(mount.Data.Path, "/")
+		path = mountPath + "/data/" + strings.TrimPrefix(strings.TrimPrefix(path, mountPath), "/")
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := v.get(path, &secret); err != nil {
+		return "", fmt.Errorf("failed to read %s from vault: %w", path, err)
+	}
+
+	data := secret.Data
+	if kv2 {
+		data, _ = secret.Data["data"].(map[string]interface{})
+	}
+
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no string key %q", path, key)
+	}
+
+	return strings.TrimSpace(value), nil
+}
+
+func (v *Vault) get(path string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(v.Addr, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	httpClient := v.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault returned status %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,75 @@
+package state
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDB stores state as items in a DynamoDB table with a string partition
+// key named key, using the default AWS credential chain. Items are limited to
+// 400KB, so very large orgs are better kept in S3.
+type DynamoDB struct {
+	Table string
+}
+
+func (d *DynamoDB) client(ctx context.Context) (*dynamodb.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws config: %w", err)
+	}
+	return dynamodb.NewFromConfig(cfg), nil
+}
+
+// Get implements Store
+func (d *DynamoDB) Get(key string) ([]byte, error) {
+	ctx := context.Background()
+
+	client, err := d.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.Table),
+		Key:            map[string]types.AttributeValue{"key": &types.AttributeValueMemberS{Value: key}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s from dynamodb table %s: %w", key, d.Table, err)
+	}
+
+	data, ok := out.Item["data"].(*types.AttributeValueMemberB)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return data.Value, nil
+}
+
+// Put implements Store
+func (d *DynamoDB) Put(key string, data []byte) error {
+	ctx := context.Background()
+
+	client, err := d.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.Table),
+		Item: map[string]types.AttributeValue{
+			"key":  &types.AttributeValueMemberS{Value: key},
+			"data": &types.AttributeValueMemberB{Value: data},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put %s to dynamodb table %s: %w", key, d.Table, err)
+	}
+
+	return nil
+}
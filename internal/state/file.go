@@ -0,0 +1,46 @@
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// File stores state as files in a local directory
+type File struct {
+	Dir string
+}
+
+// Get implements Store
+func (f *File) Get(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(f.Dir, key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Put implements Store
+func (f *File) Put(key string, data []byte) error {
+	if err := os.MkdirAll(f.Dir, 0700); err != nil {
+		return err
+	}
+
+	// write then rename, so concurrent runs never read a partial file
+	tmp, err := ioutil.TempFile(f.Dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(f.Dir, key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
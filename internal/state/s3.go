@@ -0,0 +1,75 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3 stores state as objects in an S3 bucket using the default AWS credential
+// chain
+type S3 struct {
+	Bucket string
+	Prefix string
+}
+
+func (s *S3) client(ctx context.Context) (*s3.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws config: %w", err)
+	}
+	return s3.NewFromConfig(cfg), nil
+}
+
+// Get implements Store
+func (s *S3) Get(key string) ([]byte, error) {
+	ctx := context.Background()
+
+	client, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(path.Join(s.Prefix, key)),
+	})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get s3://%s/%s: %w", s.Bucket, path.Join(s.Prefix, key), err)
+	}
+	defer out.Body.Close()
+
+	return ioutil.ReadAll(out.Body)
+}
+
+// Put implements Store
+func (s *S3) Put(key string, data []byte) error {
+	ctx := context.Background()
+
+	client, err := s.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(path.Join(s.Prefix, key)),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put s3://%s/%s: %w", s.Bucket, path.Join(s.Prefix, key), err)
+	}
+
+	return nil
+}
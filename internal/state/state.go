@@ -0,0 +1,52 @@
+// Package state keeps state between runs, like the members seen by the last
+// run, in a local directory, S3 or DynamoDB
+package state
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrNotFound is returned when there's no state stored for a key
+var ErrNotFound = errors.New("state not found")
+
+// Store gets and puts state by key
+type Store interface {
+	// Get returns the state stored for key, or ErrNotFound
+	Get(key string) ([]byte, error)
+	// Put stores the state for key, replacing any previous state
+	Put(key string, data []byte) error
+}
+
+// Open returns the store for a URI like s3://bucket/prefix or
+// dynamodb://table. Anything else is a local directory, optionally as a
+// file:// URI.
+func Open(uri string) (Store, error) {
+	if !strings.Contains(uri, "://") {
+		return &File{Dir: uri}, nil
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state store %q: %w", uri, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		return &File{Dir: u.Host + u.Path}, nil
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid state store %q: missing bucket", uri)
+		}
+		return &S3{Bucket: u.Host, Prefix: strings.TrimPrefix(u.Path, "/")}, nil
+	case "dynamodb":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid state store %q: missing table", uri)
+		}
+		return &DynamoDB{Table: u.Host}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported state store %q, expected a path, s3:// or dynamodb://", uri)
+}
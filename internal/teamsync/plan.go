@@ -0,0 +1,154 @@
+package teamsync
+
+import (
+	"strings"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+)
+
+// Actions that a change makes to a team
+const (
+	ActionAdd    = "add"
+	ActionUpdate = "update"
+	ActionRemove = "remove"
+)
+
+// Roles of team members, as they appear in specs and changes
+const (
+	RoleMember     = "member"
+	RoleMaintainer = "maintainer"
+)
+
+// Change is a change to a team's membership
+type Change struct {
+	Action string `json:"action"`
+	Org    string `json:"org"`
+	Team   string `json:"team"`
+	Email  string `json:"email"`
+	// Role is the role to add or update the member to, or the role of the
+	// removed member
+	Role string `json:"role"`
+	// FromRole is the role an updated member had
+	FromRole string `json:"from_role,omitempty"`
+
+	TeamID       string `json:"-"`
+	UserID       string `json:"-"`
+	TeamMemberID string `json:"-"`
+}
+
+// Problem is part of the spec that can't be planned, like a team that doesn't
+// exist or an email that isn't a member of the org
+type Problem struct {
+	Org    string `json:"org"`
+	Team   string `json:"team"`
+	Email  string `json:"email,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Plan returns the changes that make the teams in the spec match it, given
+// the current teams and members of each org. Each team's additions come
+// first, then role updates, then removals
+func Plan(spec *Spec, teams map[string][]buildkite.Team, members map[string][]buildkite.OrgMember) ([]Change, []Problem) {
+	changes := []Change{}
+	problems := []Problem{}
+
+	for _, ts := range spec.Teams {
+		team := findTeam(teams[ts.Org], ts.Team)
+		if team == nil {
+			problems = append(problems, Problem{Org: ts.Org, Team: ts.Team, Reason: "team doesn't exist"})
+			continue
+		}
+
+		current := map[string]buildkite.TeamMember{}
+		for _, tm := range team.Members {
+			current[tm.UserID] = tm
+		}
+
+		desired := map[string]bool{}
+		var adds, updates, removes []Change
+
+		for _, want := range desiredMembers(ts) {
+			userID, ok := findUser(members[ts.Org], want.email)
+			if !ok {
+				problems = append(problems, Problem{Org: ts.Org, Team: ts.Team, Email: want.email, Reason: "not a member of the org, invite them first"})
+				continue
+			}
+			if desired[userID] {
+				problems = append(problems, Problem{Org: ts.Org, Team: ts.Team, Email: want.email, Reason: "the same person as another email in the team"})
+				continue
+			}
+			desired[userID] = true
+
+			change := Change{Org: ts.Org, Team: ts.Team, Email: want.email, Role: want.role, TeamID: team.ID, UserID: userID}
+
+			tm, ok := current[userID]
+			switch {
+			case !ok:
+				change.Action = ActionAdd
+				adds = append(adds, change)
+			case strings.ToLower(tm.Role) != want.role:
+				change.Action = ActionUpdate
+				change.FromRole = strings.ToLower(tm.Role)
+				change.TeamMemberID = tm.ID
+				updates = append(updates, change)
+			}
+		}
+
+		for _, tm := range team.Members {
+			if desired[tm.UserID] {
+				continue
+			}
+			removes = append(removes, Change{
+				Action:       ActionRemove,
+				Org:          ts.Org,
+				Team:         ts.Team,
+				Email:        tm.Email,
+				Role:         strings.ToLower(tm.Role),
+				TeamID:       team.ID,
+				UserID:       tm.UserID,
+				TeamMemberID: tm.ID,
+			})
+		}
+
+		changes = append(changes, adds...)
+		changes = append(changes, updates...)
+		changes = append(changes, removes...)
+	}
+
+	return changes, problems
+}
+
+type desiredMember struct {
+	email string
+	role  string
+}
+
+func desiredMembers(ts TeamSpec) []desiredMember {
+	var result []desiredMember
+	for _, email := range ts.Maintainers {
+		result = append(result, desiredMember{email: email, role: RoleMaintainer})
+	}
+	for _, email := range ts.Members {
+		result = append(result, desiredMember{email: email, role: RoleMember})
+	}
+	return result
+}
+
+func findTeam(teams []buildkite.Team, slug string) *buildkite.Team {
+	for i := range teams {
+		if teams[i].Slug == slug {
+			return &teams[i]
+		}
+	}
+	return nil
+}
+
+// findUser returns the user ID of the org member with the account or SSO email
+func findUser(members []buildkite.OrgMember, email string) (string, bool) {
+	for _, m := range members {
+		if strings.EqualFold(m.Email, email) || (m.Authorization != nil && strings.EqualFold(m.Authorization.Email, email)) {
+			return m.ID, true
+		}
+	}
+	return "", false
+}
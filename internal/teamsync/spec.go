@@ -0,0 +1,87 @@
+// Package teamsync plans the changes that make team memberships match a
+// declarative spec
+package teamsync
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the desired members of teams
+type Spec struct {
+	Teams []TeamSpec `yaml:"teams"`
+}
+
+// TeamSpec is the desired members of a team, by their account or SSO email.
+// Teams that aren't in the spec are left alone
+type TeamSpec struct {
+	Org         string   `yaml:"org"`
+	Team        string   `yaml:"team"`
+	Maintainers []string `yaml:"maintainers"`
+	Members     []string `yaml:"members"`
+}
+
+// LoadSpec reads a spec file, failing on unknown keys, teams listed twice and
+// emails listed twice in a team
+func LoadSpec(path string) (*Spec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec Spec
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec %s: %w", path, err)
+	}
+
+	if err := spec.validate(); err != nil {
+		return nil, fmt.Errorf("invalid spec %s: %w", path, err)
+	}
+
+	return &spec, nil
+}
+
+func (s *Spec) validate() error {
+	teams := map[string]bool{}
+	for i, t := range s.Teams {
+		if t.Org == "" || t.Team == "" {
+			return fmt.Errorf("teams[%d] needs an org and a team", i)
+		}
+
+		key := t.Org + "/" + t.Team
+		if teams[key] {
+			return fmt.Errorf("team %s is listed more than once", key)
+		}
+		teams[key] = true
+
+		emails := map[string]bool{}
+		for _, email := range append(append([]string{}, t.Maintainers...), t.Members...) {
+			if emails[strings.ToLower(email)] {
+				return fmt.Errorf("%s is listed more than once in team %s", email, key)
+			}
+			emails[strings.ToLower(email)] = true
+		}
+	}
+	return nil
+}
+
+// OrgSlugs returns the slugs of the orgs with teams in the spec
+func (s *Spec) OrgSlugs() []string {
+	seen := map[string]bool{}
+	var slugs []string
+	for _, t := range s.Teams {
+		if !seen[t.Org] {
+			seen[t.Org] = true
+			slugs = append(slugs, t.Org)
+		}
+	}
+	sort.Strings(slugs)
+	return slugs
+}
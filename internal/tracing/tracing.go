@@ -0,0 +1,111 @@
+// Package tracing traces runs with OpenTelemetry, exporting spans over OTLP
+// when the standard OTEL_EXPORTER_OTLP_* environment variables configure an
+// endpoint. Without one, spans are no-ops
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/lox/buildkite-accounter"
+
+// Enabled returns whether the environment configures an OTLP endpoint for
+// traces, and doesn't disable them
+func Enabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	if exporter := os.Getenv("OTEL_TRACES_EXPORTER"); exporter != "" && exporter != "otlp" {
+		return false
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup exports spans over OTLP if Enabled, with the protocol, headers,
+// sampler and resource attributes of the standard environment variables,
+// returning a func that flushes the spans and shuts the exporter down. A
+// TRACEPARENT in the environment, like from a CI job's trace, becomes the
+// parent of the returned context
+func Setup(ctx context.Context, version string) (context.Context, func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+
+	if !Enabled() {
+		return ctx, noop, nil
+	}
+
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+
+	var exporter *otlptrace.Exporter
+	var err error
+	switch protocol {
+	case "", "http/protobuf":
+		exporter, err = otlptracehttp.New(ctx)
+	case "grpc":
+		exporter, err = otlptracegrpc.New(ctx)
+	default:
+		return ctx, noop, fmt.Errorf("unsupported OTLP protocol %q, use http/protobuf or grpc", protocol)
+	}
+	if err != nil {
+		return ctx, noop, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", "buildkite-accounter"),
+			attribute.String("service.version", version),
+		),
+		resource.WithFromEnv(),
+		resource.WithHost(),
+		resource.WithProcessPID(),
+	)
+	if err != nil {
+		return ctx, noop, fmt.Errorf("failed to describe the trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	propagator := propagation.TraceContext{}
+	otel.SetTextMapPropagator(propagator)
+
+	if traceparent := os.Getenv("TRACEPARENT"); traceparent != "" {
+		ctx = propagator.Extract(ctx, propagation.MapCarrier{"traceparent": traceparent})
+	}
+
+	return ctx, provider.Shutdown, nil
+}
+
+// Start starts a span, as a child of any span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends a span, marking it as failed with err if it's not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
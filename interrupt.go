@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/mattn/go-isatty"
+)
+
+// notifyInterrupt returns a channel that is closed on the first SIGINT or
+// SIGTERM, after which default handling is restored so that a second signal
+// exits immediately. The returned func stops listening for signals.
+func notifyInterrupt() (<-chan struct{}, func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	interrupt := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-sigs:
+			signal.Stop(sigs)
+			log.Printf("Received %v, stopping after the orgs loaded so far (again to exit immediately)", sig)
+			close(interrupt)
+		case <-done:
+		}
+	}()
+
+	return interrupt, func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+// confirmPartial asks on stderr whether to write partial results, which is
+// only possible if stdin is a terminal
+func confirmPartial(loaded int) bool {
+	if !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "Write partial results for the %d orgs loaded? [y/N] ", loaded)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/notify"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+// jiraTicket is a member that needs remediating and why
+type jiraTicket struct {
+	member  report.Member
+	reasons []string
+}
+
+// createJiraTickets opens or updates a Jira issue for each member that became
+// stale since the previous run, if there was one, or matches --jira-policy
+func (r *reportCmd) createJiraTickets(c *cli, rep *report.Report, previous *lastRun, policy *report.Expression) error {
+	staleAfter, err := report.ParseDuration(c.StaleAfter)
+	if err != nil {
+		return err
+	}
+
+	var tickets []*jiraTicket
+	byMember := map[string]*jiraTicket{}
+	flag := func(m report.Member, reason string) {
+		key := m.Org + "/" + m.ID
+		if byMember[key] == nil {
+			byMember[key] = &jiraTicket{member: m}
+			tickets = append(tickets, byMember[key])
+		}
+		byMember[key].reasons = append(byMember[key].reasons, reason)
+	}
+
+	if previous != nil {
+		for _, m := range report.BecameStale(previous.Members, previous.Time, rep.Members, staleAfter) {
+			flag(m, fmt.Sprintf("no SSO authorization in %s", c.StaleAfter))
+		}
+	}
+
+	if policy != nil {
+		for _, m := range rep.Members {
+			ok, err := policy.Match(m)
+			if err != nil {
+				return err
+			}
+			if ok {
+				flag(m, fmt.Sprintf("violates the policy %s", r.JiraPolicy))
+			}
+		}
+	}
+
+	j := &notify.Jira{
+		URL:       r.JiraURL,
+		User:      r.JiraUser,
+		Token:     r.JiraToken,
+		Project:   r.JiraProject,
+		IssueType: r.JiraIssueType,
+	}
+
+	for _, t := range tickets {
+		key, created, err := j.Upsert(t.ticket())
+		if err != nil {
+			return err
+		}
+		if created {
+			log.Printf("Opened %s for %s in %s", key, t.member.Email, t.member.Org)
+		} else if c.Debug {
+			log.Printf("Updated %s for %s in %s", key, t.member.Email, t.member.Org)
+		}
+	}
+
+	return nil
+}
+
+// ticket describes the member as a Jira ticket, labelled with a hash of their
+// org and ID so the same issue is found again
+func (t *jiraTicket) ticket() notify.Ticket {
+	m := t.member
+	sum := sha256.Sum256([]byte(m.Org + "/" + m.ID))
+
+	lastAuth := "never"
+	if m.LastAuth != nil {
+		lastAuth = m.LastAuth.UTC().Format(time.RFC3339)
+	}
+
+	var reasons strings.Builder
+	for _, reason := range t.reasons {
+		reasons.WriteString("* " + reason + "\n")
+	}
+
+	return notify.Ticket{
+		Label:   "buildkite-member-" + hex.EncodeToString(sum[:6]),
+		Summary: fmt.Sprintf("Review Buildkite access of %s in %s", m.Email, m.Org),
+		Description: fmt.Sprintf("%s (%s) needs review:\n\n%s\n"+
+			"||Org|%s|\n||Member ID|%s|\n||Role|%s|\n||Last SSO authorization|%s|\n",
+			m.Name, m.Email, reasons.String(), m.Org, m.ID, m.Role, lastAuth),
+	}
+}
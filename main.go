@@ -1,294 +1,722 @@
 package main
 
 import (
-	"encoding/csv"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/alecthomas/kong"
-	"github.com/hokaccha/go-prettyjson"
-	"github.com/lox/buildkite-accounter/internal/buildkite"
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/internal/config"
+	"github.com/lox/buildkite-accounter/internal/report"
+	"github.com/lox/buildkite-accounter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/text/language"
 )
 
 func main() {
-	c := &cli{}
+	start := time.Now()
+	c := &cli{ctx: context.Background()}
 	ctx := kong.Parse(c)
-	err := ctx.Run()
+	err := c.loadConfig()
+	c.resolveCacheDir()
+	if err == nil {
+		var stopProfiling func()
+		stopProfiling, err = c.startProfiling()
+		if err == nil {
+			var stopTracing func(error)
+			stopTracing, err = c.startTracing(ctx.Command())
+			if err == nil {
+				err = ctx.Run(c)
+				c.waitForRefreshes()
+				stopTracing(err)
+			}
+			stopProfiling()
+		}
+	}
+	if c.Timings && c.client != nil {
+		printTimings(c.client.Stats(), time.Since(start))
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		ctx.Errorf("%v", exitErr.err)
+		printHint(err)
+		os.Exit(exitErr.code)
+	}
 	if err != nil && c.Debug {
-		ctx.Fatalf("%+v", err)
+		ctx.Errorf("%+v", err)
+		printHint(err)
+		os.Exit(1)
+	}
+	if hint := errorHint(err); hint != "" {
+		ctx.Errorf("%v", err)
+		printHint(err)
+		os.Exit(1)
 	}
 	ctx.FatalIfErrorf(err)
 }
 
-type cli struct {
-	Debug    bool     `flag:"" help:"Whether to print debugging"`
-	APIToken string   `flag:"" help:"A Buildkite GraphQL Token" env:"BUILDKITE_TOKEN" required:""`
-	OrgSlugs []string `flag:"" help:"The buildkite org slug"`
-	Cache    bool     `flag:"" help:"Whether to use a disk cache"`
-	CacheDir string   `flag:"" help:"The cache directory" type:"path" default:"./.cache"`
-	Dedupe   []string `flag:"" help:"Ignore subsequent users" enum:"email,name"`
-	Output   string   `flag:"" help:"How to output rows" enum:"count,json,csv" default:"json"`
-	Email    string   `flag:"" help:"Filter by email"`
+const (
+	// exitPartialResults is the exit code when results were written but some
+	// orgs failed to load
+	exitPartialResults = 3
+
+	// exitRequestBudgetExceeded is the exit code when partial results were
+	// written because --max-requests was reached
+	exitRequestBudgetExceeded = 4
+
+	// exitPolicyViolated is the exit code when results were written but
+	// violate a policy, like --max-admins
+	exitPolicyViolated = 5
+
+	// exitInterrupted is the exit code when the run was interrupted by a signal
+	exitInterrupted = 130
+)
+
+// exitError is an error that exits with a specific exit code
+type exitError struct {
+	code int
+	err  error
 }
 
-type Member struct {
-	ID            string     `json:"id"`
-	Email         string     `json:"email"`
-	Domain        string     `json:"domain"`
-	Name          string     `json:"name"`
-	Org           string     `json:"org"`
-	Role          string     `json:"role"`
-	LastAuth      *time.Time `json:"last_auth"`
-	Complimentary bool       `json:"complimentary,omitempty"`
+func (e *exitError) Error() string {
+	return e.err.Error()
 }
 
-type MemberWithDuplicates struct {
-	Member
-	NameDuplicates  []Member `json:"name_duplicates,omitempty"`
-	EmailDuplicates []Member `json:"email_duplicates,omitempty"`
+func (e *exitError) Unwrap() error {
+	return e.err
 }
 
-func (c *cli) Run() error {
-	members, err := c.getMembers()
+func printHint(err error) {
+	if hint := errorHint(err); hint != "" {
+		fmt.Fprintf(os.Stderr, "hint: %s\n", hint)
+	}
+}
+
+// errorHint returns advice for errors from the API
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, buildkite.ErrUnauthorized):
+		return "check the API token is valid and has GraphQL API access"
+	case errors.Is(err, buildkite.ErrOrgNotFound):
+		return "check the org slug is spelled correctly and the API token has access to the org"
+	case errors.Is(err, buildkite.ErrCircuitOpen):
+		return "the API looks degraded, try again later or use --fallback-to-cache"
+	case errors.Is(err, buildkite.ErrUnexpectedShape):
+		return "the Buildkite API may have changed, check for a newer version of buildkite-accounter"
+	case errors.Is(err, report.ErrNotCached):
+		return "run without --offline and with --cache or --fallback-to-cache to cache the org first"
+	case errors.Is(err, buildkite.ErrOffline):
+		return "this command needs data that isn't cached, run it without --offline"
+	case errors.Is(err, buildkite.ErrRateLimited):
+		return "wait for the rate limit to reset, and consider --cache or --max-requests"
+	}
+	return ""
+}
+
+type cli struct {
+	Profile             string   `flag:"" help:"A named profile of flag values from ~/.config/buildkite-accounter/config" env:"BUILDKITE_ACCOUNTER_PROFILE"`
+	Config              string   `flag:"" help:"A YAML config file with org labels" type:"existingfile" env:"BUILDKITE_ACCOUNTER_CONFIG"`
+	Debug               bool     `flag:"" help:"Whether to print debugging"`
+	APIToken            string   `flag:"" help:"A Buildkite GraphQL Token" env:"BUILDKITE_TOKEN"`
+	APITokenFile        string   `flag:"" help:"Read the Buildkite GraphQL Token from this file, or stdin if -, instead of --api-token" env:"BUILDKITE_TOKEN_FILE"`
+	APITokenRef         string   `flag:"" help:"Read the Buildkite GraphQL Token from a 1Password reference like op://vault/item/field, using 1Password Connect if OP_CONNECT_HOST is set or the op CLI otherwise" env:"BUILDKITE_TOKEN_REF"`
+	APITokenSecret      string   `flag:"" help:"Read the Buildkite GraphQL Token from an AWS Secrets Manager secret ARN or name, with #key to read a key of a JSON secret" env:"BUILDKITE_TOKEN_SECRET"`
+	APITokenVault       string   `flag:"" help:"Read the Buildkite GraphQL Token from a HashiCorp Vault KV secret like secret/buildkite#token, using VAULT_ADDR and VAULT_TOKEN" env:"BUILDKITE_TOKEN_VAULT"`
+	Endpoint            string   `flag:"" help:"The Buildkite GraphQL endpoint, or a caching proxy in front of it" default:"https://graphql.buildkite.com/v1"`
+	UserAgent           string   `flag:"" help:"The User-Agent sent to the API, defaults to buildkite-accounter/<version>" env:"BUILDKITE_ACCOUNTER_USER_AGENT"`
+	OrgSlugs            []string `flag:"" help:"The buildkite org slugs, or patterns like acme-* matched against the orgs the token can see"`
+	ExcludeOrgSlugs     []string `flag:"" help:"Org slugs or patterns to exclude"`
+	Cache               bool     `flag:"" help:"Whether to use a disk cache"`
+	CacheStrategy       string   `flag:"" help:"How --cache serves members: cache-first from the cache once it's there, or swr to also refresh the cache in the background for the next run" enum:"cache-first,swr" default:"cache-first"`
+	CacheDir            string   `flag:"" help:"The cache directory, defaults to buildkite-accounter in the user cache dir like ~/.cache" type:"path"`
+	StateStore          string   `flag:"" help:"Where to keep state between runs, like the members seen by the last run: a directory, s3://bucket/prefix or dynamodb://table, defaults to the cache dir" env:"BUILDKITE_ACCOUNTER_STATE_STORE"`
+	Offline             bool     `flag:"" help:"Serve members only from the cache dir, failing for orgs that aren't cached, and never make a network request"`
+	FallbackToCache     bool     `flag:"" help:"Save members to the cache dir, and use them with a warning for orgs that fail to load because the API is unavailable"`
+	Resume              bool     `flag:"" help:"Resume fetching orgs from the checkpoint saved by an interrupted run"`
+	WithSSODetails      bool     `flag:"" name:"with-sso-details" help:"Include each member's SSO authorization state and timestamps in the output"`
+	Dedupe              []string `flag:"" help:"Ignore subsequent users" enum:"email,name"`
+	ExplainDedupe       bool     `flag:"" help:"Annotate each member remaining after --dedupe with the members it absorbed and why, and log a summary"`
+	NameSimilarity      float64  `flag:"" help:"How similar names must be, from 0 to 1, to count as duplicates, e.g. 0.85 to match typos" default:"1"`
+	NameLocale          string   `flag:"" help:"The locale whose case rules apply when comparing names, e.g. tr for Turkish"`
+	ContinueOnError     bool     `flag:"" help:"Continue with the remaining orgs if an org fails to load, exiting with status 3 after writing partial results"`
+	EnrichCSV           string   `flag:"" name:"enrich-csv" help:"Join columns from this CSV file onto members, like a list of people with their cost center and manager" type:"existingfile"`
+	EnrichKey           string   `flag:"" help:"The --enrich-csv column and member field to join on" default:"email"`
+	EnrichColumns       []string `flag:"" help:"The --enrich-csv columns to join, defaults to all of them"`
+	Email               string   `flag:"" help:"Filter by email"`
+	Filter              string   `flag:"" help:"Filter by an expression, e.g. 'member.role == \"admin\" && member.domain != \"acme.com\"'"`
+	StaleAfter          string   `flag:"" help:"How long since the last SSO authorization before a seat is stale" default:"90d"`
+	DatadogAPIKey       string   `flag:"" help:"Submit seat metrics to Datadog with this API key" env:"DATADOG_API_KEY"`
+	DatadogSite         string   `flag:"" help:"The Datadog site to submit metrics to" default:"datadoghq.com" env:"DD_SITE"`
+	StatsdAddr          string   `flag:"" help:"Emit seat metrics over UDP to a StatsD agent at host:port"`
+	StatsdFormat        string   `flag:"" help:"The StatsD protocol to use" enum:"dogstatsd,statsd" default:"dogstatsd"`
+	CloudwatchNamespace string   `flag:"" help:"Put seat metrics to CloudWatch in this namespace, e.g. BuildkiteSeats"`
+	PushgatewayURL      string   `flag:"" help:"Push run metrics to a Prometheus Pushgateway at this URL"`
+	PushgatewayJob      string   `flag:"" help:"The job label for pushed metrics" default:"buildkite-accounter"`
+	PushgatewayInstance string   `flag:"" help:"The instance label for pushed metrics"`
+	LockFile            string   `flag:"" help:"Hold a lock on this file for the run, failing if another run holds it, defaults to run.lock in the cache dir with --quiet or when members are written to the cache" type:"path"`
+	AuditLog            string   `flag:"" help:"Append a JSON line for each mutation, like removing a member, to this file, defaults to audit.log in the cache dir" type:"path"`
+	Concurrency         int      `flag:"" help:"The maximum number of API requests in flight at once, zero for no limit" default:"4"`
+	BatchOrgs           int      `flag:"" help:"Fetch the first page of members of up to this many orgs in one request, cutting requests for many small orgs, one fetches each org separately" default:"1"`
+	Retries             int      `flag:"" help:"Retry queries that fail with errors like 5xx responses up to this many times, mutations are never retried" default:"0"`
+	RetryBackoff        string   `flag:"" help:"How long to wait before the first retry, doubling for each retry after it" default:"1s"`
+	CircuitBreaker      int      `flag:"" help:"Fail requests fast for --circuit-cooldown after this many consecutive requests fail with errors like 5xx responses, zero disables" default:"5"`
+	CircuitCooldown     string   `flag:"" help:"How long the circuit breaker fails requests fast before trying the API again" default:"1m"`
+	Stats               bool     `flag:"" help:"Whether to print API usage statistics"`
+	CPUProfile          string   `flag:"" name:"cpuprofile" help:"Write a CPU profile of the run to this file, for go tool pprof" type:"path"`
+	MemProfile          string   `flag:"" name:"memprofile" help:"Write a heap profile at the end of the run to this file, for go tool pprof" type:"path"`
+	Timings             bool     `flag:"" help:"Print where the run's time went: each org's pages, time per page and decoding, and the time spent on the API versus processing"`
+	Record              string   `flag:"" help:"Record raw GraphQL responses as fixtures in this directory" type:"path" xor:"fixtures"`
+	Replay              string   `flag:"" help:"Replay raw GraphQL responses from fixtures in this directory" type:"path" xor:"fixtures"`
+
+	config *config.Config
+	// token is the API token the client was created with
+	token string
+	// client is the client created last, for --timings
+	client *buildkite.Client
+	// refreshes are background refreshes of the cache by --cache-strategy swr
+	refreshes sync.WaitGroup
+	// fetch is the memoized FetchFunc for org members, shared by everything
+	// in the run that needs them
+	fetch report.FetchFunc
+	// httpMetrics records the API requests of the run's clients
+	httpMetrics *buildkite.MetricsTransport
+	// ctx holds the run's span, the parent of the spans of its stages
+	ctx context.Context
+
+	Report               reportCmd               `cmd:"" default:"withargs" help:"Report on members across orgs"`
+	Stream               streamCmd               `cmd:"" help:"Write members as NDJSON, a line of JSON each, as each page is fetched, in bounded memory for very large orgs"`
+	Serve                serveCmd                `cmd:"" help:"Run as a daemon that refreshes members periodically"`
+	ServeAPI             serveAPICmd             `cmd:"" name:"serve-api" help:"Run as a daemon that also serves members over a REST API"`
+	Proxy                proxyCmd                `cmd:"" help:"Run a caching proxy in front of the GraphQL API for the queries this tool issues"`
+	Usage                usageCmd                `cmd:"" help:"Report build job minutes per pipeline or build creator over a date range"`
+	OrphanedPipelines    orphanedPipelinesCmd    `cmd:"" name:"orphaned-pipelines" help:"Report pipelines whose recent build creators and team maintainers are all stale or removed members"`
+	LingeringCredentials lingeringCredentialsCmd `cmd:"" name:"lingering-credentials" help:"Report members whose SSO authorization was revoked or expired but who still created recent builds"`
+	Mismatches           mismatchesCmd           `cmd:"" help:"Report members whose SSO identity email or name differs from their Buildkite account"`
+	SSOProviders         ssoProvidersCmd         `cmd:"" name:"sso-providers" help:"List each org's SSO providers, their state and session duration, and whether SSO is enforced"`
+	ExpiringSSO          expiringSSOCmd          `cmd:"" name:"expiring-sso" help:"List SSO authorizations that expire within --within, and those that have expired for members still in the orgs"`
+	Idle                 idleCmd                 `cmd:"" help:"Classify members by their last SSO authorization (--stale-after) and last build, listing idle seats that are safe to reclaim"`
+	Admins               adminsCmd               `cmd:"" help:"List the admins of each org, with per-org counts, optionally failing if there are more than --max-admins"`
+	RoleChanges          roleChangesCmd          `cmd:"" name:"role-changes" help:"List members whose role changed since the last report run, promotions to admin first"`
+	InactiveAdmins       inactiveAdminsCmd       `cmd:"" name:"inactive-admins" help:"List admins with no SSO authorization within --stale-after, and optionally no recent builds, as candidates for demotion"`
+	TopDomains           topDomainsCmd           `cmd:"" name:"top-domains" help:"Rank email domains by seats, with their share of seats and stale seats"`
+	Recommend            recommendCmd            `cmd:"" help:"Recommend seats to reclaim based on staleness, duplicate accounts and bots"`
+	Reclaim              reclaimCmd              `cmd:"" help:"Remove the members recommended for removal, confirming each with --interactive or all at once with --yes"`
+	DestroySessions      destroySessionsCmd      `cmd:"" name:"destroy-sessions" help:"Log a member out of the orgs by destroying their sessions"`
+	Offboard             offboardCmd             `cmd:"" help:"Offboard a member by revoking their SSO authorization, destroying their sessions and removing them from the orgs"`
+	Invite               inviteCmd               `cmd:"" help:"Invite the emails in a CSV to an org, skipping existing members and pending invitations"`
+	Invitations          invitationsCmd          `cmd:"" help:"List and revoke pending invitations to the orgs"`
+	Teams                teamsCmd                `cmd:"" help:"Manage team memberships from a spec file"`
+	Whoami               whoamiCmd               `cmd:"" help:"Show who the api token belongs to, the organizations it can see and its scopes"`
+	Member               memberCmd               `cmd:"" help:"Show what is known about a member across orgs"`
+	History              historyCmd              `cmd:"" help:"Query the changes in members recorded by report runs"`
+	Forecast             forecastCmd             `cmd:"" help:"Project seats and their cost months ahead from the trend in seat history recorded by report runs"`
+	Allocate             allocateCmd             `cmd:"" help:"Allocate the cost of seats to teams, cost centers or domains, for chargeback"`
+	Reconcile            reconcileCmd            `cmd:"" help:"Cross-reference members with another directory of people, like a GitHub organization"`
+}
+
+// loadConfig loads the config file, using its orgs if none are provided by flags
+func (c *cli) loadConfig() error {
+	if c.Config == "" {
+		return nil
+	}
+
+	cfg, err := config.Load(c.Config)
 	if err != nil {
 		return err
 	}
 
+	if len(c.OrgSlugs) == 0 {
+		c.OrgSlugs = cfg.OrgSlugs()
+	}
+
+	c.config = cfg
+	return nil
+}
+
+func (c *cli) filterOptions() (report.FilterOptions, error) {
+	filter := report.FilterOptions{Email: c.Email}
+	if c.Filter != "" {
+		expression, err := report.CompileExpression(c.Filter)
+		if err != nil {
+			return filter, err
+		}
+		filter.Expression = expression
+	}
+	return filter, nil
+}
+
+// logf returns a Logf that logs if --debug is set
+func (c *cli) logf() report.Logf {
 	if c.Debug {
-		log.Printf("Found %d accounts over %d accounts", len(members), len(c.OrgSlugs))
+		return log.Printf
+	}
+	return nil
+}
+
+// fetchFunc returns a FetchFunc for org members that fetches each org once
+// per run, however many reports need it
+func (c *cli) fetchFunc(client *buildkite.Client) (report.FetchFunc, error) {
+	if c.fetch == nil {
+		fetch, err := c.newFetchFunc(client)
+		if err != nil {
+			return nil, err
+		}
+		c.fetch = report.MemoizedFetch(fetch)
+	}
+	return c.fetch, nil
+}
+
+// forgetMembers discards the members fetched so far, so the next FetchFunc
+// fetches them afresh, like on each refresh of a daemon
+func (c *cli) forgetMembers() {
+	c.fetch = nil
+}
+
+// newFetchFunc returns a FetchFunc for org members that checkpoints
+// pagination and uses the disk cache if enabled
+func (c *cli) newFetchFunc(client *buildkite.Client) (report.FetchFunc, error) {
+	if c.Offline {
+		return report.OfflineFetch(c.membersCacheDir()), nil
 	}
 
-	emails := make([]string, 0, len(members))
-	for _, member := range members {
-		emails = append(emails, member.Email)
+	fetch, err := report.CheckpointedFetch(filepath.Join(c.membersCacheDir(), "checkpoints"), client.GetOrgMembersPages, c.Resume, c.logf())
+	if err != nil {
+		return nil, err
 	}
 
-	sort.Strings(emails)
+	if c.Cache && c.CacheStrategy == `swr` {
+		return report.RevalidatingFetch(c.membersCacheDir(), fetch, log.Printf, &c.refreshes)
+	} else if c.Cache {
+		return report.CachedFetch(c.membersCacheDir(), fetch)
+	} else if c.FallbackToCache {
+		return report.FallbackFetch(c.membersCacheDir(), fetch, log.Printf)
+	}
 
-	result := []MemberWithDuplicates{}
+	return fetch, nil
+}
 
-	// iterate by sorted email
-	for _, email := range emails {
-		if c.Email != "" && c.Email != email {
-			continue
+// writesCache returns whether fetching members writes them to the cache dir,
+// which concurrent runs would race on
+func (c *cli) writesCache() bool {
+	return !c.Offline && (c.Cache || c.FallbackToCache)
+}
+
+// membersCacheDir returns the directory members are cached in, namespaced by
+// the token and endpoint so that tokens with different access to an org never
+// share cached members
+func (c *cli) membersCacheDir() string {
+	sum := sha256.Sum256([]byte(c.token + "\n" + c.Endpoint))
+	return filepath.Join(c.CacheDir, "members", hex.EncodeToString(sum[:6]))
+}
+
+// uncachedOrgSlugs returns the orgs whose members will be fetched from the
+// API rather than served from the cache, which swr serves and refreshes
+func (c *cli) uncachedOrgSlugs(orgSlugs []string) []string {
+	if !c.Cache || c.CacheStrategy == `swr` {
+		return orgSlugs
+	}
+
+	var result []string
+	for _, orgSlug := range orgSlugs {
+		if _, err := os.Stat(report.CacheFile(c.membersCacheDir(), orgSlug)); err != nil {
+			result = append(result, orgSlug)
 		}
-		byEmail := filterMembersByEmail(members, email)
-		member := byEmail[0]
-		byName := filterMembers(members, func(m Member) bool {
-			return m.Name == member.Name && m.Email != member.Email
-		})
-		result = append(result, MemberWithDuplicates{
-			Member:          member,
-			EmailDuplicates: byEmail[1:],
-			NameDuplicates:  byName,
-		})
 	}
+	return result
+}
 
-	var dedupeEmail bool
-	var dedupeName bool
+// waitForRefreshes waits for the cache to finish refreshing in the background,
+// after the output has been written
+func (c *cli) waitForRefreshes() {
+	if c.Debug {
+		log.Printf("Waiting for the cache to refresh")
+	}
+	c.refreshes.Wait()
+}
 
-	for _, d := range c.Dedupe {
-		if d == `email` {
-			dedupeEmail = true
-		} else if d == `name` {
-			dedupeName = true
+// groupOptions returns how duplicates are found from the global flags
+func (c *cli) groupOptions() (report.GroupOptions, error) {
+	if c.NameSimilarity < 0 || c.NameSimilarity > 1 {
+		return report.GroupOptions{}, fmt.Errorf("--name-similarity must be between 0 and 1, got %v", c.NameSimilarity)
+	}
+
+	opts := report.GroupOptions{NameSimilarity: c.NameSimilarity}
+	if c.NameLocale != "" {
+		locale, err := language.Parse(c.NameLocale)
+		if err != nil {
+			return report.GroupOptions{}, fmt.Errorf("--name-locale: %w", err)
 		}
+		opts.Locale = locale
 	}
+	return opts, nil
+}
 
-	// remove duplicates
-	if dedupeEmail || dedupeName {
-		dupeResults := []MemberWithDuplicates{}
-		seenMembers := make(map[string]bool)
+// buildReport loads and processes members, stopping early if interrupt is
+// closed, in which case the report is marked partial
+func (c *cli) buildReport(client *buildkite.Client, filter report.FilterOptions, interrupt <-chan struct{}) (*report.Report, error) {
+	groupOpts, err := c.groupOptions()
+	if err != nil {
+		return nil, err
+	}
 
-		for _, r := range result {
-			if _, ok := seenMembers[r.ID]; ok {
-				continue
-			}
-			dupeResults = append(dupeResults, r)
-			seenMembers[r.ID] = true
+	fetch, err := c.fetchFunc(client)
+	if err != nil {
+		return nil, err
+	}
 
-			if dedupeEmail {
-				for _, rr := range r.EmailDuplicates {
-					seenMembers[rr.ID] = true
-				}
-			}
-			if dedupeName {
-				for _, rr := range r.NameDuplicates {
-					seenMembers[rr.ID] = true
-				}
-			}
+	opts := report.LoadOptions{
+		Logf:            c.logf(),
+		ContinueOnError: c.ContinueOnError,
+		Interrupt:       interrupt,
+	}
+
+	orgSlugs, err := c.resolveOrgSlugs(client)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.BatchOrgs > 1 && !c.Offline {
+		client.BatchOrgMembers(c.uncachedOrgSlugs(orgSlugs), c.BatchOrgs)
+	}
+
+	_, span := tracing.Start(c.ctx, "load members", attribute.StringSlice("orgs", orgSlugs))
+	members, err := report.Load(fetch, orgSlugs, opts)
+	span.SetAttributes(attribute.Int("members", len(members)))
+	tracing.End(span, err)
+
+	var partialErr *report.PartialError
+	var interruptedErr *report.InterruptedError
+	if errors.As(err, &partialErr) {
+		for _, f := range partialErr.Failures {
+			log.Printf("Failed to load members of %s: %v", f.Org, f.Err)
+		}
+	} else if errors.As(err, &interruptedErr) {
+		if interruptedErr.Err != nil {
+			log.Printf("Stopped before loading %s: %v", strings.Join(interruptedErr.Remaining, ", "), interruptedErr.Err)
+		} else {
+			log.Printf("Interrupted before loading %s", strings.Join(interruptedErr.Remaining, ", "))
 		}
+	} else if err != nil {
+		return nil, err
+	}
 
-		result = dupeResults
+	if c.config != nil {
+		members = report.Label(members, c.config.OrgLabels())
 	}
 
-	if c.Output == `count` {
-		fmt.Println(len(result))
-	} else if c.Output == `json` {
-		s, _ := prettyjson.Marshal(result)
-		fmt.Println(string(s))
-	} else if c.Output == `csv` {
-		csvFile, err := os.Create("output.csv")
+	if c.EnrichCSV != "" {
+		enrichment, err := report.LoadEnrichment(c.EnrichCSV, c.EnrichKey, c.EnrichColumns)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		members, err = report.Enrich(members, enrichment)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		csvWriter := csv.NewWriter(csvFile)
-		csvWriter.Write([]string{"email", "name", "org", "role", "last_sso_auth"})
+	if !c.WithSSODetails {
+		for i := range members {
+			members[i].SSO = nil
+		}
+	}
 
-		for _, member := range members {
-			lastAuth := ""
+	if c.Debug {
+		log.Printf("Found %d accounts over %d accounts", len(members), len(orgSlugs))
+	}
 
-			if member.LastAuth != nil {
-				lastAuth = member.LastAuth.Format(`2006-01-02 15:04:05`)
-			}
+	dedupe := report.DedupeOptions{Explain: c.ExplainDedupe}
+	for _, d := range c.Dedupe {
+		if d == `email` {
+			dedupe.Email = true
+		} else if d == `name` {
+			dedupe.Name = true
+		}
+	}
+
+	_, span = tracing.Start(c.ctx, "group members")
+	result := report.Group(members, groupOpts)
+	report.AssignDuplicateGroups(members, result)
+	tracing.End(span, nil)
+
+	_, span = tracing.Start(c.ctx, "filter members")
+	result, err = report.Filter(result, filter)
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	_, span = tracing.Start(c.ctx, "dedupe members")
+	result = report.Dedupe(result, dedupe)
+	tracing.End(span, nil)
 
-			csvWriter.Write([]string{
-				member.Email,
-				member.Name,
-				member.Org,
-				member.Role,
-				lastAuth,
-			})
+	if c.ExplainDedupe {
+		for _, line := range report.ExplainDedupe(result) {
+			log.Printf("Dedupe %s", line)
 		}
+	}
 
-		csvWriter.Flush()
-		csvFile.Close()
+	rep := &report.Report{Orgs: orgSlugs, Members: members, Results: result}
+	if partialErr != nil {
+		rep.Failures = partialErr.Failures
+	}
+	if interruptedErr != nil {
+		rep.Orgs = interruptedErr.Loaded
+		rep.Partial = true
 	}
 
-	return nil
+	return rep, nil
 }
 
-func (c *cli) getMembers() ([]Member, error) {
-	client, err := buildkite.NewClient(c.APIToken)
+func (c *cli) newClient() (*buildkite.Client, error) {
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent()
+	}
+
+	backoff, err := report.ParseDuration(c.RetryBackoff)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := c.newBaseClient(
+		buildkite.WithUserAgent(userAgent),
+		buildkite.WithRetry(c.Retries, backoff),
+		buildkite.WithLogger(buildkite.Logf(c.logf())),
+		buildkite.WithTraceContext(c.ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cooldown, err := report.ParseDuration(c.CircuitCooldown)
 	if err != nil {
 		return nil, err
 	}
+	client.SetCircuitBreaker(c.CircuitBreaker, cooldown)
+	client.SetConcurrency(c.Concurrency)
+
+	c.client = client
+	return client, nil
+}
+
+// newBaseClient returns a client for the token and endpoint, with a transport
+// that reads or writes fixtures if they're in use
+func (c *cli) newBaseClient(opts ...buildkite.Option) (*buildkite.Client, error) {
+	newClient := func(token string, transport http.RoundTripper) (*buildkite.Client, error) {
+		// the run's clients share a transport, which is the same for all of
+		// them, so its metrics cover the whole run
+		if c.httpMetrics == nil {
+			c.httpMetrics = &buildkite.MetricsTransport{Transport: transport}
+		}
+		return buildkite.NewClient(token, append([]buildkite.Option{
+			buildkite.WithEndpoint(c.Endpoint),
+			buildkite.WithHTTPClient(&http.Client{Transport: c.httpMetrics}),
+		}, opts...)...)
+	}
 
-	getOrgMembers := client.GetOrgMembers
-	if c.Cache {
-		if err := os.MkdirAll(c.CacheDir, 0700); err != nil {
+	if c.Offline {
+		// the token finds its cache, but other token sources make network requests
+		if c.APITokenRef != "" || c.APITokenSecret != "" || c.APITokenVault != "" {
+			return nil, fmt.Errorf("--offline can only read the api token from --api-token or --api-token-file")
+		}
+		token, err := c.apiToken()
+		if err != nil {
 			return nil, err
 		}
+		if token == "" {
+			return nil, fmt.Errorf("--offline needs the api token the members were cached with, set --api-token or BUILDKITE_TOKEN")
+		}
+		c.token = token
+		return newClient(token, buildkite.OfflineTransport{})
+	}
 
-		// switch in a cached alternative
-		getOrgMembers = func(orgSlug string) ([]buildkite.OrgMember, error) {
-			cacheFile := filepath.Join(c.CacheDir, orgSlug+".json")
+	if c.Replay != "" {
+		// replayed fixtures don't need a real token
+		c.token = c.APIToken
+		return newClient(c.APIToken, &buildkite.ReplayTransport{Dir: c.Replay})
+	}
 
-			// serve from cache if it exists
-			if _, err := os.Stat(cacheFile); err == nil {
-				b, err := ioutil.ReadFile(cacheFile)
-				if err != nil {
-					return nil, err
-				}
+	token, err := c.apiToken()
+	if err != nil {
+		return nil, err
+	}
 
-				var result []buildkite.OrgMember
+	if token == "" {
+		return nil, fmt.Errorf("an api token is required, set --api-token or BUILDKITE_TOKEN, or a token source like --api-token-file")
+	}
 
-				if err := json.Unmarshal(b, &result); err != nil {
-					return nil, err
-				}
+	c.token = token
 
-				return result, nil
-			}
+	if c.Record != "" {
+		return newClient(token, &buildkite.RecordingTransport{Dir: c.Record})
+	}
 
-			// otherwise look up the org members form the API (slow)
-			members, err := client.GetOrgMembers(orgSlug)
-			if err != nil {
-				return nil, err
-			}
+	return newClient(token, nil)
+}
 
-			b, err := json.Marshal(members)
-			if err != nil {
-				return nil, err
-			}
+// printQueries prints the GraphQL queries a report would issue to fetch
+// members, expanding org slug patterns first, which needs the API
+func (c *cli) printQueries() error {
+	if c.Offline {
+		fmt.Printf("# None, --offline serves members from the cache\n")
+		return nil
+	}
 
-			// save in cache
-			err = ioutil.WriteFile(cacheFile, b, 0600)
-			if err != nil {
-				return nil, err
-			}
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
 
-			return members, nil
-		}
+	orgSlugs, literals, err := c.expandOrgSlugs(client)
+	if err != nil {
+		return err
 	}
 
-	result := []Member{}
-	for _, orgSlug := range c.OrgSlugs {
-		if c.Debug {
-			log.Printf("Finding members in %s", orgSlug)
+	for _, slug := range c.OrgSlugs {
+		if isOrgPattern(slug) {
+			fmt.Printf("# Expanded %s to %s from the orgs the token can see\n\n", strings.Join(c.OrgSlugs, ", "), strings.Join(orgSlugs, ", "))
+			break
 		}
-		t := time.Now()
+	}
 
-		members, err := getOrgMembers(orgSlug)
-		if err != nil {
-			return nil, err
+	for _, orgSlug := range literals {
+		query, vars := buildkite.OrganizationQuery(orgSlug)
+		if err := printQuery(fmt.Sprintf("# Check %s exists", orgSlug), query, vars); err != nil {
+			return err
 		}
+	}
 
-		for _, orgMember := range members {
-			m := Member{
-				ID:            orgMember.ID,
-				Email:         orgMember.Email,
-				Name:          orgMember.Name,
-				Org:           orgSlug,
-				Role:          strings.ToLower(orgMember.Role),
-				Complimentary: orgMember.Complimentary,
+	// batches are fetched like the client does, in order, leaving an org
+	// without others to batch it with to be fetched on its own
+	batched := map[string]bool{}
+	if c.BatchOrgs > 1 {
+		size := min(c.BatchOrgs, buildkite.MaxBatchOrgs)
+		pending := c.uncachedOrgSlugs(orgSlugs)
+		for len(pending) > 1 {
+			batch := pending[:min(size, len(pending))]
+			pending = pending[len(batch):]
+
+			query, vars, err := buildkite.OrgMembersBatchQuery(batch)
+			if err != nil {
+				return err
 			}
-
-			if orgMember.Authorization != nil {
-				m.Email = orgMember.Authorization.Email
-				m.LastAuth = &orgMember.Authorization.CreatedAt
+			if err := printQuery(fmt.Sprintf("# First pages of members of %s", strings.Join(batch, ", ")), query, vars); err != nil {
+				return err
 			}
-
-			domain, err := getEmailDomain(m.Email)
-			if err != nil {
-				return nil, err
+			for _, orgSlug := range batch {
+				batched[orgSlug] = true
 			}
-			m.Domain = domain
-
-			result = append(result, m)
 		}
+	}
 
-		if c.Debug {
-			log.Printf("Found %d responses in %v", len(members), time.Since(t))
+	for _, orgSlug := range orgSlugs {
+		query, vars := buildkite.OrgMembersQuery(orgSlug, "")
+
+		comment := fmt.Sprintf("# Members of %s", orgSlug)
+		if c.Cache {
+			comment += fmt.Sprintf("\n# Skipped if cached as %s for the token in %s", filepath.Base(report.CacheFile("", orgSlug)), c.CacheDir)
+		}
+		if batched[orgSlug] {
+			comment += "\n# Only after the first page from the batch, with $after set to its pageInfo.endCursor while pageInfo.hasNextPage is true"
+		} else {
+			comment += "\n# Repeated with $after set to pageInfo.endCursor while pageInfo.hasNextPage is true"
+		}
+		if err := printQuery(comment, query, vars); err != nil {
+			return err
 		}
 	}
 
-	return result, nil
+	return nil
 }
 
-func filterMembers(members []Member, f func(m Member) bool) (matching []Member) {
-	for _, m := range members {
-		if f(m) {
-			matching = append(matching, m)
-		}
+// printQuery prints a GraphQL query and its variables under a comment
+func printQuery(comment string, query string, vars map[string]interface{}) error {
+	b, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return err
 	}
-	return
+
+	fmt.Printf("%s\n%s\n\nVariables: %s\n\n", comment, strings.TrimSpace(query), b)
+	return nil
 }
 
-func filterMembersByEmail(members []Member, email string) (matching []Member) {
-	return filterMembers(members, func(m Member) bool {
-		return m.Email == email
-	})
+func printStats(stats buildkite.Stats) {
+	log.Printf("API usage: %d requests, %d bytes sent, %d bytes received in %v",
+		stats.Requests, stats.BytesSent, stats.BytesReceived, stats.Duration)
+
+	orgSlugs := make([]string, 0, len(stats.Orgs))
+	for orgSlug := range stats.Orgs {
+		orgSlugs = append(orgSlugs, orgSlug)
+	}
+	sort.Strings(orgSlugs)
+
+	for _, orgSlug := range orgSlugs {
+		o := stats.Orgs[orgSlug]
+		log.Printf("API usage for %s: %d pages, %d members in %v", orgSlug, o.Pages, o.Members, o.Duration)
+	}
+
+	if stats.Rejected > 0 {
+		log.Printf("Request budget: rejected %d requests", stats.Rejected)
+	}
+
+	if stats.RateLimit > 0 {
+		log.Printf("Rate limit: consumed %d of %d points (%d remaining)",
+			stats.RateLimitConsumed(), stats.RateLimit, stats.RateLimitRemaining)
+	}
 }
 
-func filterMembersByName(members []Member, name string) (matching []Member) {
-	return filterMembers(members, func(m Member) bool {
-		return m.Name == name
+// printTimings prints how much of the run was spent on the API, decoding
+// members and everything else, then the pages of each org, slowest first
+func printTimings(stats buildkite.Stats, total time.Duration) {
+	var decode time.Duration
+	orgSlugs := make([]string, 0, len(stats.Orgs))
+	for orgSlug, o := range stats.Orgs {
+		decode += o.Decode
+		orgSlugs = append(orgSlugs, orgSlug)
+	}
+	sort.Slice(orgSlugs, func(i, j int) bool {
+		return stats.Orgs[orgSlugs[i]].Duration > stats.Orgs[orgSlugs[j]].Duration
 	})
-}
 
-func getEmailDomain(email string) (string, error) {
-	at := strings.LastIndex(email, "@")
-	if at >= 0 {
-		_, domain := email[:at], email[at+1:]
-		return domain, nil
+	// concurrent requests can add up to more than the run took
+	processing := total - stats.Duration - decode
+	if processing < 0 {
+		processing = 0
+	}
+
+	log.Printf("Timings: %v in total, %v waiting on %d API requests, %v decoding members and %v processing",
+		total, stats.Duration, stats.Requests, decode, processing)
+
+	for _, orgSlug := range orgSlugs {
+		o := stats.Orgs[orgSlug]
+
+		var fetching, slowest time.Duration
+		for _, d := range o.PageDurations {
+			fetching += d
+			if d > slowest {
+				slowest = d
+			}
+		}
+
+		var perPage time.Duration
+		if len(o.PageDurations) > 0 {
+			perPage = fetching / time.Duration(len(o.PageDurations))
+		}
+
+		log.Printf("Timings for %s: %d pages in %v, %v per page and %v for the slowest, %v decoding",
+			orgSlug, o.Pages, o.Duration, perPage, slowest, o.Decode)
 	}
-	return "", fmt.Errorf("%s is an invalid email address", email)
 }
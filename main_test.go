@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/lox/buildkite-accounter/buildkite"
+	"github.com/lox/buildkite-accounter/buildkite/buildkitetest"
+)
+
+// runCLI runs the command line against the fake server like main does, with
+// the cache and audit log in a temporary directory
+func runCLI(t *testing.T, s *buildkitetest.Server, args ...string) error {
+	t.Helper()
+
+	dir := t.TempDir()
+	args = append([]string{
+		"--api-token", "test-token",
+		"--endpoint", s.URL,
+		"--cache-dir", filepath.Join(dir, "cache"),
+		"--audit-log", filepath.Join(dir, "audit.log"),
+	}, args...)
+
+	c := &cli{ctx: context.Background()}
+	parser, err := kong.New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := parser.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if err := c.loadConfig(); err != nil {
+		return err
+	}
+	c.resolveCacheDir()
+
+	return ctx.Run(c)
+}
+
+// orgEmails returns the emails of the members of an org on the fake server
+func orgEmails(t *testing.T, s *buildkitetest.Server, orgSlug string) []string {
+	t.Helper()
+
+	client, err := s.Client("test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	members, err := client.GetOrgMembers(orgSlug)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var emails []string
+	for _, m := range members {
+		emails = append(emails, m.Email)
+	}
+	return emails
+}
+
+// neverAuthorized returns a member without an SSO authorization, which is
+// stale however long --stale-after is
+func neverAuthorized(id, email string) buildkite.OrgMember {
+	return buildkite.OrgMember{ID: id, Name: id, Email: email, Role: "MEMBER"}
+}
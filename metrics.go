@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+
+	"github.com/lox/buildkite-accounter/internal/metrics"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+// publishers returns the metrics publishers configured by flags
+func (c *cli) publishers() []metrics.Publisher {
+	var publishers []metrics.Publisher
+
+	if c.DatadogAPIKey != "" {
+		publishers = append(publishers, &metrics.Datadog{
+			APIKey: c.DatadogAPIKey,
+			Site:   c.DatadogSite,
+		})
+	}
+
+	if c.StatsdAddr != "" {
+		publishers = append(publishers, &metrics.StatsD{
+			Addr:      c.StatsdAddr,
+			DogStatsD: c.StatsdFormat == `dogstatsd`,
+		})
+	}
+
+	if c.CloudwatchNamespace != "" {
+		publishers = append(publishers, &metrics.CloudWatch{
+			Namespace: c.CloudwatchNamespace,
+		})
+	}
+
+	if c.PushgatewayURL != "" {
+		publishers = append(publishers, &metrics.Pushgateway{
+			URL:      c.PushgatewayURL,
+			Job:      c.PushgatewayJob,
+			Instance: c.PushgatewayInstance,
+		})
+	}
+
+	return publishers
+}
+
+// publishMetrics publishes run gauges and seat gauges for the report, if there
+// is one, to every configured backend
+func (c *cli) publishMetrics(rep *report.Report, run metrics.Run) error {
+	publishers := c.publishers()
+	if len(publishers) == 0 {
+		return nil
+	}
+
+	staleAfter, err := report.ParseDuration(c.StaleAfter)
+	if err != nil {
+		return err
+	}
+
+	gauges := run.Gauges()
+	if c.httpMetrics != nil {
+		gauges = append(gauges, metrics.HTTPGauges(c.httpMetrics.Metrics())...)
+	}
+	if rep != nil {
+		gauges = append(gauges, metrics.Gauges(rep, staleAfter)...)
+	}
+
+	for _, p := range publishers {
+		if err := p.Publish(gauges); err != nil {
+			return err
+		}
+	}
+
+	if c.Debug {
+		log.Printf("Published %d gauges to %d metrics backends", len(gauges), len(publishers))
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/lox/buildkite-accounter/buildkite"
+)
+
+// isOrgPattern returns whether an org slug is a glob pattern like acme-*
+func isOrgPattern(slug string) bool {
+	return strings.ContainsAny(slug, "*?[")
+}
+
+func matchesAny(patterns []string, slug string) (bool, error) {
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, slug)
+		if err != nil {
+			return false, fmt.Errorf("invalid org slug pattern %q: %w", pattern, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// includesOrg returns whether an org is selected by --org-slugs, or by default
+// if there are none, and isn't excluded, without asking the API
+func (c *cli) includesOrg(slug string) (bool, error) {
+	if len(c.OrgSlugs) > 0 {
+		ok, err := matchesAny(c.OrgSlugs, slug)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	excluded, err := matchesAny(c.ExcludeOrgSlugs, slug)
+	if err != nil {
+		return false, err
+	}
+	return !excluded, nil
+}
+
+// resolveOrgSlugs expands org slug patterns against the orgs the token can see
+// and removes excluded orgs, then checks the orgs that were listed exist
+func (c *cli) resolveOrgSlugs(client *buildkite.Client) ([]string, error) {
+	slugs, literals, err := c.expandOrgSlugs(client)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Offline {
+		// a missing org fails to load from the cache instead
+		return slugs, nil
+	}
+
+	if err := validateOrgSlugs(client, literals); err != nil {
+		return nil, err
+	}
+
+	return slugs, nil
+}
+
+// expandOrgSlugs expands org slug patterns against the orgs the token can see
+// and removes excluded orgs, returning the orgs and those of them that were
+// listed rather than matched by a pattern, which aren't known to exist
+func (c *cli) expandOrgSlugs(client *buildkite.Client) ([]string, []string, error) {
+	var patterns []string
+	for _, slug := range c.OrgSlugs {
+		if isOrgPattern(slug) {
+			patterns = append(patterns, slug)
+		}
+	}
+
+	if c.Offline && len(patterns) > 0 {
+		return nil, nil, fmt.Errorf("org patterns like %s can't be expanded with --offline, list the org slugs instead", patterns[0])
+	}
+
+	var orgs []buildkite.Organization
+	if len(patterns) > 0 {
+		var err error
+		orgs, err = client.GetOrganizations()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	seen := map[string]bool{}
+	var slugs []string
+	literal := map[string]bool{}
+
+	add := func(slug string) error {
+		excluded, err := matchesAny(c.ExcludeOrgSlugs, slug)
+		if err != nil {
+			return err
+		}
+		if !excluded && !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+		return nil
+	}
+
+	for _, slug := range c.OrgSlugs {
+		if !isOrgPattern(slug) {
+			if err := add(slug); err != nil {
+				return nil, nil, err
+			}
+			literal[slug] = true
+			continue
+		}
+
+		for _, org := range orgs {
+			ok, err := path.Match(slug, org.Slug)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid org slug pattern %q: %w", slug, err)
+			}
+			if ok {
+				if err := add(org.Slug); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	if c.Debug && len(patterns) > 0 {
+		log.Printf("Expanded org slugs %v to %v", c.OrgSlugs, slugs)
+	}
+
+	// orgs matched by patterns are known to exist
+	var literals []string
+	for _, slug := range slugs {
+		if literal[slug] {
+			literals = append(literals, slug)
+		}
+	}
+
+	return slugs, literals, nil
+}
+
+// validateOrgSlugs checks that orgs exist before fetching anything from them,
+// so a typo fails fast rather than deep into a run
+func validateOrgSlugs(client *buildkite.Client, orgSlugs []string) error {
+	var missing []string
+	for _, slug := range orgSlugs {
+		_, err := client.GetOrganization(slug)
+		if errors.Is(err, buildkite.ErrOrgNotFound) {
+			missing = append(missing, slug)
+		} else if errors.Is(err, buildkite.ErrUnavailable) {
+			// leave it to fetching, which may fall back to the cache
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if len(missing) > 0 {
+		return &orgsNotFoundError{slugs: missing}
+	}
+	return nil
+}
+
+// orgsNotFoundError is org slugs that failed validation
+type orgsNotFoundError struct {
+	slugs []string
+}
+
+func (e *orgsNotFoundError) Error() string {
+	if len(e.slugs) == 1 {
+		return fmt.Sprintf("organization '%s' not found or token lacks access", e.slugs[0])
+	}
+	return fmt.Sprintf("organizations '%s' not found or token lacks access", strings.Join(e.slugs, "', '"))
+}
+
+// Is matches buildkite.ErrOrgNotFound
+func (e *orgsNotFoundError) Is(target error) bool {
+	return target == buildkite.ErrOrgNotFound
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+	"github.com/mattn/go-isatty"
+)
+
+// outputTarget is an output format and the path it's written to, or - for stdout
+type outputTarget struct {
+	format string
+	path   string
+	// changesOnly writes only the changes since the last run
+	changesOnly bool
+}
+
+// changesFormats are the output formats that support --changes-only
+var changesFormats = map[string]bool{`json`: true, `csv`: true, `count`: true, `gsheet`: true}
+
+// exportFormats are output formats written to an external service rather
+// than a file, as the rows of the csv format
+var exportFormats = map[string]bool{`gsheet`: true}
+
+// binaryFormats are output formats that would garble a terminal, so are only
+// written to stdout when it's redirected
+var binaryFormats = map[string]bool{`pdf`: true}
+
+// isTerminal returns whether a file is a terminal
+var isTerminal = func(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// outputTargets parses --output values like json or csv=members.csv
+func (r *reportCmd) outputTargets() ([]outputTarget, error) {
+	var targets []outputTarget
+	var hasJSON bool
+
+	for _, spec := range r.Output {
+		t := outputTarget{format: spec, path: "-", changesOnly: r.ChangesOnly}
+
+		if idx := strings.Index(spec, "="); idx >= 0 {
+			t.format, t.path = spec[:idx], spec[idx+1:]
+		} else if t.format == `csv` {
+			// csv has always been written to a file by default
+			t.path = "output.csv"
+		}
+
+		// delta is the changes since the last run in --delta-format
+		if t.format == `delta` {
+			t.format, t.changesOnly = r.DeltaFormat, true
+		}
+
+		if !report.IsOutputFormat(t.format) && !exportFormats[t.format] {
+			return nil, fmt.Errorf("unknown output format %q, expected one of delta, gsheet, %s",
+				t.format, strings.Join(report.OutputFormats(), ", "))
+		}
+
+		if t.format == `gsheet` && r.SheetID == "" {
+			return nil, fmt.Errorf("--output gsheet requires --sheet-id")
+		}
+
+		if t.format == `json` {
+			hasJSON = true
+		}
+
+		if t.changesOnly && !changesFormats[t.format] {
+			return nil, fmt.Errorf("--changes-only isn't supported with --output %s", t.format)
+		}
+
+		if binaryFormats[t.format] && t.path == "-" && isTerminal(os.Stdout) {
+			return nil, fmt.Errorf("--output %s is binary, write it to a file with --output %s=report.%s or redirect stdout", t.format, t.format, t.format)
+		}
+
+		if t.format == `template` && r.ReportTemplate == "" {
+			return nil, fmt.Errorf("--output template requires --report-template")
+		}
+
+		targets = append(targets, t)
+	}
+
+	if r.Query != "" && !hasJSON {
+		return nil, fmt.Errorf("--query is only supported with --output json")
+	}
+
+	return targets, nil
+}
+
+func (r *reportCmd) writeOutput(t outputTarget, rep *report.Report, query *report.Query, tmpl *template.Template) error {
+	if exportFormats[t.format] {
+		return r.writeExport(t, rep)
+	}
+
+	f := os.Stdout
+	if t.path != "-" {
+		var err error
+		f, err = os.Create(t.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+	}
+
+	// pretty print with colors for humans, compact for pipes and files
+	tty := isTerminal(f)
+	opts := report.OutputOptions{
+		Color:   tty && !r.NoColor && os.Getenv(`NO_COLOR`) == "",
+		Compact: !tty || r.Compact,
+
+		ChangesOnly: t.changesOnly,
+	}
+
+	if t.format == `json` {
+		opts.Query = query
+	}
+
+	if t.format == `template` {
+		opts.Template = tmpl
+	}
+
+	out, err := report.NewOutputWriter(t.format, f, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := out.Write(rep); err != nil {
+		return err
+	}
+
+	return out.Flush()
+}
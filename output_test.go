@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOutputTargetsBinaryToTerminal(t *testing.T) {
+	defer func(f func(*os.File) bool) { isTerminal = f }(isTerminal)
+
+	tests := []struct {
+		name     string
+		output   string
+		terminal bool
+		err      string
+	}{
+		{name: "pdf to a terminal", output: "pdf", terminal: true, err: "--output pdf=report.pdf"},
+		{name: "pdf to a terminal explicitly", output: "pdf=-", terminal: true, err: "--output pdf=report.pdf"},
+		{name: "pdf to a pipe", output: "pdf"},
+		{name: "pdf to a file", output: "pdf=report.pdf", terminal: true},
+		{name: "json to a terminal", output: "json", terminal: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			isTerminal = func(*os.File) bool { return tc.terminal }
+
+			r := &reportCmd{Output: []string{tc.output}}
+			_, err := r.outputTargets()
+			if tc.err == "" && err != nil {
+				t.Fatalf("outputTargets() error = %v", err)
+			} else if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Fatalf("outputTargets() error = %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
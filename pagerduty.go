@@ -0,0 +1,53 @@
+package main
+
+import (
+	"log"
+
+	"github.com/lox/buildkite-accounter/internal/notify"
+	"github.com/lox/buildkite-accounter/internal/report"
+)
+
+// alertPagerDuty triggers a PagerDuty alert for each threshold an org
+// breaches, and resolves the alerts of thresholds that are no longer breached
+func (r *reportCmd) alertPagerDuty(rep *report.Report) error {
+	breaches, kinds := report.CheckThresholds(rep.Orgs, rep.Members, report.Thresholds{
+		MaxSeats:       r.MaxSeats,
+		MaxAdmins:      r.MaxAdmins,
+		AllowedDomains: r.AllowedDomains,
+	})
+
+	pd := &notify.PagerDuty{RoutingKey: r.PagerdutyRoutingKey}
+	dedupKey := func(org, kind string) string {
+		return "buildkite-accounter/" + org + "/" + kind
+	}
+
+	breached := map[string]bool{}
+	for _, b := range breaches {
+		key := dedupKey(b.Org, b.Kind)
+		breached[key] = true
+
+		log.Printf("Threshold breached: %s", b.Summary)
+		err := pd.Trigger(notify.Alert{
+			DedupKey: key,
+			Summary:  b.Summary,
+			Severity: r.PagerdutySeverity,
+			Source:   "buildkite-accounter",
+			Details:  b.Details,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, org := range rep.Orgs {
+		for _, kind := range kinds {
+			if key := dedupKey(org, kind); !breached[key] {
+				if err := pd.Resolve(key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
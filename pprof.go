@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+)
+
+// startProfiling starts writing a CPU profile to --cpuprofile, returning a
+// func that stops it and writes a heap profile to --memprofile
+func (c *cli) startProfiling() (func(), error) {
+	var cpu *os.File
+
+	if c.CPUProfile != "" {
+		f, err := os.Create(c.CPUProfile)
+		if err != nil {
+			return nil, fmt.Errorf("--cpuprofile: %w", err)
+		}
+		if err := runtimepprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("--cpuprofile: %w", err)
+		}
+		cpu = f
+	}
+
+	return func() {
+		if cpu != nil {
+			runtimepprof.StopCPUProfile()
+			if err := cpu.Close(); err != nil {
+				log.Printf("Failed to write CPU profile: %v", err)
+			}
+		}
+
+		if c.MemProfile != "" {
+			if err := writeHeapProfile(c.MemProfile); err != nil {
+				log.Printf("Failed to write memory profile: %v", err)
+			}
+		}
+	}, nil
+}
+
+// writeHeapProfile writes a heap profile as of the last garbage collection to path
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	// collect garbage so the profile is up to date
+	runtime.GC()
+
+	if err := runtimepprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// servePprof serves the net/http/pprof endpoints on addr under /debug/pprof/,
+// on a listener of their own so they're never exposed with the daemon's
+func servePprof(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	log.Printf("Serving pprof on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Failed to serve pprof: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/lox/buildkite-accounter/internal/config"
+)
+
+// BeforeResolve resolves flags that weren't set on the command line or by
+// environment variables from the profile selected with --profile
+func (c *cli) BeforeResolve(ctx *kong.Context) error {
+	profile, err := loadProfile(ctx)
+	if err != nil {
+		return err
+	}
+
+	if profile != nil {
+		ctx.AddResolver(profileResolver(profile))
+	}
+
+	return nil
+}
+
+func profileResolver(profile config.Profile) kong.Resolver {
+	return kong.ResolverFunc(func(ctx *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		if flag.Tag.Env != "" && os.Getenv(flag.Tag.Env) != "" {
+			return nil, nil
+		}
+
+		v, _ := profile.Value(flag.Name)
+		return v, nil
+	})
+}
+
+// loadProfile loads the profile selected with --profile, if any, checking that
+// it only sets known flags
+func loadProfile(ctx *kong.Context) (config.Profile, error) {
+	var name string
+	for _, f := range ctx.Flags() {
+		if f.Name == "profile" {
+			name, _ = ctx.FlagValue(f).(string)
+		}
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	path, err := config.DefaultProfilesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := config.LoadProfiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile %q: %w", name, err)
+	}
+
+	profile, err := profiles.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := map[string]bool{}
+	_ = kong.Visit(ctx.Model, func(node kong.Visitable, next kong.Next) error {
+		if f, ok := node.(*kong.Flag); ok {
+			flags[strings.ReplaceAll(f.Name, "-", "_")] = true
+		}
+		return next(nil)
+	})
+
+	for _, key := range profile.Keys() {
+		if !flags[key] || key == "profile" {
+			return nil, fmt.Errorf("profile %q sets unknown flag %q", name, key)
+		}
+	}
+
+	return profile, nil
+}
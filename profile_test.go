@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lox/buildkite-accounter/buildkite/buildkitetest"
+)
+
+func TestProfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("BUILDKITE_ACCOUNTER_PROFILE", "")
+
+	path := filepath.Join(dir, "buildkite-accounter", "config")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	profiles := "profiles:\n  acme:\n    org_slugs: [acme]\n  typo:\n    org-slug: acme\n"
+	if err := os.WriteFile(path, []byte(profiles), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		profile string
+		want    string
+		err     string
+	}{
+		{name: "org slugs", profile: "acme", want: "2\n"},
+		{name: "unknown profile", profile: "llama", err: `no profile named "llama"`},
+		{name: "unknown flag", profile: "typo", err: `sets unknown flag "org_slug"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := buildkitetest.NewServer()
+			defer s.Close()
+			s.AddOrg("acme", neverAuthorized("alice", "alice@acme.com"), neverAuthorized("bob", "bob@acme.com"))
+			s.AddOrg("llama", neverAuthorized("carol", "carol@llama.com"))
+
+			out := filepath.Join(t.TempDir(), "count")
+			err := runCLI(t, s, "--profile", tc.profile, "report", "--output", "count="+out)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("report error = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("counted %q members, want %q", got, tc.want)
+			}
+		})
+	}
+}
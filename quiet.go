@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lox/buildkite-accounter/internal/report"
+	"github.com/lox/buildkite-accounter/internal/state"
+)
+
+// membershipDigest is a digest of the org memberships and roles in a report,
+// deliberately ignoring fields like last_auth that change on every run
+func membershipDigest(rep *report.Report) string {
+	lines := make([]string, 0, len(rep.Members))
+	for _, m := range rep.Members {
+		lines = append(lines, strings.Join([]string{m.Org, m.ID, m.Email, m.Role}, "\t"))
+	}
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// stateStore returns the store for state kept between runs
+func (c *cli) stateStore() (state.Store, error) {
+	if c.StateStore == "" {
+		return &state.File{Dir: c.CacheDir}, nil
+	}
+	return state.Open(c.StateStore)
+}
+
+// lastRunKey returns the key of state kept about the last run for the current
+// set of orgs
+func (c *cli) lastRunKey(ext string) string {
+	orgSlugs := append([]string{}, c.OrgSlugs...)
+	sort.Strings(orgSlugs)
+	sum := sha256.Sum256([]byte(strings.Join(orgSlugs, ",")))
+	return fmt.Sprintf("last-run-%x.%s", sum[:6], ext)
+}
+
+// changedSinceLastRun returns whether the memberships in the report differ from
+// the previous run, recording the report as the new previous run
+func (c *cli) changedSinceLastRun(store state.Store, rep *report.Report) (bool, error) {
+	digest := membershipDigest(rep)
+	key := c.lastRunKey("digest")
+
+	previous, err := store.Get(key)
+	if err != nil && !errors.Is(err, state.ErrNotFound) {
+		return false, err
+	}
+
+	if err := store.Put(key, []byte(digest)); err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(string(previous)) != digest, nil
+}
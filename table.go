@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// writeTable writes v as JSON, or the header and rows as CSV or an aligned table
+func writeTable(format string, v interface{}, header []string, rows [][]string) error {
+	switch format {
+	case `json`:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+
+	case `csv`:
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write(header)
+		for _, row := range rows {
+			_ = w.Write(row)
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(header, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/lock"
+	"github.com/lox/buildkite-accounter/internal/report"
+	_ "modernc.org/sqlite"
+)
+
+// timeseriesColumns are the columns of --append-timeseries, in order
+var timeseriesColumns = []string{"timestamp", "org", "total", "paid", "complimentary", "stale", "duplicates"}
+
+// timeseriesRow summarizes the seats of an org at the time of a run
+type timeseriesRow struct {
+	Time          time.Time
+	Org           string
+	Total         int
+	Paid          int
+	Complimentary int
+	Stale         int
+	Duplicates    int
+}
+
+// timeseriesRows summarizes each org in the report. Duplicates are the
+// members with email or name duplicates in any org
+func timeseriesRows(rep *report.Report, staleAfter time.Duration, t time.Time) []timeseriesRow {
+	orgs := map[string]*timeseriesRow{}
+	for _, org := range rep.Orgs {
+		orgs[org] = &timeseriesRow{Time: t.UTC(), Org: org}
+	}
+
+	for _, m := range rep.Members {
+		row, ok := orgs[m.Org]
+		if !ok {
+			continue
+		}
+		row.Total++
+		if m.Complimentary {
+			row.Complimentary++
+		} else {
+			row.Paid++
+		}
+		if m.IsStale(staleAfter) {
+			row.Stale++
+		}
+		if m.DuplicateGroup != "" {
+			row.Duplicates++
+		}
+	}
+
+	rows := make([]timeseriesRow, 0, len(orgs))
+	for _, row := range orgs {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Org < rows[j].Org })
+	return rows
+}
+
+// appendTimeseries appends rows to a CSV file, or a seats table of an SQLite
+// database if the path ends in .db, .sqlite or .sqlite3, creating either if
+// it doesn't exist
+func appendTimeseries(path string, rows []timeseriesRow) error {
+	l, err := lock.Acquire(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".db", ".sqlite", ".sqlite3":
+		return appendTimeseriesSQLite(path, rows)
+	}
+	return appendTimeseriesCSV(path, rows)
+}
+
+func appendTimeseriesCSV(path string, rows []timeseriesRow) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if info.Size() == 0 {
+		_ = w.Write(timeseriesColumns)
+	}
+	for _, r := range rows {
+		_ = w.Write([]string{
+			r.Time.Format(time.RFC3339),
+			r.Org,
+			strconv.Itoa(r.Total),
+			strconv.Itoa(r.Paid),
+			strconv.Itoa(r.Complimentary),
+			strconv.Itoa(r.Stale),
+			strconv.Itoa(r.Duplicates),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func appendTimeseriesSQLite(path string, rows []timeseriesRow) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS seats (
+		timestamp TEXT NOT NULL,
+		org TEXT NOT NULL,
+		total INTEGER NOT NULL,
+		paid INTEGER NOT NULL,
+		complimentary INTEGER NOT NULL,
+		stale INTEGER NOT NULL,
+		duplicates INTEGER NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create the seats table in %s: %w", path, err)
+	}
+
+	for _, r := range rows {
+		if _, err := tx.Exec(`INSERT INTO seats (timestamp, org, total, paid, complimentary, stale, duplicates) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			r.Time.Format(time.RFC3339), r.Org, r.Total, r.Paid, r.Complimentary, r.Stale, r.Duplicates); err != nil {
+			return fmt.Errorf("failed to insert into %s: %w", path, err)
+		}
+	}
+
+	return tx.Commit()
+}
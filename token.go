@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/lox/buildkite-accounter/internal/secrets"
+)
+
+// apiToken returns the API token from whichever token source was configured
+func (c *cli) apiToken() (string, error) {
+	switch {
+	case c.APITokenFile != "":
+		return readTokenFile(c.APITokenFile)
+	case c.APITokenRef != "":
+		return secrets.NewOnePassword().Read(c.APITokenRef)
+	case c.APITokenSecret != "":
+		return secrets.ReadAWSSecret(c.APITokenSecret)
+	case c.APITokenVault != "":
+		vault, err := secrets.NewVault()
+		if err != nil {
+			return "", err
+		}
+		return vault.Read(c.APITokenVault)
+	}
+	return c.APIToken, nil
+}
+
+// readTokenFile reads a token from a file, or stdin if path is -
+func readTokenFile(path string) (string, error) {
+	var b []byte
+	var err error
+
+	if path == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read api token: %w", err)
+	}
+
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("api token file %s is empty", path)
+	}
+
+	return token, nil
+}
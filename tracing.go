@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lox/buildkite-accounter/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// startTracing starts the run's span, exported if the OTLP environment
+// variables are set, returning a func that ends it with the run's error and
+// flushes the spans
+func (c *cli) startTracing(command string) (func(error), error) {
+	ctx, shutdown, err := tracing.Setup(context.Background(), currentVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	// the span is named for the command, without its arguments
+	var name []string
+	for _, word := range strings.Fields(command) {
+		if !strings.HasPrefix(word, "<") {
+			name = append(name, word)
+		}
+	}
+
+	ctx, span := tracing.Start(ctx, strings.Join(name, " "),
+		attribute.String("command", command),
+		attribute.StringSlice("orgs", c.OrgSlugs),
+	)
+	c.ctx = ctx
+
+	return func(err error) {
+		tracing.End(span, err)
+
+		// an unreachable collector shouldn't hold up the end of the run
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdown(ctx); err != nil {
+			log.Printf("Failed to export traces: %v", err)
+		}
+	}, nil
+}
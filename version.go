@@ -0,0 +1,23 @@
+package main
+
+import "runtime/debug"
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3"
+var version = ""
+
+// currentVersion returns the version set at build time, falling back to the
+// module version for go install builds
+func currentVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// defaultUserAgent returns the User-Agent sent when --user-agent isn't set
+func defaultUserAgent() string {
+	return "buildkite-accounter/" + currentVersion()
+}